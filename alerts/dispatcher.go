@@ -0,0 +1,339 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// Defaults of Dispatcher
+const (
+	DefaultPollInterval = time.Minute
+	// DefaultMaxPollInterval is the longest interval Run backs off to after failed polls
+	DefaultMaxPollInterval = 10 * time.Minute
+	DefaultLookback        = time.Hour
+	DefaultSubjectTemplate = `{{.Alert.Asset}} {{.Alert.Alert}} is {{.Alert.Status}}`
+	DefaultMessageTemplate = `Alert {{.Alert.Alert}} of {{.Alert.Asset}} changed from {{.Previous}} to {{.Alert.Status}} at {{.Alert.Time}}` +
+		`{{with .Alert.Value}}, value {{.}}{{end}}{{with .Alert.Threshold}}, threshold {{.}}{{end}}.`
+)
+
+// Dispatcher polls asset alerts, or takes them from other source like Evaluator, and delivers
+// every status transition to notifiers. Alerts are deduplicated per asset and alert, so repeated
+// records and records which do not change status are not delivered. Notifications which failed are
+// queued per notifier and delivered again by next Dispatch, in order.
+type Dispatcher struct {
+	client          api.ClientWithResponsesInterface
+	assets          []string
+	alerts          []string
+	notifiers       []Notifier
+	pollInterval    time.Duration
+	maxPollInterval time.Duration
+	activeOnly      bool
+	subject         *template.Template
+	message         *template.Template
+	reqEditors      []api.RequestEditorFn
+	onError         func(error)
+
+	mu     sync.Mutex
+	since  time.Time
+	states map[string]alertState
+
+	// deliveryMu serializes deliveries, so queued notifications keep order
+	deliveryMu sync.Mutex
+	pending    [][]Notification
+}
+
+type alertState struct {
+	status api.AssetAlertStatus
+	time   string
+}
+
+// DispatcherOption allows to customize Dispatcher
+type DispatcherOption func(*Dispatcher)
+
+// WithDispatchInterval sets interval between polls of Run
+func WithDispatchInterval(interval time.Duration) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.pollInterval = interval
+	}
+}
+
+// WithMaxDispatchInterval sets the longest interval Run backs off to after failed polls
+func WithMaxDispatchInterval(max time.Duration) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.maxPollInterval = max
+	}
+}
+
+// WithDispatchErrorHandler receives errors of polls which Run recovers from, by default they are logged
+func WithDispatchErrorHandler(fn func(error)) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.onError = fn
+	}
+}
+
+// WithSince sets time from which alerts are polled, default is DefaultLookback before dispatcher is created
+func WithSince(since time.Time) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.since = since
+	}
+}
+
+// WithActiveOnly delivers only transitions to active status
+func WithActiveOnly() DispatcherOption {
+	return func(d *Dispatcher) {
+		d.activeOnly = true
+	}
+}
+
+// WithTemplates sets text/template of subject and message, templates are executed with Notification
+func WithTemplates(subject, message *template.Template) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.subject = subject
+		d.message = message
+	}
+}
+
+// WithDispatcherRequestEditors are passed to every call made by dispatcher
+func WithDispatcherRequestEditors(fns ...api.RequestEditorFn) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.reqEditors = append(d.reqEditors, fns...)
+	}
+}
+
+// NewDispatcher creates dispatcher of alerts of assets to notifiers, client is only needed by Poll and Run
+func NewDispatcher(client api.ClientWithResponsesInterface, assets, alerts []string, notifiers []Notifier, opts ...DispatcherOption) *Dispatcher {
+	d := Dispatcher{
+		client:          client,
+		assets:          assets,
+		alerts:          alerts,
+		notifiers:       notifiers,
+		pollInterval:    DefaultPollInterval,
+		maxPollInterval: DefaultMaxPollInterval,
+		since:           time.Now().Add(-DefaultLookback),
+		subject:         template.Must(template.New(`subject`).Parse(DefaultSubjectTemplate)),
+		message:         template.Must(template.New(`message`).Parse(DefaultMessageTemplate)),
+		onError: func(err error) {
+			log.Printf(`alerts: %s`, err)
+		},
+		states:  map[string]alertState{},
+		pending: make([][]Notification, len(notifiers)),
+	}
+	for _, opt := range opts {
+		opt(&d)
+	}
+	return &d
+}
+
+// Run polls alerts until ctx is done. Transient api errors, like rate limit, and failed deliveries are passed to error
+// handler and polling continues with interval doubled up to max interval, other errors stop Run.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	interval := d.pollInterval
+	for {
+		err := d.Poll(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil && !recoverable(err) {
+			return err
+		}
+		if err == nil {
+			interval = d.pollInterval
+		} else {
+			d.onError(err)
+			if interval *= 2; interval > d.maxPollInterval {
+				interval = d.maxPollInterval
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Poll fetches alerts since latest polled alert and dispatches them
+func (d *Dispatcher) Poll(ctx context.Context) error {
+	d.mu.Lock()
+	since := d.since
+	d.mu.Unlock()
+	pageSize := api.PageSize(constants.DefaultPageSize)
+	startTime := api.StartTime(FormatTime(since))
+	params := api.GetAssetAlertsParams{
+		Assets:    api.AssetId(strings.Join(d.assets, `,`)),
+		Alerts:    api.AssetAlertId(d.alerts),
+		StartTime: &startTime,
+		PageSize:  &pageSize,
+	}
+	var alerts []api.AssetAlert
+	for {
+		res, err := d.client.GetAssetAlertsWithResponse(ctx, &params, d.reqEditors...)
+		if err != nil {
+			return err
+		}
+		if res.JSON200 == nil {
+			return coinmetrics.NewApiError(res.StatusCode(), res.Body)
+		}
+		alerts = append(alerts, res.JSON200.Data...)
+		if res.JSON200.NextPageToken == nil {
+			break
+		}
+		params.NextPageToken = res.JSON200.NextPageToken
+	}
+	err := d.Dispatch(ctx, alerts)
+	var deliveryErr *DeliveryError
+	if err != nil && !errors.As(err, &deliveryErr) {
+		return err
+	}
+	// failed notifications are queued, so polled alerts are not fetched again
+	d.mu.Lock()
+	for _, alert := range alerts {
+		if t, err := time.Parse(time.RFC3339Nano, alert.Time); err == nil && t.After(d.since) {
+			d.since = t
+		}
+	}
+	d.mu.Unlock()
+	return err
+}
+
+// DeliveryError is returned by Dispatch when notifiers fail, failed notifications stay queued for next Dispatch
+type DeliveryError struct {
+	// Err is the first error of notifiers
+	Err error
+	// Pending is number of queued notifications
+	Pending int
+}
+
+// Error returns error of notifier
+func (e *DeliveryError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns error of notifier
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// Dispatch delivers status transitions among alerts, which can come from any source, to all notifiers.
+// Alerts are processed in time order, those not newer than last dispatched alert of same asset and alert are skipped.
+// Queued notifications are delivered first. All notifiers are tried, notifications a notifier fails to deliver are
+// queued together with all following ones for it, and *DeliveryError is returned.
+func (d *Dispatcher) Dispatch(ctx context.Context, alerts []api.AssetAlert) error {
+	alerts = append([]api.AssetAlert(nil), alerts...)
+	sort.SliceStable(alerts, func(i, j int) bool {
+		return alerts[i].Time < alerts[j].Time
+	})
+	var notifications []Notification
+	for _, alert := range alerts {
+		notification, ok, err := d.transition(alert)
+		if err != nil {
+			return err
+		}
+		if ok {
+			notifications = append(notifications, notification)
+		}
+	}
+	return d.deliver(ctx, notifications)
+}
+
+// deliver sends queued and new notifications to every notifier, delivery to notifier stops at its first failure
+func (d *Dispatcher) deliver(ctx context.Context, notifications []Notification) error {
+	d.deliveryMu.Lock()
+	defer d.deliveryMu.Unlock()
+	var firstErr error
+	pending := 0
+	for i, notifier := range d.notifiers {
+		queue := append(d.pending[i], notifications...)
+		d.pending[i] = nil
+		for j, notification := range queue {
+			if err := notifier.Notify(ctx, notification); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				d.pending[i] = append([]Notification(nil), queue[j:]...)
+				break
+			}
+		}
+		pending += len(d.pending[i])
+	}
+	if firstErr != nil {
+		return &DeliveryError{Err: firstErr, Pending: pending}
+	}
+	return nil
+}
+
+// Pending returns number of queued notifications of all notifiers
+func (d *Dispatcher) Pending() int {
+	d.deliveryMu.Lock()
+	defer d.deliveryMu.Unlock()
+	pending := 0
+	for _, queue := range d.pending {
+		pending += len(queue)
+	}
+	return pending
+}
+
+// transition records alert and returns rendered notification when it changes status. Alert is not recorded when
+// rendering fails, so it is not lost.
+func (d *Dispatcher) transition(alert api.AssetAlert) (Notification, bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := alert.Asset + `/` + string(alert.Alert)
+	previous, ok := d.states[key]
+	if !ok {
+		previous.status = Inactive
+	}
+	if ok && alert.Time <= previous.time {
+		return Notification{}, false, nil
+	}
+	notify := alert.Status != previous.status && (!d.activeOnly || alert.Status == Active)
+	notification := Notification{Alert: alert, Previous: previous.status}
+	if notify {
+		if err := d.render(&notification); err != nil {
+			return Notification{}, false, err
+		}
+	}
+	d.states[key] = alertState{status: alert.Status, time: alert.Time}
+	return notification, notify, nil
+}
+
+func (d *Dispatcher) render(notification *Notification) error {
+	var subject, message bytes.Buffer
+	if err := d.subject.Execute(&subject, notification); err != nil {
+		return err
+	}
+	if err := d.message.Execute(&message, notification); err != nil {
+		return err
+	}
+	notification.Subject = subject.String()
+	notification.Message = message.String()
+	return nil
+}
+
+// recoverable tells whether Run continues after error, like rate limit, server errors, network errors and failed
+// deliveries
+func recoverable(err error) bool {
+	var deliveryErr *DeliveryError
+	if errors.As(err, &deliveryErr) {
+		return true
+	}
+	var apiError coinmetrics.ApiError
+	if errors.As(err, &apiError) {
+		return apiError.StatusCode == http.StatusTooManyRequests || apiError.StatusCode >= http.StatusInternalServerError
+	}
+	var urlError *url.Error
+	return errors.As(err, &urlError)
+}
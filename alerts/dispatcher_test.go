@@ -0,0 +1,166 @@
+package alerts_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/rulesng/coinmetrics-go-sdk/alerts"
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+const testAlertsPage1 = `{"data":[
+  {"asset":"btc","time":"2021-06-08T11:48:21.000000000Z","alert":"mempool_count_empty_5m","value":"0","threshold":"0","status":"active"},
+  {"asset":"btc","time":"2021-06-08T12:48:21.000000000Z","alert":"mempool_vsize_hi","value":"323","threshold":"300","status":"active"}
+],"next_page_token":"page2"}`
+
+const testAlertsPage2 = `{"data":[
+  {"asset":"btc","time":"2021-06-08T12:50:00.000000000Z","alert":"mempool_vsize_hi","value":"330","threshold":"300","status":"active"},
+  {"asset":"btc","time":"2021-06-08T13:48:21.000000000Z","alert":"mempool_count_empty_5m","value":"1","threshold":"0","status":"inactive"}
+]}`
+
+func registerAssetAlerts(t *testing.T, starts *[]string) {
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf(`%s%s/timeseries/asset-alerts`, constants.TestEndpoint, constants.ApiVersion), func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, `btc`, req.URL.Query().Get(`assets`))
+		assert.Equal(t, `mempool_count_empty_5m,mempool_vsize_hi`, req.URL.Query().Get(`alerts`))
+		body := testAlertsPage1
+		if req.URL.Query().Get(`next_page_token`) == `page2` {
+			body = testAlertsPage2
+		} else {
+			*starts = append(*starts, req.URL.Query().Get(`start_time`))
+		}
+		resp := httpmock.NewStringResponse(http.StatusOK, body)
+		resp.Header.Set(`Content-Type`, `application/json`)
+		return resp, nil
+	})
+}
+
+func TestDispatcherPoll(t *testing.T) {
+	var starts []string
+	registerAssetAlerts(t, &starts)
+	recorder := alerts.NewRecorder(nil)
+	dispatcher := alerts.NewDispatcher(_coinmetrics, []string{`btc`}, []string{`mempool_count_empty_5m`, `mempool_vsize_hi`}, []alerts.Notifier{recorder},
+		alerts.WithSince(time.Date(2021, time.June, 8, 0, 0, 0, 0, time.UTC)))
+	ctx := context.Background()
+	assert.Nil(t, dispatcher.Poll(ctx))
+	// same records again are deduplicated
+	assert.Nil(t, dispatcher.Poll(ctx))
+	assert.Equal(t, []string{`2021-06-08T00:00:00.000000000Z`, `2021-06-08T13:48:21.000000000Z`}, starts)
+
+	var subjects []string
+	for _, notification := range recorder.Notifications() {
+		subjects = append(subjects, notification.Subject)
+	}
+	assert.Equal(t, []string{
+		`btc mempool_count_empty_5m is active`,
+		`btc mempool_vsize_hi is active`,
+		`btc mempool_count_empty_5m is inactive`,
+	}, subjects)
+	assert.Equal(t, `Alert mempool_count_empty_5m of btc changed from active to inactive at 2021-06-08T13:48:21.000000000Z, value 1, threshold 0.`,
+		recorder.Notifications()[2].Message)
+}
+
+func TestDispatcherActiveOnlyAndTemplates(t *testing.T) {
+	recorder := alerts.NewRecorder(nil)
+	failing := alerts.NewRecorder(errors.New(`unavailable`))
+	dispatcher := alerts.NewDispatcher(nil, nil, nil, []alerts.Notifier{failing, recorder}, alerts.WithActiveOnly(),
+		alerts.WithTemplates(template.Must(template.New(`subject`).Parse(`[{{.Alert.Status}}] {{.Alert.Alert}}`)), template.Must(template.New(`message`).Parse(`{{.Alert.Asset}}`))))
+
+	evaluated := []api.AssetAlert{
+		{Asset: `eth`, Alert: `gas_hi`, Status: alerts.Inactive, Time: `2021-06-08T10:00:00.000000000Z`},
+		{Asset: `eth`, Alert: `gas_hi`, Status: alerts.Active, Time: `2021-06-08T11:00:00.000000000Z`},
+		{Asset: `eth`, Alert: `gas_hi`, Status: alerts.Inactive, Time: `2021-06-08T12:00:00.000000000Z`},
+	}
+	err := dispatcher.Dispatch(context.Background(), evaluated)
+	assert.EqualError(t, err, `unavailable`)
+	assert.Equal(t, 1, len(recorder.Notifications()))
+	assert.Equal(t, `[active] gas_hi`, recorder.Notifications()[0].Subject)
+	assert.Equal(t, `eth`, recorder.Notifications()[0].Message)
+	assert.Equal(t, alerts.Inactive, recorder.Notifications()[0].Previous)
+}
+
+func TestDispatcherQueuesFailedDeliveries(t *testing.T) {
+	recorder := alerts.NewRecorder(nil)
+	var delivered []alerts.Notification
+	down := true
+	flaky := alerts.NotifierFunc(func(ctx context.Context, notification alerts.Notification) error {
+		if down {
+			return errors.New(`unavailable`)
+		}
+		delivered = append(delivered, notification)
+		return nil
+	})
+	dispatcher := alerts.NewDispatcher(nil, nil, nil, []alerts.Notifier{flaky, recorder})
+	ctx := context.Background()
+	err := dispatcher.Dispatch(ctx, []api.AssetAlert{
+		{Asset: `btc`, Alert: `mempool_vsize_hi`, Status: alerts.Active, Time: `2021-06-08T10:00:00.000000000Z`},
+	})
+	var deliveryErr *alerts.DeliveryError
+	if assert.True(t, errors.As(err, &deliveryErr)) {
+		assert.Equal(t, 1, deliveryErr.Pending)
+	}
+	assert.Equal(t, 1, dispatcher.Pending())
+
+	// notification queued behind failed one is not delivered out of order
+	err = dispatcher.Dispatch(ctx, []api.AssetAlert{
+		{Asset: `btc`, Alert: `mempool_vsize_hi`, Status: alerts.Inactive, Time: `2021-06-08T11:00:00.000000000Z`},
+	})
+	assert.EqualError(t, err, `unavailable`)
+	assert.Equal(t, 2, dispatcher.Pending())
+	assert.Nil(t, delivered)
+
+	down = false
+	assert.Nil(t, dispatcher.Dispatch(ctx, nil))
+	assert.Equal(t, 0, dispatcher.Pending())
+	if assert.Len(t, delivered, 2) {
+		assert.Equal(t, alerts.Active, delivered[0].Alert.Status)
+		assert.Equal(t, alerts.Inactive, delivered[1].Alert.Status)
+	}
+	// notifier which did not fail gets every notification once
+	assert.Len(t, recorder.Notifications(), 2)
+}
+
+func TestDispatcherRunRecovers(t *testing.T) {
+	var starts []string
+	responder := httpmock.NewStringResponder(http.StatusTooManyRequests, `{"error":{"type":"rate_limit","message":"Requests rate limit exceeded."}}`)
+	url := fmt.Sprintf(`%s%s/timeseries/asset-alerts`, constants.TestEndpoint, constants.ApiVersion)
+	httpmock.RegisterResponder(http.MethodGet, url, func(req *http.Request) (*http.Response, error) {
+		// rate limited once, then alerts are served
+		registerAssetAlerts(t, &starts)
+		return responder(req)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var errs []error
+	recorder := alerts.NewRecorder(nil)
+	dispatcher := alerts.NewDispatcher(_coinmetrics, []string{`btc`}, []string{`mempool_count_empty_5m`, `mempool_vsize_hi`}, []alerts.Notifier{alerts.NotifierFunc(
+		func(ctx context.Context, notification alerts.Notification) error {
+			err := recorder.Notify(ctx, notification)
+			if len(recorder.Notifications()) == 3 {
+				cancel()
+			}
+			return err
+		})},
+		alerts.WithSince(time.Date(2021, time.June, 8, 0, 0, 0, 0, time.UTC)), alerts.WithDispatchInterval(time.Millisecond), alerts.WithMaxDispatchInterval(10*time.Millisecond),
+		alerts.WithDispatchErrorHandler(func(err error) {
+			errs = append(errs, err)
+		}))
+	assert.ErrorIs(t, dispatcher.Run(ctx), context.Canceled)
+	if assert.Len(t, errs, 1) {
+		assert.EqualError(t, errs[0], `api error 429 rate_limit: Requests rate limit exceeded.`)
+	}
+	assert.Len(t, recorder.Notifications(), 3)
+
+	// other errors stop Run
+	httpmock.RegisterResponder(http.MethodGet, url, httpmock.NewStringResponder(http.StatusForbidden, `{"error":{"type":"forbidden","message":"Requested resource is not available with supplied credentials."}}`))
+	err := alerts.NewDispatcher(_coinmetrics, []string{`btc`}, nil, nil).Run(context.Background())
+	assert.EqualError(t, err, `api error 403 forbidden: Requested resource is not available with supplied credentials.`)
+}
@@ -0,0 +1,277 @@
+package alerts
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// Comparison tells how alerting value is compared against threshold of condition
+type Comparison string
+
+// Comparisons of alert conditions
+const (
+	// AtLeast breaches when value is greater than or equal to threshold
+	AtLeast Comparison = ">="
+	// AtMost breaches when value is less than or equal to threshold
+	AtMost Comparison = "<="
+)
+
+// ComparisonOf infers comparison from name of rule, rules named `*_lo` breach at most threshold, others at least threshold
+func ComparisonOf(rule api.AssetAlertRuleName) Comparison {
+	if strings.HasSuffix(string(rule), `_lo`) {
+		return AtMost
+	}
+	return AtLeast
+}
+
+// condition is parsed sub-rule, value is first constituent, or ratio of first to second constituent
+type condition struct {
+	constituents []string
+	threshold    float64
+	comparison   Comparison
+}
+
+// met evaluates condition against latest metrics, ok is false while some constituent is missing.
+// Value is nil when divisor of ratio is zero, condition is not met then.
+func (c condition) met(metrics map[string]float64) (value *float64, met, ok bool) {
+	v, ok := metrics[c.constituents[0]]
+	if !ok {
+		return nil, false, false
+	}
+	if len(c.constituents) == 2 {
+		divisor, ok := metrics[c.constituents[1]]
+		if !ok {
+			return nil, false, false
+		}
+		if divisor == 0 {
+			return nil, false, true
+		}
+		v /= divisor
+	}
+	if c.comparison == AtMost {
+		return &v, v <= c.threshold, true
+	}
+	return &v, v >= c.threshold, true
+}
+
+type rule struct {
+	info       api.AssetAlertRuleInfo
+	conditions []condition
+}
+
+type ruleState struct {
+	active bool
+	// time since which conditions are not met while rule is active
+	recovering time.Time
+}
+
+// Evaluator evaluates asset alert rules locally against observed asset metrics and emits alerts,
+// in the shape of `/timeseries/asset-alerts`, whenever rule becomes active or inactive.
+// Rule is active when all of its conditions are met.
+type Evaluator struct {
+	rules       []rule
+	recovery    time.Duration
+	comparisons map[api.AssetAlertRuleName]Comparison
+
+	mu      sync.Mutex
+	metrics map[string]map[string]float64
+	states  map[string]*ruleState
+}
+
+// EvaluatorOption allows to customize Evaluator
+type EvaluatorOption func(*Evaluator)
+
+// WithComparison overrides comparison inferred from name of rule
+func WithComparison(name string, comparison Comparison) EvaluatorOption {
+	return func(e *Evaluator) {
+		e.comparisons[api.AssetAlertRuleName(name)] = comparison
+	}
+}
+
+// WithRecoveryPeriod keeps rule active until its conditions are not met for whole period
+func WithRecoveryPeriod(period time.Duration) EvaluatorOption {
+	return func(e *Evaluator) {
+		e.recovery = period
+	}
+}
+
+// NewEvaluator creates evaluator of rules, which can be loaded with LoadRules or defined in the same schema.
+// Conditions must have threshold and one constituent, or two constituents forming ratio.
+func NewEvaluator(rules []api.AssetAlertRuleInfo, opts ...EvaluatorOption) (*Evaluator, error) {
+	e := Evaluator{
+		comparisons: map[api.AssetAlertRuleName]Comparison{},
+		metrics:     map[string]map[string]float64{},
+		states:      map[string]*ruleState{},
+	}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	for _, info := range rules {
+		comparison, ok := e.comparisons[info.Name]
+		if !ok {
+			comparison = ComparisonOf(info.Name)
+		}
+		r := rule{info: info}
+		for _, sub := range info.Conditions {
+			if sub.Threshold == nil || len(sub.Constituents) == 0 || len(sub.Constituents) > 2 {
+				return nil, fmt.Errorf(`%s: %s %s`, constants.UnsupportedAlertRule, info.Asset, info.Name)
+			}
+			threshold, err := strconv.ParseFloat(string(*sub.Threshold), 64)
+			if err != nil {
+				return nil, err
+			}
+			c := condition{threshold: threshold, comparison: comparison}
+			for _, constituent := range sub.Constituents {
+				c.constituents = append(c.constituents, string(constituent))
+			}
+			r.conditions = append(r.conditions, c)
+		}
+		if len(r.conditions) == 0 {
+			return nil, fmt.Errorf(`%s: %s %s`, constants.UnsupportedAlertRule, info.Asset, info.Name)
+		}
+		e.rules = append(e.rules, r)
+	}
+	return &e, nil
+}
+
+// Observe records metric values of asset at time t and returns alerts of rules which changed status, ordered by rule name.
+// Metrics may be observed in parts, latest value of every metric is used.
+func (e *Evaluator) Observe(asset string, t time.Time, metrics map[string]string) ([]api.AssetAlert, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	latest, ok := e.metrics[asset]
+	if !ok {
+		latest = map[string]float64{}
+		e.metrics[asset] = latest
+	}
+	for metric, value := range metrics {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		latest[metric] = parsed
+	}
+
+	var alerts []api.AssetAlert
+	for _, r := range e.rules {
+		if string(r.info.Asset) != asset {
+			continue
+		}
+		alert, changed := e.evaluate(r, latest, t)
+		if changed {
+			alerts = append(alerts, alert)
+		}
+	}
+	sort.SliceStable(alerts, func(i, j int) bool {
+		return alerts[i].Alert < alerts[j].Alert
+	})
+	return alerts, nil
+}
+
+// ObserveRow observes row of asset metrics as returned by `/timeseries/asset-metrics`, fields other than
+// `asset` and `time` which hold numeric strings are taken as metrics
+func (e *Evaluator) ObserveRow(row map[string]interface{}) ([]api.AssetAlert, error) {
+	asset, _ := row[`asset`].(string)
+	rawTime, _ := row[`time`].(string)
+	t, err := time.Parse(time.RFC3339Nano, rawTime)
+	if err != nil {
+		return nil, err
+	}
+	metrics := map[string]string{}
+	for key, value := range row {
+		if value, ok := value.(string); ok && key != `asset` && key != `time` {
+			if _, err := strconv.ParseFloat(value, 64); err == nil {
+				metrics[key] = value
+			}
+		}
+	}
+	return e.Observe(asset, t, metrics)
+}
+
+// Statuses returns current status of every rule of asset by rule name
+func (e *Evaluator) Statuses(asset string) map[string]api.AssetAlertStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	statuses := map[string]api.AssetAlertStatus{}
+	for _, r := range e.rules {
+		if string(r.info.Asset) != asset {
+			continue
+		}
+		statuses[string(r.info.Name)] = Inactive
+		if state, ok := e.states[stateKey(r.info)]; ok && state.active {
+			statuses[string(r.info.Name)] = Active
+		}
+	}
+	return statuses
+}
+
+func stateKey(info api.AssetAlertRuleInfo) string {
+	return fmt.Sprintf(`%s/%s`, info.Asset, info.Name)
+}
+
+// evaluate updates state of rule, e.mu must be held
+func (e *Evaluator) evaluate(r rule, metrics map[string]float64, t time.Time) (api.AssetAlert, bool) {
+	met := true
+	var value *float64
+	for _, c := range r.conditions {
+		v, conditionMet, ok := c.met(metrics)
+		if !ok {
+			return api.AssetAlert{}, false
+		}
+		value = v
+		met = met && conditionMet
+	}
+
+	key := stateKey(r.info)
+	state, ok := e.states[key]
+	if !ok {
+		state = &ruleState{}
+		e.states[key] = state
+	}
+	switch {
+	case met:
+		state.recovering = time.Time{}
+		if state.active {
+			return api.AssetAlert{}, false
+		}
+		state.active = true
+	case !state.active:
+		return api.AssetAlert{}, false
+	default:
+		if state.recovering.IsZero() {
+			state.recovering = t
+		}
+		if t.Sub(state.recovering) < e.recovery {
+			return api.AssetAlert{}, false
+		}
+		state.active = false
+		state.recovering = time.Time{}
+	}
+
+	alert := api.AssetAlert{
+		Alert:  api.AssetAlertName(r.info.Name),
+		Asset:  string(r.info.Asset),
+		Status: Inactive,
+		Time:   FormatTime(t),
+	}
+	if state.active {
+		alert.Status = Active
+	}
+	// value and threshold are omitted for rules of multiple conditions, like api does
+	if len(r.info.Conditions) == 1 {
+		threshold := api.AssetAlertThreshold(*r.info.Conditions[0].Threshold)
+		alert.Threshold = &threshold
+		if value != nil {
+			formatted := api.AssetAlertValue(strconv.FormatFloat(*value, 'f', -1, 64))
+			alert.Value = &formatted
+		}
+	}
+	return alert, true
+}
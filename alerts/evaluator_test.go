@@ -0,0 +1,114 @@
+package alerts_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/rulesng/coinmetrics-go-sdk/alerts"
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+const testRules = `[
+  {"asset":"btc","name":"mempool_vsize_hi","conditions":[{"description":"Mempool is large.","threshold":"300","constituents":["mempool_vsize"]}]},
+  {"asset":"btc","name":"hashrate_lo","conditions":[{"description":"Hash rate dropped.","threshold":"100","constituents":["HashRate"]}]},
+  {"asset":"btc","name":"fee_share_hi","conditions":[
+    {"description":"Fees are half of revenue.","threshold":"0.5","constituents":["fees","revenue"]},
+    {"description":"At least two blocks.","threshold":"2","constituents":["count"]}
+  ]},
+  {"asset":"eth","name":"mempool_vsize_hi","conditions":[{"description":"Mempool is large.","threshold":"300","constituents":["mempool_vsize"]}]}
+]`
+
+func testEvaluator(t *testing.T, opts ...alerts.EvaluatorOption) *alerts.Evaluator {
+	var rules []api.AssetAlertRuleInfo
+	assert.Nil(t, json.Unmarshal([]byte(testRules), &rules))
+	evaluator, err := alerts.NewEvaluator(rules, opts...)
+	assert.Nil(t, err)
+	return evaluator
+}
+
+func describe(alerts []api.AssetAlert) []string {
+	var descriptions []string
+	for _, alert := range alerts {
+		description := fmt.Sprintf(`%s %s %s %s`, alert.Asset, alert.Alert, alert.Status, alert.Time)
+		if alert.Value != nil {
+			description += fmt.Sprintf(` value=%s threshold=%s`, *alert.Value, *alert.Threshold)
+		}
+		descriptions = append(descriptions, description)
+	}
+	return descriptions
+}
+
+func TestEvaluatorTransitions(t *testing.T) {
+	evaluator := testEvaluator(t)
+	start := time.Date(2021, time.June, 8, 11, 48, 21, 0, time.UTC)
+
+	fired, err := evaluator.Observe(`btc`, start, map[string]string{`mempool_vsize`: `323`, `HashRate`: `150`})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`btc mempool_vsize_hi active 2021-06-08T11:48:21.000000000Z value=323 threshold=300`}, describe(fired))
+
+	fired, err = evaluator.Observe(`btc`, start.Add(time.Minute), map[string]string{`mempool_vsize`: `310`})
+	assert.Nil(t, err)
+	assert.Nil(t, fired)
+
+	fired, err = evaluator.Observe(`btc`, start.Add(2*time.Minute), map[string]string{`mempool_vsize`: `200`, `HashRate`: `90`})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		`btc hashrate_lo active 2021-06-08T11:50:21.000000000Z value=90 threshold=100`,
+		`btc mempool_vsize_hi inactive 2021-06-08T11:50:21.000000000Z value=200 threshold=300`,
+	}, describe(fired))
+
+	// ratio with zero divisor is not met, rules of multiple conditions omit value and threshold
+	fired, err = evaluator.Observe(`btc`, start.Add(3*time.Minute), map[string]string{`fees`: `5`, `revenue`: `0`, `count`: `3`})
+	assert.Nil(t, err)
+	assert.Nil(t, fired)
+	fired, err = evaluator.Observe(`btc`, start.Add(4*time.Minute), map[string]string{`revenue`: `8`})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`btc fee_share_hi active 2021-06-08T11:52:21.000000000Z`}, describe(fired))
+
+	assert.Equal(t, map[string]api.AssetAlertStatus{
+		`mempool_vsize_hi`: alerts.Inactive,
+		`hashrate_lo`:      alerts.Active,
+		`fee_share_hi`:     alerts.Active,
+	}, evaluator.Statuses(`btc`))
+	assert.Equal(t, alerts.Inactive, evaluator.Statuses(`eth`)[`mempool_vsize_hi`])
+}
+
+func TestEvaluatorRecoveryPeriod(t *testing.T) {
+	evaluator := testEvaluator(t, alerts.WithRecoveryPeriod(5*time.Minute), alerts.WithComparison(`hashrate_lo`, alerts.AtLeast))
+	fired, err := evaluator.ObserveRow(map[string]interface{}{`asset`: `btc`, `time`: `2021-06-08T11:00:00.000000000Z`, `mempool_vsize`: `400`, `HashRate`: `150`})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(fired))
+
+	for minute, expected := range []int{0, 0, 0, 0, 0, 1} {
+		fired, err = evaluator.ObserveRow(map[string]interface{}{`asset`: `btc`, `time`: fmt.Sprintf(`2021-06-08T11:%02d:00Z`, minute+1), `mempool_vsize`: `100`})
+		assert.Nil(t, err)
+		assert.Equal(t, expected, len(fired))
+	}
+	assert.Equal(t, alerts.Inactive, fired[0].Status)
+	assert.Equal(t, `2021-06-08T11:06:00.000000000Z`, fired[0].Time)
+}
+
+func TestEvaluatorUnsupportedRule(t *testing.T) {
+	_, err := alerts.NewEvaluator([]api.AssetAlertRuleInfo{{Asset: `btc`, Name: `variable_hi`, Conditions: api.AssetAlertSubRules{{Constituents: api.AssetAlertSubRuleConstituents{`metric`}}}}})
+	assert.EqualError(t, err, constants.UnsupportedAlertRule+`: btc variable_hi`)
+}
+
+func TestLoadRules(t *testing.T) {
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf(`%s%s/catalog/alerts`, constants.TestEndpoint, constants.ApiVersion), func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, `btc`, req.URL.Query().Get(`assets`))
+		resp := httpmock.NewStringResponse(http.StatusOK, fmt.Sprintf(`{"data":%s}`, testRules))
+		resp.Header.Set(`Content-Type`, `application/json`)
+		return resp, nil
+	})
+	rules, err := alerts.LoadRules(context.Background(), _coinmetrics, []string{`btc`})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(rules))
+	assert.Equal(t, api.AssetAlertRuleName(`fee_share_hi`), rules[2].Name)
+}
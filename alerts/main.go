@@ -0,0 +1,40 @@
+package alerts
+
+import (
+	"context"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+)
+
+// Statuses of asset alerts
+const (
+	Active   api.AssetAlertStatus = "active"
+	Inactive api.AssetAlertStatus = "inactive"
+)
+
+// timeLayout is format of times returned by api, always with nanoseconds precision
+const timeLayout = `2006-01-02T15:04:05.000000000Z07:00`
+
+// FormatTime formats t the way api formats times
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// LoadRules fetches alert rules of assets from `/catalog/alerts`, all rules are returned when no asset is given
+func LoadRules(ctx context.Context, client api.ClientWithResponsesInterface, assets []string, reqEditors ...api.RequestEditorFn) ([]api.AssetAlertRuleInfo, error) {
+	params := api.GetCatalogAssetAlertRulesParams{}
+	if len(assets) > 0 {
+		ids := api.CatalogAssetId(assets)
+		params.Assets = &ids
+	}
+	res, err := client.GetCatalogAssetAlertRulesWithResponse(ctx, &params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	if res.JSON200 == nil {
+		return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+	}
+	return res.JSON200.Data, nil
+}
@@ -0,0 +1,24 @@
+package alerts_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+var _coinmetrics coinmetrics.CoinMetrics
+
+func TestMain(m *testing.M) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	var _err error
+	_coinmetrics, _err = coinmetrics.InitClient(constants.TestEndpoint, constants.TestKey)
+	if _err != nil {
+		fmt.Println(_err)
+	}
+	os.Exit(m.Run())
+}
@@ -0,0 +1,165 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"sync"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// Notification is alert status transition delivered by notifiers, Subject and Message are rendered from templates
+type Notification struct {
+	Alert    api.AssetAlert       `json:"alert"`
+	Previous api.AssetAlertStatus `json:"previous_status"`
+	Subject  string               `json:"subject"`
+	Message  string               `json:"message"`
+}
+
+// Notifier delivers notifications
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// NotifierFunc allows to use function as Notifier
+type NotifierFunc func(ctx context.Context, notification Notification) error
+
+// Notify calls f
+func (f NotifierFunc) Notify(ctx context.Context, notification Notification) error {
+	return f(ctx, notification)
+}
+
+// WebhookNotifier POSTs notifications as JSON to url
+type WebhookNotifier struct {
+	url    string
+	client api.HttpRequestDoer
+}
+
+// NewWebhookNotifier creates webhook notifier, http.DefaultClient is used when client is nil
+func NewWebhookNotifier(url string, client api.HttpRequestDoer) *WebhookNotifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WebhookNotifier{url: url, client: client}
+}
+
+// Notify POSTs notification, responses other than 2xx are errors
+func (n *WebhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(`Content-Type`, `application/json`)
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		response, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf(`%s: %d %s`, constants.WebhookFailed, res.StatusCode, strings.TrimSpace(string(response)))
+	}
+	return nil
+}
+
+// LogNotifier writes notifications to writer, one per line, as JSON or as subject and message
+type LogNotifier struct {
+	mu   sync.Mutex
+	w    io.Writer
+	json bool
+}
+
+// NewLogNotifier creates notifier writing text lines, use os.Stdout to write to standard output
+func NewLogNotifier(w io.Writer) *LogNotifier {
+	return &LogNotifier{w: w}
+}
+
+// NewJSONLogNotifier creates notifier writing JSON lines
+func NewJSONLogNotifier(w io.Writer) *LogNotifier {
+	return &LogNotifier{w: w, json: true}
+}
+
+// Notify writes notification line
+func (n *LogNotifier) Notify(ctx context.Context, notification Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.json {
+		return json.NewEncoder(n.w).Encode(notification)
+	}
+	_, err := fmt.Fprintf(n.w, "%s %s: %s\n", notification.Alert.Time, notification.Subject, notification.Message)
+	return err
+}
+
+// MailSender sends mail, smtp.SendMail satisfies it
+type MailSender func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// MailNotifier sends notifications by mail through SMTP server
+type MailNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+	send MailSender
+}
+
+// NewMailNotifier creates mail notifier sending through SMTP server at addr, smtp.SendMail is used when send is nil
+func NewMailNotifier(addr string, auth smtp.Auth, from string, to []string, send MailSender) *MailNotifier {
+	if send == nil {
+		send = smtp.SendMail
+	}
+	return &MailNotifier{addr: addr, auth: auth, from: from, to: to, send: send}
+}
+
+// Notify sends notification with subject and message. Subject is rendered from alert data, so line breaks are replaced
+// by spaces and other than printable ASCII is encoded, it can not inject headers.
+func (n *MailNotifier) Notify(ctx context.Context, notification Notification) error {
+	subject := strings.NewReplacer("\r\n", ` `, "\r", ` `, "\n", ` `).Replace(notification.Subject)
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.to, `, `))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode(`UTF-8`, subject))
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(notification.Message, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+	return n.send(n.addr, n.auth, n.from, n.to, msg.Bytes())
+}
+
+// Recorder keeps notifications in memory, it is meant as test double of notifiers
+type Recorder struct {
+	mu            sync.Mutex
+	notifications []Notification
+	err           error
+}
+
+// NewRecorder creates recorder, every notification is recorded and err is returned for it
+func NewRecorder(err error) *Recorder {
+	return &Recorder{err: err}
+}
+
+// Notify records notification
+func (r *Recorder) Notify(ctx context.Context, notification Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications = append(r.notifications, notification)
+	return r.err
+}
+
+// Notifications returns recorded notifications
+func (r *Recorder) Notifications() []Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Notification(nil), r.notifications...)
+}
@@ -0,0 +1,88 @@
+package alerts_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/smtp"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/rulesng/coinmetrics-go-sdk/alerts"
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+var testNotification = alerts.Notification{
+	Alert:    api.AssetAlert{Asset: `btc`, Alert: `mempool_vsize_hi`, Status: alerts.Active, Time: `2021-06-08T12:48:21.000000000Z`},
+	Previous: alerts.Inactive,
+	Subject:  `btc mempool_vsize_hi is active`,
+	Message:  "Mempool is large.\nCheck fees.",
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	const url = `https://hooks.example.com/alerts`
+	var received alerts.Notification
+	httpmock.RegisterResponder(http.MethodPost, url, func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, `application/json`, req.Header.Get(`Content-Type`))
+		body, _ := ioutil.ReadAll(req.Body)
+		assert.Nil(t, json.Unmarshal(body, &received))
+		return httpmock.NewStringResponse(http.StatusOK, ``), nil
+	})
+	notifier := alerts.NewWebhookNotifier(url, nil)
+	assert.Nil(t, notifier.Notify(context.Background(), testNotification))
+	assert.Equal(t, testNotification, received)
+
+	httpmock.RegisterResponder(http.MethodPost, url, httpmock.NewStringResponder(http.StatusBadGateway, "upstream down\n"))
+	assert.EqualError(t, notifier.Notify(context.Background(), testNotification), `webhook failed: 502 upstream down`)
+}
+
+func TestLogNotifier(t *testing.T) {
+	var text, lines bytes.Buffer
+	assert.Nil(t, alerts.NewLogNotifier(&text).Notify(context.Background(), testNotification))
+	assert.Equal(t, "2021-06-08T12:48:21.000000000Z btc mempool_vsize_hi is active: Mempool is large.\nCheck fees.\n", text.String())
+
+	assert.Nil(t, alerts.NewJSONLogNotifier(&lines).Notify(context.Background(), testNotification))
+	var logged alerts.Notification
+	assert.Nil(t, json.Unmarshal(lines.Bytes(), &logged))
+	assert.Equal(t, testNotification, logged)
+}
+
+func TestMailNotifier(t *testing.T) {
+	var sent struct {
+		addr string
+		from string
+		to   []string
+		msg  string
+	}
+	send := func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent.addr, sent.from, sent.to, sent.msg = addr, from, to, string(msg)
+		return nil
+	}
+	notifier := alerts.NewMailNotifier(`smtp.example.com:587`, nil, `alerts@example.com`, []string{`ops@example.com`, `risk@example.com`}, send)
+	assert.Nil(t, notifier.Notify(context.Background(), testNotification))
+	assert.Equal(t, `smtp.example.com:587`, sent.addr)
+	assert.Equal(t, `alerts@example.com`, sent.from)
+	assert.Equal(t, []string{`ops@example.com`, `risk@example.com`}, sent.to)
+	assert.Equal(t, "From: alerts@example.com\r\nTo: ops@example.com, risk@example.com\r\nSubject: btc mempool_vsize_hi is active\r\n"+
+		"Content-Type: text/plain; charset=UTF-8\r\n\r\nMempool is large.\r\nCheck fees.\r\n", sent.msg)
+}
+
+func TestMailNotifierSubjectInjection(t *testing.T) {
+	var msg string
+	send := func(addr string, a smtp.Auth, from string, to []string, m []byte) error {
+		msg = string(m)
+		return nil
+	}
+	notification := testNotification
+	notification.Subject = "btc is active\r\nBcc: attacker@example.com\nX-Test: 1"
+	assert.Nil(t, alerts.NewMailNotifier(`smtp.example.com:587`, nil, `alerts@example.com`, []string{`ops@example.com`}, send).Notify(context.Background(), notification))
+	assert.Contains(t, msg, "\r\nSubject: btc is active Bcc: attacker@example.com X-Test: 1\r\nContent-Type:")
+	assert.NotContains(t, msg, "\nBcc:")
+
+	notification.Subject = `btc höchst aktiv`
+	assert.Nil(t, alerts.NewMailNotifier(`smtp.example.com:587`, nil, `alerts@example.com`, []string{`ops@example.com`}, send).Notify(context.Background(), notification))
+	assert.Contains(t, msg, "\r\nSubject: =?UTF-8?q?btc_h=C3=B6chst_aktiv?=\r\n")
+}
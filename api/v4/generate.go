@@ -0,0 +1,10 @@
+package v4
+
+// Client is generated from spec/v4.yml by oapi-codegen of version pinned in go.mod by tools.go, then overlay.yml
+// applies customizations of generated code. Do not edit v4.go by hand, add patch to overlay.yml instead. Before
+// updating spec/v4.yml review changes of api with
+//
+//	go run ../../cmd/apigen diff spec/v4.yml new.yml
+
+//go:generate go run github.com/deepmap/oapi-codegen/cmd/oapi-codegen -config oapi-codegen.yml spec/v4.yml
+//go:generate go run ../../cmd/apigen overlay -overlay overlay.yml v4.go
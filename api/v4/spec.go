@@ -0,0 +1,8 @@
+package v4
+
+import (
+	"github.com/rulesng/coinmetrics-go-sdk/api/v4/spec"
+)
+
+// SpecYAML is OpenAPI spec the client is generated from
+var SpecYAML = spec.YAML
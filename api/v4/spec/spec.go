@@ -0,0 +1,13 @@
+// Package spec embeds OpenAPI spec of api/v4, it has no dependencies so tools generating the client can read the spec
+// without importing the client
+package spec
+
+import (
+	// embeds spec
+	_ "embed"
+)
+
+// YAML is OpenAPI spec the client is generated from
+//
+//go:embed v4.yml
+var YAML []byte
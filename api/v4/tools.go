@@ -0,0 +1,8 @@
+//go:build tools
+
+package v4
+
+// Pins version of oapi-codegen run by go generate, see generate.go
+import (
+	_ "github.com/deepmap/oapi-codegen/cmd/oapi-codegen"
+)
@@ -9284,8 +9284,6 @@ func NewGetCatalogAllAssetsRequest(server string, params *GetCatalogAllAssetsPar
 		return nil, err
 	}
 
-	fmt.Printf("queryURL: %s\n", queryURL)
-
 	queryValues := queryURL.Query()
 
 	if params.Assets != nil {
@@ -21204,9 +21202,7 @@ func ParseGetTimeseriesMarketCandlesResponse(rsp *http.Response) (*GetTimeseries
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
 		}
-		fmt.Printf("Dest is %+v\n", dest)
 		response.JSON200 = &dest
-		fmt.Printf("Response is %+v\n", response.JSON200)
 
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 400:
 		var dest ErrorResponse
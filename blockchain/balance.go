@@ -0,0 +1,436 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// AccountInfo is account listed by `/blockchain-v2/{asset}/accounts`
+type AccountInfo struct {
+	Account                     api.BlockchainAccount             `json:"account"`
+	Type                        api.BlockchainAccountType         `json:"type"`
+	Balance                     api.BlockchainAccountBalance      `json:"balance"`
+	NDebits                     api.BlockchainNumberOfDebits      `json:"n_debits"`
+	NCredits                    api.BlockchainNumberOfCredits     `json:"n_credits"`
+	CreationHeight              api.BlockchainBlockHeight         `json:"creation_height"`
+	CreationBlockHash           api.BlockchainBlockHash           `json:"creation_block_hash"`
+	CreationTime                api.Time                          `json:"creation_time"`
+	CreationChainSequenceNumber api.BlockchainChainSequenceNumber `json:"creation_chain_sequence_number"`
+	LastChainSequenceNumber     api.BlockchainChainSequenceNumber `json:"last_chain_sequence_number"`
+	LastDebitHeight             api.BlockchainBlockHeight         `json:"last_debit_height"`
+	LastCreditHeight            api.BlockchainBlockHeight         `json:"last_credit_height"`
+}
+
+// SubAccountInfo is sub-account listed by `/blockchain-v2/{asset}/sub-accounts`
+type SubAccountInfo struct {
+	SubAccount                  api.BlockchainSubAccount          `json:"sub_account"`
+	Account                     api.BlockchainAccount             `json:"account"`
+	Type                        api.BlockchainAccountType         `json:"type"`
+	Balance                     api.BlockchainSubAccountBalance   `json:"balance"`
+	CreationHeight              api.BlockchainBlockHeight         `json:"creation_height"`
+	CreationBlockHash           api.BlockchainBlockHash           `json:"creation_block_hash"`
+	CreationTime                api.Time                          `json:"creation_time"`
+	CreationChainSequenceNumber api.BlockchainChainSequenceNumber `json:"creation_chain_sequence_number"`
+}
+
+// BalancePoint is balance of account right after balance update
+type BalancePoint struct {
+	Height              int64
+	Time                time.Time
+	ChainSequenceNumber int64
+	Txid                string
+	Change              *big.Rat
+	Balance             *big.Rat
+}
+
+// BalanceSeries is balance history of account or sub-account ordered by chain sequence number
+type BalanceSeries struct {
+	Account    string
+	SubAccount string
+	Points     []BalancePoint
+	// start is balance before first point, zero unless history starts above account creation.
+	// Series of partial history without updates start at balance reported by accounts endpoint.
+	start *big.Rat
+}
+
+// Final returns balance after last update
+func (s *BalanceSeries) Final() *big.Rat {
+	if len(s.Points) == 0 {
+		return new(big.Rat).Set(s.start)
+	}
+	return new(big.Rat).Set(s.Points[len(s.Points)-1].Balance)
+}
+
+// AtHeight returns balance at the end of block height
+func (s *BalanceSeries) AtHeight(height int64) *big.Rat {
+	i := sort.Search(len(s.Points), func(i int) bool { return s.Points[i].Height > height })
+	return s.before(i)
+}
+
+// AtTime returns balance at time t, updates made exactly at t are included
+func (s *BalanceSeries) AtTime(t time.Time) *big.Rat {
+	i := sort.Search(len(s.Points), func(i int) bool { return s.Points[i].Time.After(t) })
+	return s.before(i)
+}
+
+func (s *BalanceSeries) before(i int) *big.Rat {
+	if i == 0 {
+		return new(big.Rat).Set(s.start)
+	}
+	return new(big.Rat).Set(s.Points[i-1].Balance)
+}
+
+// Discrepancy is mismatch between replayed balance and balance reported by api
+type Discrepancy struct {
+	Account    string
+	SubAccount string
+	// Height and ChainSequenceNumber of balance update, both are zero when final balance does not match
+	Height              int64
+	ChainSequenceNumber int64
+	Reason              string
+	Expected            string
+	Actual              string
+}
+
+// String returns human readable description of discrepancy
+func (d Discrepancy) String() string {
+	account := d.Account
+	if d.SubAccount != `` {
+		account = fmt.Sprintf(`%s/%s`, d.Account, d.SubAccount)
+	}
+	if d.ChainSequenceNumber == 0 {
+		return fmt.Sprintf(`%s: %s, expected %s, got %s`, account, d.Reason, d.Expected, d.Actual)
+	}
+	return fmt.Sprintf(`%s at height %d (%d): %s, expected %s, got %s`, account, d.Height, d.ChainSequenceNumber, d.Reason, d.Expected, d.Actual)
+}
+
+// Reasons of discrepancies
+const (
+	ReasonPreviousBalance = `previous balance does not match replayed balance`
+	ReasonNewBalance      = `new balance does not match previous balance plus change`
+	ReasonFinalBalance    = `replayed balance does not match reported balance`
+)
+
+// BalanceHistory holds reconstructed balance series of accounts and their sub-accounts
+type BalanceHistory struct {
+	Accounts map[string]*BalanceSeries
+	// SubAccounts are keyed by sub-account id
+	SubAccounts   map[string]*BalanceSeries
+	Discrepancies []Discrepancy
+}
+
+type balanceOptions struct {
+	subAccounts bool
+	startHeight *int64
+	reqEditors  []api.RequestEditorFn
+}
+
+// BalanceOption allows to customize ReconstructBalances
+type BalanceOption func(*balanceOptions)
+
+// WithSubAccountBalances reconstructs series of sub-accounts as well
+func WithSubAccountBalances() BalanceOption {
+	return func(o *balanceOptions) {
+		o.subAccounts = true
+	}
+}
+
+// WithStartHeight replays updates from height on, series start at previous balance of first update or at reported
+// balance when account has no updates from height on
+func WithStartHeight(height int64) BalanceOption {
+	return func(o *balanceOptions) {
+		o.startHeight = &height
+	}
+}
+
+// WithBalanceRequestEditors are passed to every call made while reconstructing balances
+func WithBalanceRequestEditors(fns ...api.RequestEditorFn) BalanceOption {
+	return func(o *balanceOptions) {
+		o.reqEditors = append(o.reqEditors, fns...)
+	}
+}
+
+// ReconstructBalances replays balance updates of main chain into balance series of accounts,
+// checks that every update continues replayed balance and that final balance matches balance reported by accounts endpoint.
+// Mismatches do not fail reconstruction, they are reported as discrepancies.
+func ReconstructBalances(ctx context.Context, client api.ClientWithResponsesInterface, asset string, accounts []string, opts ...BalanceOption) (*BalanceHistory, error) {
+	o := balanceOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	history := BalanceHistory{Accounts: map[string]*BalanceSeries{}, SubAccounts: map[string]*BalanceSeries{}}
+	for _, account := range accounts {
+		history.Accounts[account] = &BalanceSeries{Account: account, start: new(big.Rat)}
+	}
+
+	updates, err := listBalanceUpdates(ctx, client, api.BlockchainAsset(asset), accounts, o)
+	if err != nil {
+		return nil, err
+	}
+	for _, update := range updates {
+		series, ok := history.Accounts[string(update.Account)]
+		if !ok {
+			continue
+		}
+		if err := history.replay(series, update, update.PreviousBalance, update.NewBalance, o.startHeight != nil); err != nil {
+			return nil, err
+		}
+		if !o.subAccounts || update.SubAccount == nil || update.SubAccount.SubAccount == nil {
+			continue
+		}
+		id := string(*update.SubAccount.SubAccount)
+		subSeries, ok := history.SubAccounts[id]
+		if !ok {
+			subSeries = &BalanceSeries{Account: series.Account, SubAccount: id, start: new(big.Rat)}
+			history.SubAccounts[id] = subSeries
+		}
+		var previous, next api.BlockchainAccountBalance
+		if update.SubAccount.PreviousBalance != nil {
+			previous = *update.SubAccount.PreviousBalance
+		}
+		if update.SubAccount.NewBalance != nil {
+			next = *update.SubAccount.NewBalance
+		}
+		if err := history.replay(subSeries, update, previous, next, o.startHeight != nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := history.verifyAccounts(ctx, client, api.BlockchainAsset(asset), accounts, o); err != nil {
+		return nil, err
+	}
+	if o.subAccounts {
+		if err := history.verifySubAccounts(ctx, client, api.BlockchainAsset(asset), accounts, o); err != nil {
+			return nil, err
+		}
+	}
+	return &history, nil
+}
+
+// replay appends balance update to series, previous and new are balances reported by update
+func (h *BalanceHistory) replay(series *BalanceSeries, update api.BlockchainBalanceUpdateV2, previous, next api.BlockchainAccountBalance, partial bool) error {
+	height, err := ParseHeight(update.Height)
+	if err != nil {
+		return err
+	}
+	sequence, err := strconv.ParseInt(string(update.ChainSequenceNumber), 10, 64)
+	if err != nil {
+		return err
+	}
+	consensusTime, err := time.Parse(time.RFC3339Nano, string(update.ConsensusTime))
+	if err != nil {
+		return err
+	}
+	change, err := parseAmount(string(update.Change))
+	if err != nil {
+		return err
+	}
+	reportedPrevious, err := parseAmount(string(previous))
+	if err != nil {
+		return err
+	}
+	reportedNext, err := parseAmount(string(next))
+	if err != nil {
+		return err
+	}
+
+	balance := series.Final()
+	if len(series.Points) == 0 && partial {
+		// History which starts above creation of account continues from balance reported by first update
+		series.start, balance = reportedPrevious, new(big.Rat).Set(reportedPrevious)
+	}
+	discrepancy := Discrepancy{Account: series.Account, SubAccount: series.SubAccount, Height: height, ChainSequenceNumber: sequence}
+	if previous != `` && balance.Cmp(reportedPrevious) != 0 {
+		discrepancy.Reason, discrepancy.Expected, discrepancy.Actual = ReasonPreviousBalance, FormatAmount(balance), FormatAmount(reportedPrevious)
+		h.Discrepancies = append(h.Discrepancies, discrepancy)
+	}
+	balance.Add(balance, change)
+	if next != `` && previous != `` {
+		if expected := new(big.Rat).Add(reportedPrevious, change); expected.Cmp(reportedNext) != 0 {
+			discrepancy.Reason, discrepancy.Expected, discrepancy.Actual = ReasonNewBalance, FormatAmount(expected), FormatAmount(reportedNext)
+			h.Discrepancies = append(h.Discrepancies, discrepancy)
+		}
+	}
+
+	point := BalancePoint{Height: height, Time: consensusTime, ChainSequenceNumber: sequence, Change: change, Balance: balance}
+	if update.Txid != nil {
+		point.Txid = string(*update.Txid)
+	}
+	series.Points = append(series.Points, point)
+	return nil
+}
+
+func (h *BalanceHistory) verifyAccounts(ctx context.Context, client api.ClientWithResponsesInterface, asset api.BlockchainAsset, accounts []string, o balanceOptions) error {
+	ids := api.BlockchainAccounts(accounts)
+	pageSize := api.PageSize(constants.DefaultPageSize)
+	params := api.GetBlockchainV2ListOfAccountsParams{Accounts: &ids, PageSize: &pageSize}
+	return pages(func(token *api.NextPageToken) (*api.NextPageToken, error) {
+		params.NextPageToken = token
+		res, err := client.GetBlockchainV2ListOfAccountsWithResponse(ctx, asset, &params, o.reqEditors...)
+		if err != nil {
+			return nil, err
+		}
+		if res.JSON200 == nil {
+			return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+		}
+		var infos []AccountInfo
+		if err := decodeData(res.JSON200.Data, &infos); err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			if series, ok := h.Accounts[string(info.Account)]; ok {
+				if err := h.verifyFinal(series, string(info.Balance), o.startHeight != nil); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return res.JSON200.NextPageToken, nil
+	})
+}
+
+func (h *BalanceHistory) verifySubAccounts(ctx context.Context, client api.ClientWithResponsesInterface, asset api.BlockchainAsset, accounts []string, o balanceOptions) error {
+	ids := api.BlockchainAccounts(accounts)
+	pageSize := api.PageSize(constants.DefaultPageSize)
+	params := api.GetBlockchainV2ListOfSubAccountsParams{Accounts: &ids, PageSize: &pageSize}
+	return pages(func(token *api.NextPageToken) (*api.NextPageToken, error) {
+		params.NextPageToken = token
+		res, err := client.GetBlockchainV2ListOfSubAccountsWithResponse(ctx, asset, &params, o.reqEditors...)
+		if err != nil {
+			return nil, err
+		}
+		if res.JSON200 == nil {
+			return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+		}
+		var infos []SubAccountInfo
+		if err := decodeData(res.JSON200.Data, &infos); err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			series, ok := h.SubAccounts[string(info.SubAccount)]
+			if !ok {
+				// Sub-account without updates in replayed range
+				series = &BalanceSeries{Account: string(info.Account), SubAccount: string(info.SubAccount), start: new(big.Rat)}
+				h.SubAccounts[string(info.SubAccount)] = series
+			}
+			if err := h.verifyFinal(series, string(info.Balance), o.startHeight != nil); err != nil {
+				return nil, err
+			}
+		}
+		return res.JSON200.NextPageToken, nil
+	})
+}
+
+func (h *BalanceHistory) verifyFinal(series *BalanceSeries, reported string, partial bool) error {
+	balance, err := parseAmount(reported)
+	if err != nil {
+		return err
+	}
+	if len(series.Points) == 0 && partial {
+		// Balance did not change since start height, there is nothing to compare it with
+		series.start = balance
+		return nil
+	}
+	if final := series.Final(); final.Cmp(balance) != 0 {
+		h.Discrepancies = append(h.Discrepancies, Discrepancy{
+			Account:    series.Account,
+			SubAccount: series.SubAccount,
+			Reason:     ReasonFinalBalance,
+			Expected:   FormatAmount(final),
+			Actual:     FormatAmount(balance),
+		})
+	}
+	return nil
+}
+
+func listBalanceUpdates(ctx context.Context, client api.ClientWithResponsesInterface, asset api.BlockchainAsset, accounts []string, o balanceOptions) ([]api.BlockchainBalanceUpdateV2, error) {
+	ids := api.BlockchainAccounts(accounts)
+	pageSize := api.PageSize(constants.DefaultPageSize)
+	chain := MainChain
+	params := api.GetBlockchainV2ListOfBalanceUpdatesParams{Accounts: &ids, Chain: &chain, PageSize: &pageSize}
+	if o.subAccounts {
+		include := api.BlockchainIncludeSubAccounts(true)
+		params.IncludeSubAccounts = &include
+	}
+	if o.startHeight != nil {
+		start := api.BlockchainStartHeight(*o.startHeight)
+		params.StartHeight = &start
+	}
+
+	var updates []api.BlockchainBalanceUpdateV2
+	err := pages(func(token *api.NextPageToken) (*api.NextPageToken, error) {
+		params.NextPageToken = token
+		res, err := client.GetBlockchainV2ListOfBalanceUpdatesWithResponse(ctx, asset, &params, o.reqEditors...)
+		if err != nil {
+			return nil, err
+		}
+		if res.JSON200 == nil {
+			return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+		}
+		updates = append(updates, res.JSON200.Data...)
+		return res.JSON200.NextPageToken, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sequences := make([]int64, len(updates))
+	for i, update := range updates {
+		if sequences[i], err = strconv.ParseInt(string(update.ChainSequenceNumber), 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	sort.Sort(bySequence{updates: updates, sequences: sequences})
+	return updates, nil
+}
+
+type bySequence struct {
+	updates   []api.BlockchainBalanceUpdateV2
+	sequences []int64
+}
+
+func (s bySequence) Len() int           { return len(s.updates) }
+func (s bySequence) Less(i, j int) bool { return s.sequences[i] < s.sequences[j] }
+func (s bySequence) Swap(i, j int) {
+	s.updates[i], s.updates[j] = s.updates[j], s.updates[i]
+	s.sequences[i], s.sequences[j] = s.sequences[j], s.sequences[i]
+}
+
+// parseAmount parses decimal amount returned by api, empty amount is zero
+func parseAmount(amount string) (*big.Rat, error) {
+	r := new(big.Rat)
+	if amount == `` {
+		return r, nil
+	}
+	if _, ok := r.SetString(amount); !ok {
+		return nil, fmt.Errorf(`%s %q`, constants.InvalidAmount, amount)
+	}
+	return r, nil
+}
+
+// FormatAmount formats amount as decimal without trailing zeros
+func FormatAmount(amount *big.Rat) string {
+	// Amounts parsed from api are exact with denominator dividing power of ten, others are rounded to 18 decimals
+	decimals := 18
+	power := big.NewInt(1)
+	for n := 0; n <= 36; n++ {
+		if new(big.Int).Mod(power, amount.Denom()).Sign() == 0 {
+			decimals = n
+			break
+		}
+		power.Mul(power, big.NewInt(10))
+	}
+	formatted := amount.FloatString(decimals)
+	if decimals > 0 {
+		formatted = strings.TrimRight(strings.TrimRight(formatted, `0`), `.`)
+	}
+	return formatted
+}
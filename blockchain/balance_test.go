@@ -0,0 +1,116 @@
+package blockchain_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/rulesng/coinmetrics-go-sdk/blockchain"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+const testBalanceUpdatesPage1 = `{
+  "data": [
+    {"account":"addr1","change":"1.5","previous_balance":"0","new_balance":"1.5","chain_sequence_number":"1","height":"1","block_hash":"hash-1","consensus_time":"2022-05-01T00:00:00.000000000Z","txid":"tx1",
+     "sub_account":{"sub_account":"s1","previous_balance":"0","new_balance":"1.5"}},
+    {"account":"addr1","change":"-0.25","previous_balance":"1.5","new_balance":"1.25","chain_sequence_number":"2","height":"2","block_hash":"hash-2","consensus_time":"2022-05-01T00:10:00.000000000Z","txid":"tx2",
+     "sub_account":{"sub_account":"s1","previous_balance":"1.5","new_balance":"1.25"}}
+  ],
+  "next_page_token": "page2"
+}`
+
+const testBalanceUpdatesPage2 = `{
+  "data": [
+    {"account":"addr1","change":"0.00000001","previous_balance":"1.25","new_balance":"1.25000001","chain_sequence_number":"5","height":"3","block_hash":"hash-3","consensus_time":"2022-05-01T00:20:00.000000000Z","txid":"tx3",
+     "sub_account":{"sub_account":"s2","previous_balance":"0","new_balance":"0.00000001"}}
+  ]
+}`
+
+func registerBalanceResponders(accountBalance string, pages ...string) {
+	url := fmt.Sprintf(`%s%s/blockchain-v2/btc`, constants.TestEndpoint, constants.ApiVersion)
+	httpmock.RegisterResponder(http.MethodGet, url+`/balance-updates`, func(req *http.Request) (*http.Response, error) {
+		body := pages[0]
+		if req.URL.Query().Get(`next_page_token`) == `page2` {
+			body = pages[1]
+		}
+		resp := httpmock.NewStringResponse(http.StatusOK, body)
+		resp.Header.Set(`Content-Type`, `application/json`)
+		return resp, nil
+	})
+	accounts, _ := httpmock.NewJsonResponder(http.StatusOK, map[string]interface{}{
+		`data`: []map[string]string{{`account`: `addr1`, `type`: `UTXO`, `balance`: accountBalance}},
+	})
+	httpmock.RegisterResponder(http.MethodGet, url+`/accounts`, accounts)
+	subAccounts, _ := httpmock.NewJsonResponder(http.StatusOK, map[string]interface{}{
+		`data`: []map[string]string{
+			{`account`: `addr1`, `sub_account`: `s1`, `type`: `UTXO`, `balance`: `1.25`},
+			{`account`: `addr1`, `sub_account`: `s2`, `type`: `UTXO`, `balance`: `0.00000001`},
+		},
+	})
+	httpmock.RegisterResponder(http.MethodGet, url+`/sub-accounts`, subAccounts)
+}
+
+func TestReconstructBalances(t *testing.T) {
+	registerBalanceResponders(`1.25000001`, testBalanceUpdatesPage1, testBalanceUpdatesPage2)
+	history, err := blockchain.ReconstructBalances(context.Background(), _coinmetrics, `btc`, []string{`addr1`}, blockchain.WithSubAccountBalances())
+	assert.Nil(t, err)
+	assert.Nil(t, history.Discrepancies)
+
+	series := history.Accounts[`addr1`]
+	assert.Equal(t, 3, len(series.Points))
+	assert.Equal(t, `1.25000001`, blockchain.FormatAmount(series.Final()))
+	assert.Equal(t, `0`, blockchain.FormatAmount(series.AtHeight(0)))
+	assert.Equal(t, `1.5`, blockchain.FormatAmount(series.AtHeight(1)))
+	assert.Equal(t, `1.25`, blockchain.FormatAmount(series.AtTime(time.Date(2022, time.May, 1, 0, 15, 0, 0, time.UTC))))
+	assert.Equal(t, `tx3`, series.Points[2].Txid)
+
+	assert.Equal(t, `1.25`, blockchain.FormatAmount(history.SubAccounts[`s1`].Final()))
+	assert.Equal(t, `0.00000001`, blockchain.FormatAmount(history.SubAccounts[`s2`].Final()))
+	assert.Equal(t, `addr1`, history.SubAccounts[`s2`].Account)
+}
+
+func TestReconstructBalancesDiscrepancies(t *testing.T) {
+	// Second update is missing from first page
+	page1 := `{"data":[{"account":"addr1","change":"1.5","previous_balance":"0","new_balance":"1.5","chain_sequence_number":"1","height":"1","block_hash":"hash-1","consensus_time":"2022-05-01T00:00:00.000000000Z"}],"next_page_token":"page2"}`
+	registerBalanceResponders(`2`, page1, testBalanceUpdatesPage2)
+	history, err := blockchain.ReconstructBalances(context.Background(), _coinmetrics, `btc`, []string{`addr1`})
+	assert.Nil(t, err)
+
+	var discrepancies []string
+	for _, discrepancy := range history.Discrepancies {
+		discrepancies = append(discrepancies, discrepancy.String())
+	}
+	assert.Equal(t, []string{
+		`addr1 at height 3 (5): previous balance does not match replayed balance, expected 1.5, got 1.25`,
+		`addr1: replayed balance does not match reported balance, expected 1.50000001, got 2`,
+	}, discrepancies)
+}
+
+func TestReconstructBalancesFromHeight(t *testing.T) {
+	registerBalanceResponders(`1.25000001`, `{"data":[]}`, ``)
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf(`%s%s/blockchain-v2/btc/balance-updates`, constants.TestEndpoint, constants.ApiVersion),
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, `3`, req.URL.Query().Get(`start_height`))
+			resp := httpmock.NewStringResponse(http.StatusOK, testBalanceUpdatesPage2)
+			resp.Header.Set(`Content-Type`, `application/json`)
+			return resp, nil
+		})
+	history, err := blockchain.ReconstructBalances(context.Background(), _coinmetrics, `btc`, []string{`addr1`}, blockchain.WithStartHeight(3))
+	assert.Nil(t, err)
+	assert.Nil(t, history.Discrepancies)
+	assert.Equal(t, `1.25`, blockchain.FormatAmount(history.Accounts[`addr1`].AtHeight(2)))
+}
+
+func TestReconstructBalancesFromHeightWithoutUpdates(t *testing.T) {
+	registerBalanceResponders(`1.25000001`, `{"data":[]}`, ``)
+	history, err := blockchain.ReconstructBalances(context.Background(), _coinmetrics, `btc`, []string{`addr1`}, blockchain.WithStartHeight(10), blockchain.WithSubAccountBalances())
+	assert.Nil(t, err)
+	assert.Nil(t, history.Discrepancies)
+	assert.Empty(t, history.Accounts[`addr1`].Points)
+	assert.Equal(t, `1.25000001`, blockchain.FormatAmount(history.Accounts[`addr1`].Final()))
+	assert.Equal(t, `1.25`, blockchain.FormatAmount(history.SubAccounts[`s1`].Final()))
+}
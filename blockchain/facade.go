@@ -0,0 +1,738 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// Generation of blockchain endpoints, `/blockchain/*` is V1 and `/blockchain-v2/*` is V2
+type Generation string
+
+// Generations used by Blockchain
+const (
+	// Auto calls V2 and falls back to V1 for assets where V2 is not available
+	Auto Generation = "auto"
+	V1   Generation = "v1"
+	V2   Generation = "v2"
+)
+
+// Query selects blocks, transactions or balance updates, empty fields are not sent
+type Query struct {
+	StartHeight *int64
+	EndHeight   *int64
+	StartTime   string
+	EndTime     string
+	BlockHashes []string
+	// Transactions are txids for V2 and transaction hashes for V1, queries filtering them are not retried on V1 when
+	// generation of asset is not resolved yet
+	Transactions []string
+	// Accounts filter balance updates
+	Accounts []string
+	// Limit stops paging once that many items are returned, zero returns all items
+	Limit int
+}
+
+// BlockInfo is block returned by either generation
+type BlockInfo struct {
+	Hash            string
+	ParentHash      string
+	Height          int64
+	ConsensusTime   string
+	MinerTime       string
+	NTransactions   string
+	NBalanceUpdates string
+	Stale           bool
+}
+
+// TransactionInfo is transaction returned by either generation, Id is txid for V2 and transaction hash for V1
+type TransactionInfo struct {
+	Id              string
+	BlockHash       string
+	Height          int64
+	ConsensusTime   string
+	Amount          string
+	NBalanceUpdates string
+	Stale           bool
+}
+
+// BalanceUpdate is balance update returned by either generation
+type BalanceUpdate struct {
+	Account             string
+	Change              string
+	PreviousBalance     string
+	NewBalance          string
+	ChainSequenceNumber string
+	TransactionId       string
+	BlockHash           string
+	Height              int64
+	ConsensusTime       string
+	Stale               bool
+}
+
+// Credit tells if update increased balance of account
+func (u BalanceUpdate) Credit() bool {
+	change, err := parseAmount(u.Change)
+	return err == nil && change.Sign() > 0
+}
+
+// FullBlock is block with its transactions and balance updates
+type FullBlock struct {
+	BlockInfo
+	Transactions   []FullTransaction
+	BalanceUpdates []BalanceUpdate
+}
+
+// FullTransaction is transaction with its balance updates
+type FullTransaction struct {
+	TransactionInfo
+	BalanceUpdates []BalanceUpdate
+}
+
+// Blockchain gives access to blockchain data regardless of generation of endpoints serving asset
+type Blockchain interface {
+	Blocks(ctx context.Context, asset string, query Query) ([]BlockInfo, error)
+	Block(ctx context.Context, asset, hash string) (*FullBlock, error)
+	Transactions(ctx context.Context, asset string, query Query) ([]TransactionInfo, error)
+	// Transaction returns transaction by txid for V2 or transaction hash for V1. Identifiers differ, so lookup is not
+	// retried on V1, use generation resolved by other call or WithGeneration for assets served by V1.
+	Transaction(ctx context.Context, asset, id string) (*FullTransaction, error)
+	BalanceUpdates(ctx context.Context, asset string, query Query) ([]BalanceUpdate, error)
+	Accounts(ctx context.Context, asset string, accounts []string) ([]AccountInfo, error)
+	// Generation returns generation used for asset, Auto until it is resolved by first call
+	Generation(asset string) Generation
+}
+
+// BlockchainOption allows to customize Blockchain
+type BlockchainOption func(*facade)
+
+// WithGeneration forces generation of endpoints instead of detecting it per asset
+func WithGeneration(generation Generation) BlockchainOption {
+	return func(f *facade) {
+		f.generation = generation
+	}
+}
+
+// WithBlockchainRequestEditors are passed to every call
+func WithBlockchainRequestEditors(fns ...api.RequestEditorFn) BlockchainOption {
+	return func(f *facade) {
+		f.reqEditors = append(f.reqEditors, fns...)
+	}
+}
+
+// NewBlockchain creates Blockchain which routes calls to V2 and falls back to V1 when V2 rejects asset
+func NewBlockchain(client api.ClientWithResponsesInterface, opts ...BlockchainOption) Blockchain {
+	f := facade{
+		client:     client,
+		generation: Auto,
+		resolved:   map[string]Generation{},
+	}
+	for _, opt := range opts {
+		opt(&f)
+	}
+	return &f
+}
+
+type facade struct {
+	client     api.ClientWithResponsesInterface
+	generation Generation
+	reqEditors []api.RequestEditorFn
+
+	mu       sync.Mutex
+	resolved map[string]Generation
+}
+
+func (f *facade) Generation(asset string) Generation {
+	if f.generation != Auto {
+		return f.generation
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if generation, ok := f.resolved[asset]; ok {
+		return generation
+	}
+	return Auto
+}
+
+// route calls v2 unless asset is known to be served by v1 only. When fallback is set, v1 is tried if v2 does not
+// support asset. Lookups by identifiers which differ between generations, like txid and transaction hash, do not fall
+// back, error of v2 is returned.
+func (f *facade) route(asset string, fallback bool, v1, v2 func() error) error {
+	switch f.Generation(asset) {
+	case V1:
+		return v1()
+	case V2:
+		return v2()
+	}
+	err := v2()
+	unsupported, pin := unsupported(err)
+	if !unsupported {
+		f.resolve(asset, V2, err)
+		return err
+	}
+	if !fallback {
+		return err
+	}
+	err = v1()
+	if pin {
+		f.resolve(asset, V1, err)
+	}
+	return err
+}
+
+func (f *facade) resolve(asset string, generation Generation, err error) {
+	if err != nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.resolved[asset] = generation
+}
+
+// unsupported tells if api error means that v2 does not serve asset. Forbidden blockchain resource means asset is not
+// available in v2 at all, so asset can be pinned to v1. Rejected asset param is retried on v1 without pinning, other
+// errors, like bad params or unknown hash, are errors of request.
+func unsupported(err error) (unsupported, pin bool) {
+	var apiError coinmetrics.ApiError
+	if !errors.As(err, &apiError) {
+		return false, false
+	}
+	switch apiError.StatusCode {
+	case http.StatusForbidden:
+		return apiError.Response.Error.Type == constants.ErrorTypeForbidden, true
+	case http.StatusBadRequest:
+		param, ok := apiError.BadParameter()
+		return ok && param == `asset`, false
+	}
+	return false, false
+}
+
+// pageSize returns page size for query and whether paging should stop once n items are collected
+func (q Query) pageSize(n int) (*api.PageSize, bool) {
+	size := api.PageSize(constants.DefaultPageSize)
+	if q.Limit > 0 && q.Limit-n < int(size) {
+		size = api.PageSize(q.Limit - n)
+	}
+	return &size, q.Limit > 0 && n >= q.Limit
+}
+
+func (q Query) heights() (*api.BlockchainStartHeight, *api.BlockchainEndHeight) {
+	var start *api.BlockchainStartHeight
+	var end *api.BlockchainEndHeight
+	if q.StartHeight != nil {
+		value := api.BlockchainStartHeight(*q.StartHeight)
+		start = &value
+	}
+	if q.EndHeight != nil {
+		value := api.BlockchainEndHeight(*q.EndHeight)
+		end = &value
+	}
+	return start, end
+}
+
+func (q Query) times() (*api.BlockchainStartTime, *api.BlockchainEndTime) {
+	var start *api.BlockchainStartTime
+	var end *api.BlockchainEndTime
+	if q.StartTime != `` {
+		value := api.BlockchainStartTime(q.StartTime)
+		start = &value
+	}
+	if q.EndTime != `` {
+		value := api.BlockchainEndTime(q.EndTime)
+		end = &value
+	}
+	return start, end
+}
+
+func optionalList(values []string) *[]string {
+	if len(values) == 0 {
+		return nil
+	}
+	return &values
+}
+
+func (f *facade) Blocks(ctx context.Context, asset string, query Query) ([]BlockInfo, error) {
+	var blocks []BlockInfo
+	startHeight, endHeight := query.heights()
+	startTime, endTime := query.times()
+	v1 := func() error {
+		blocks = nil
+		params := api.GetBlockchainListOfBlocksParams{StartHeight: startHeight, EndHeight: endHeight, StartTime: startTime, EndTime: endTime,
+			BlockHashes: (*api.BlockchainBlockHashes)(optionalList(query.BlockHashes))}
+		return pages(func(token *api.NextPageToken) (*api.NextPageToken, error) {
+			params.NextPageToken = token
+			params.PageSize, _ = query.pageSize(len(blocks))
+			res, err := f.client.GetBlockchainListOfBlocksWithResponse(ctx, api.BlockchainAsset(asset), &params, f.reqEditors...)
+			if err != nil {
+				return nil, err
+			}
+			if res.JSON200 == nil {
+				return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+			}
+			var page []api.BlockchainBlockInfo
+			if err := decodeData(res.JSON200.Data, &page); err != nil {
+				return nil, err
+			}
+			for _, info := range page {
+				block, err := blockFromV1(info)
+				if err != nil {
+					return nil, err
+				}
+				blocks = append(blocks, block)
+			}
+			if _, done := query.pageSize(len(blocks)); done {
+				return nil, nil
+			}
+			return res.JSON200.NextPageToken, nil
+		})
+	}
+	v2 := func() error {
+		blocks = nil
+		params := api.GetBlockchainV2ListOfBlocksParams{StartHeight: startHeight, EndHeight: endHeight, StartTime: startTime, EndTime: endTime,
+			BlockHashes: (*api.BlockchainBlockHashes)(optionalList(query.BlockHashes))}
+		return pages(func(token *api.NextPageToken) (*api.NextPageToken, error) {
+			params.NextPageToken = token
+			params.PageSize, _ = query.pageSize(len(blocks))
+			res, err := f.client.GetBlockchainV2ListOfBlocksWithResponse(ctx, api.BlockchainAsset(asset), &params, f.reqEditors...)
+			if err != nil {
+				return nil, err
+			}
+			if res.JSON200 == nil {
+				return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+			}
+			var page []api.BlockchainBlockInfoV2
+			if err := decodeData(res.JSON200.Data, &page); err != nil {
+				return nil, err
+			}
+			for _, info := range page {
+				block, err := blockFromV2(info)
+				if err != nil {
+					return nil, err
+				}
+				blocks = append(blocks, block)
+			}
+			if _, done := query.pageSize(len(blocks)); done {
+				return nil, nil
+			}
+			return res.JSON200.NextPageToken, nil
+		})
+	}
+	if err := f.route(asset, true, v1, v2); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+func (f *facade) Block(ctx context.Context, asset, hash string) (*FullBlock, error) {
+	var block *FullBlock
+	v1 := func() error {
+		res, err := f.client.GetBlockchainFullBlockWithResponse(ctx, api.BlockchainAsset(asset), api.BlockchainBlockHash(hash), &api.GetBlockchainFullBlockParams{}, f.reqEditors...)
+		if err != nil {
+			return err
+		}
+		if res.JSON200 == nil {
+			return coinmetrics.NewApiError(res.StatusCode(), res.Body)
+		}
+		info, err := blockFromV1(res.JSON200.BlockchainBlockInfo)
+		if err != nil {
+			return err
+		}
+		block = &FullBlock{BlockInfo: info}
+		if res.JSON200.Transactions != nil {
+			for _, tx := range *res.JSON200.Transactions {
+				full := FullTransaction{TransactionInfo: transactionFromV1(tx.BlockchainBlockTransactionInfo, info)}
+				full.BalanceUpdates = updatesFromV1(tx.BalanceUpdates, full.TransactionInfo)
+				block.Transactions = append(block.Transactions, full)
+			}
+		}
+		block.BalanceUpdates = updatesFromV1(res.JSON200.BalanceUpdates, TransactionInfo{BlockHash: info.Hash, Height: info.Height, ConsensusTime: info.ConsensusTime, Stale: info.Stale})
+		return nil
+	}
+	v2 := func() error {
+		res, err := f.client.GetBlockchainV2FullBlockWithResponse(ctx, api.BlockchainAsset(asset), api.BlockchainBlockHash(hash), &api.GetBlockchainV2FullBlockParams{}, f.reqEditors...)
+		if err != nil {
+			return err
+		}
+		if res.JSON200 == nil {
+			return coinmetrics.NewApiError(res.StatusCode(), res.Body)
+		}
+		info, err := blockFromV2(res.JSON200.BlockchainBlockInfoV2)
+		if err != nil {
+			return err
+		}
+		block = &FullBlock{BlockInfo: info}
+		if res.JSON200.Transactions != nil {
+			for _, tx := range *res.JSON200.Transactions {
+				full := FullTransaction{TransactionInfo: transactionFromV2(tx.BlockchainBlockTransactionInfoV2, info)}
+				full.BalanceUpdates = updatesFromV2(tx.BalanceUpdates, full.TransactionInfo)
+				block.Transactions = append(block.Transactions, full)
+			}
+		}
+		block.BalanceUpdates = updatesFromV2(res.JSON200.BalanceUpdates, TransactionInfo{BlockHash: info.Hash, Height: info.Height, ConsensusTime: info.ConsensusTime, Stale: info.Stale})
+		return nil
+	}
+	if err := f.route(asset, true, v1, v2); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func (f *facade) Transactions(ctx context.Context, asset string, query Query) ([]TransactionInfo, error) {
+	var transactions []TransactionInfo
+	startHeight, endHeight := query.heights()
+	startTime, endTime := query.times()
+	v1 := func() error {
+		transactions = nil
+		params := api.GetBlockchainListOfTransactionsParams{StartHeight: startHeight, EndHeight: endHeight, StartTime: startTime, EndTime: endTime,
+			BlockHashes:       (*api.BlockchainBlockHashes)(optionalList(query.BlockHashes)),
+			TransactionHashes: (*api.BlockchainTransactions)(optionalList(query.Transactions))}
+		return pages(func(token *api.NextPageToken) (*api.NextPageToken, error) {
+			params.NextPageToken = token
+			params.PageSize, _ = query.pageSize(len(transactions))
+			res, err := f.client.GetBlockchainListOfTransactionsWithResponse(ctx, api.BlockchainAsset(asset), &params, f.reqEditors...)
+			if err != nil {
+				return nil, err
+			}
+			if res.JSON200 == nil {
+				return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+			}
+			var page []api.BlockchainTransactionInfo
+			if err := decodeData(res.JSON200.Data, &page); err != nil {
+				return nil, err
+			}
+			for _, info := range page {
+				height, err := ParseHeight(info.Height)
+				if err != nil {
+					return nil, err
+				}
+				transactions = append(transactions, transactionFromV1(info.BlockchainBlockTransactionInfo, BlockInfo{Hash: string(info.BlockHash), Height: height}))
+			}
+			if _, done := query.pageSize(len(transactions)); done {
+				return nil, nil
+			}
+			return res.JSON200.NextPageToken, nil
+		})
+	}
+	v2 := func() error {
+		transactions = nil
+		params := api.GetBlockchainV2ListOfTransactionsParams{StartHeight: startHeight, EndHeight: endHeight, StartTime: startTime, EndTime: endTime,
+			BlockHashes: (*api.BlockchainBlockHashes)(optionalList(query.BlockHashes)),
+			Txids:       (*api.BlockchainTransactionIds)(optionalList(query.Transactions))}
+		return pages(func(token *api.NextPageToken) (*api.NextPageToken, error) {
+			params.NextPageToken = token
+			params.PageSize, _ = query.pageSize(len(transactions))
+			res, err := f.client.GetBlockchainV2ListOfTransactionsWithResponse(ctx, api.BlockchainAsset(asset), &params, f.reqEditors...)
+			if err != nil {
+				return nil, err
+			}
+			if res.JSON200 == nil {
+				return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+			}
+			var page []api.BlockchainTransactionInfoV2
+			if err := decodeData(res.JSON200.Data, &page); err != nil {
+				return nil, err
+			}
+			for _, info := range page {
+				height, err := ParseHeight(info.Height)
+				if err != nil {
+					return nil, err
+				}
+				transactions = append(transactions, transactionFromV2(info.BlockchainBlockTransactionInfoV2, BlockInfo{Hash: string(info.BlockHash), Height: height}))
+			}
+			if _, done := query.pageSize(len(transactions)); done {
+				return nil, nil
+			}
+			return res.JSON200.NextPageToken, nil
+		})
+	}
+	if err := f.route(asset, len(query.Transactions) == 0, v1, v2); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (f *facade) Transaction(ctx context.Context, asset, id string) (*FullTransaction, error) {
+	var transaction *FullTransaction
+	v1 := func() error {
+		res, err := f.client.GetBlockchainFullTransactionWithResponse(ctx, api.BlockchainAsset(asset), api.BlockchainTransactionHash(id), &api.GetBlockchainFullTransactionParams{}, f.reqEditors...)
+		if err != nil {
+			return err
+		}
+		if res.JSON200 == nil {
+			return coinmetrics.NewApiError(res.StatusCode(), res.Body)
+		}
+		height, err := ParseHeight(res.JSON200.Height)
+		if err != nil {
+			return err
+		}
+		info := transactionFromV1(res.JSON200.BlockchainBlockTransactionInfo, BlockInfo{Hash: string(res.JSON200.BlockHash), Height: height})
+		transaction = &FullTransaction{TransactionInfo: info, BalanceUpdates: updatesFromV1(res.JSON200.BalanceUpdates, info)}
+		return nil
+	}
+	v2 := func() error {
+		res, err := f.client.GetBlockchainV2FullTransactionWithResponse(ctx, api.BlockchainAsset(asset), api.BlockchainTransactionId(id), &api.GetBlockchainV2FullTransactionParams{}, f.reqEditors...)
+		if err != nil {
+			return err
+		}
+		if res.JSON200 == nil {
+			return coinmetrics.NewApiError(res.StatusCode(), res.Body)
+		}
+		height, err := ParseHeight(res.JSON200.Height)
+		if err != nil {
+			return err
+		}
+		info := transactionFromV2(res.JSON200.BlockchainBlockTransactionInfoV2, BlockInfo{Hash: string(res.JSON200.BlockHash), Height: height})
+		transaction = &FullTransaction{TransactionInfo: info, BalanceUpdates: updatesFromV2(res.JSON200.BalanceUpdates, info)}
+		return nil
+	}
+	if err := f.route(asset, false, v1, v2); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+func (f *facade) BalanceUpdates(ctx context.Context, asset string, query Query) ([]BalanceUpdate, error) {
+	var updates []BalanceUpdate
+	startHeight, endHeight := query.heights()
+	startTime, endTime := query.times()
+	v1 := func() error {
+		updates = nil
+		params := api.GetBlockchainListOfBalanceUpdatesParams{StartHeight: startHeight, EndHeight: endHeight, StartTime: startTime, EndTime: endTime,
+			Accounts:          (*api.BlockchainAccounts)(optionalList(query.Accounts)),
+			BlockHashes:       (*api.BlockchainBlockHashes)(optionalList(query.BlockHashes)),
+			TransactionHashes: (*api.BlockchainTransactions)(optionalList(query.Transactions))}
+		return pages(func(token *api.NextPageToken) (*api.NextPageToken, error) {
+			params.NextPageToken = token
+			params.PageSize, _ = query.pageSize(len(updates))
+			res, err := f.client.GetBlockchainListOfBalanceUpdatesWithResponse(ctx, api.BlockchainAsset(asset), &params, f.reqEditors...)
+			if err != nil {
+				return nil, err
+			}
+			if res.JSON200 == nil {
+				return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+			}
+			for _, update := range res.JSON200.Data {
+				height, err := ParseHeight(update.Height)
+				if err != nil {
+					return nil, err
+				}
+				tx := TransactionInfo{BlockHash: string(update.BlockHash), Height: height, ConsensusTime: string(update.ConsensusTime)}
+				if update.TransactionHash != nil {
+					tx.Id = string(*update.TransactionHash)
+				}
+				updates = append(updates, updateFromV1(update.BlockchainTransactionBalanceUpdate, tx))
+			}
+			if _, done := query.pageSize(len(updates)); done {
+				return nil, nil
+			}
+			return res.JSON200.NextPageToken, nil
+		})
+	}
+	v2 := func() error {
+		updates = nil
+		params := api.GetBlockchainV2ListOfBalanceUpdatesParams{StartHeight: startHeight, EndHeight: endHeight, StartTime: startTime, EndTime: endTime,
+			Accounts:    (*api.BlockchainAccounts)(optionalList(query.Accounts)),
+			BlockHashes: (*api.BlockchainBlockHashes)(optionalList(query.BlockHashes)),
+			Txids:       (*api.BlockchainTransactionIds)(optionalList(query.Transactions))}
+		return pages(func(token *api.NextPageToken) (*api.NextPageToken, error) {
+			params.NextPageToken = token
+			params.PageSize, _ = query.pageSize(len(updates))
+			res, err := f.client.GetBlockchainV2ListOfBalanceUpdatesWithResponse(ctx, api.BlockchainAsset(asset), &params, f.reqEditors...)
+			if err != nil {
+				return nil, err
+			}
+			if res.JSON200 == nil {
+				return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+			}
+			for _, update := range res.JSON200.Data {
+				height, err := ParseHeight(update.Height)
+				if err != nil {
+					return nil, err
+				}
+				tx := TransactionInfo{BlockHash: string(update.BlockHash), Height: height, ConsensusTime: string(update.ConsensusTime)}
+				if update.Txid != nil {
+					tx.Id = string(*update.Txid)
+				}
+				updates = append(updates, updateFromV2(update.BlockchainTransactionBalanceUpdateV2, tx))
+			}
+			if _, done := query.pageSize(len(updates)); done {
+				return nil, nil
+			}
+			return res.JSON200.NextPageToken, nil
+		})
+	}
+	if err := f.route(asset, len(query.Transactions) == 0, v1, v2); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+func (f *facade) Accounts(ctx context.Context, asset string, accounts []string) ([]AccountInfo, error) {
+	var infos []AccountInfo
+	pageSize := api.PageSize(constants.DefaultPageSize)
+	ids := (*api.BlockchainAccounts)(optionalList(accounts))
+	v1 := func() error {
+		infos = nil
+		params := api.GetBlockchainListOfAccountsParams{Accounts: ids, PageSize: &pageSize}
+		return pages(func(token *api.NextPageToken) (*api.NextPageToken, error) {
+			params.NextPageToken = token
+			res, err := f.client.GetBlockchainListOfAccountsWithResponse(ctx, api.BlockchainAsset(asset), &params, f.reqEditors...)
+			if err != nil {
+				return nil, err
+			}
+			if res.JSON200 == nil {
+				return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+			}
+			var page []AccountInfo
+			if err := decodeData(res.JSON200.Data, &page); err != nil {
+				return nil, err
+			}
+			infos = append(infos, page...)
+			return res.JSON200.NextPageToken, nil
+		})
+	}
+	v2 := func() error {
+		infos = nil
+		params := api.GetBlockchainV2ListOfAccountsParams{Accounts: ids, PageSize: &pageSize}
+		return pages(func(token *api.NextPageToken) (*api.NextPageToken, error) {
+			params.NextPageToken = token
+			res, err := f.client.GetBlockchainV2ListOfAccountsWithResponse(ctx, api.BlockchainAsset(asset), &params, f.reqEditors...)
+			if err != nil {
+				return nil, err
+			}
+			if res.JSON200 == nil {
+				return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+			}
+			var page []AccountInfo
+			if err := decodeData(res.JSON200.Data, &page); err != nil {
+				return nil, err
+			}
+			infos = append(infos, page...)
+			return res.JSON200.NextPageToken, nil
+		})
+	}
+	if err := f.route(asset, true, v1, v2); err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+func blockFromV1(info api.BlockchainBlockInfo) (BlockInfo, error) {
+	height, err := ParseHeight(info.Height)
+	if err != nil {
+		return BlockInfo{}, err
+	}
+	block := BlockInfo{
+		Hash:            string(info.BlockHash),
+		Height:          height,
+		ConsensusTime:   string(info.ConsensusTime),
+		MinerTime:       string(info.MinerTime),
+		NTransactions:   string(info.NTransactions),
+		NBalanceUpdates: string(info.NBalanceUpdates),
+	}
+	if info.ParentBlockHash != nil {
+		block.ParentHash = string(*info.ParentBlockHash)
+	}
+	return block, nil
+}
+
+func blockFromV2(info api.BlockchainBlockInfoV2) (BlockInfo, error) {
+	height, err := ParseHeight(info.Height)
+	if err != nil {
+		return BlockInfo{}, err
+	}
+	block := BlockInfo{
+		Hash:            string(info.BlockHash),
+		Height:          height,
+		ConsensusTime:   string(info.ConsensusTime),
+		MinerTime:       string(info.MinerTime),
+		NTransactions:   string(info.NTransactions),
+		NBalanceUpdates: string(info.NBalanceUpdates),
+		Stale:           IsStale(info.Stale),
+	}
+	if info.ParentBlockHash != nil {
+		block.ParentHash = string(*info.ParentBlockHash)
+	}
+	return block, nil
+}
+
+func transactionFromV1(info api.BlockchainBlockTransactionInfo, block BlockInfo) TransactionInfo {
+	return TransactionInfo{
+		Id:              string(info.TransactionHash),
+		BlockHash:       block.Hash,
+		Height:          block.Height,
+		ConsensusTime:   string(info.ConsensusTime),
+		Amount:          string(info.Amount),
+		NBalanceUpdates: string(info.NBalanceUpdates),
+		Stale:           block.Stale || IsStale(info.Stale),
+	}
+}
+
+func transactionFromV2(info api.BlockchainBlockTransactionInfoV2, block BlockInfo) TransactionInfo {
+	return TransactionInfo{
+		Id:              string(info.Txid),
+		BlockHash:       block.Hash,
+		Height:          block.Height,
+		ConsensusTime:   string(info.ConsensusTime),
+		Amount:          string(info.Amount),
+		NBalanceUpdates: string(info.NBalanceUpdates),
+		Stale:           block.Stale || IsStale(info.Stale),
+	}
+}
+
+func updateFromV1(update api.BlockchainTransactionBalanceUpdate, tx TransactionInfo) BalanceUpdate {
+	return BalanceUpdate{
+		Account:             string(update.Account),
+		Change:              string(update.Change),
+		PreviousBalance:     string(update.PreviousBalance),
+		NewBalance:          string(update.NewBalance),
+		ChainSequenceNumber: string(update.ChainSequenceNumber),
+		TransactionId:       tx.Id,
+		BlockHash:           tx.BlockHash,
+		Height:              tx.Height,
+		ConsensusTime:       tx.ConsensusTime,
+		Stale:               tx.Stale,
+	}
+}
+
+func updateFromV2(update api.BlockchainTransactionBalanceUpdateV2, tx TransactionInfo) BalanceUpdate {
+	return BalanceUpdate{
+		Account:             string(update.Account),
+		Change:              string(update.Change),
+		PreviousBalance:     string(update.PreviousBalance),
+		NewBalance:          string(update.NewBalance),
+		ChainSequenceNumber: string(update.ChainSequenceNumber),
+		TransactionId:       tx.Id,
+		BlockHash:           tx.BlockHash,
+		Height:              tx.Height,
+		ConsensusTime:       tx.ConsensusTime,
+		Stale:               tx.Stale || IsStale(update.Stale),
+	}
+}
+
+func updatesFromV1(updates *api.BlockchainTransactionBalanceUpdates, tx TransactionInfo) []BalanceUpdate {
+	if updates == nil {
+		return nil
+	}
+	result := make([]BalanceUpdate, 0, len(*updates))
+	for _, update := range *updates {
+		result = append(result, updateFromV1(update, tx))
+	}
+	return result
+}
+
+func updatesFromV2(updates *api.BlockchainTransactionBalanceUpdatesV2, tx TransactionInfo) []BalanceUpdate {
+	if updates == nil {
+		return nil
+	}
+	result := make([]BalanceUpdate, 0, len(*updates))
+	for _, update := range *updates {
+		result = append(result, updateFromV2(update, tx))
+	}
+	return result
+}
@@ -0,0 +1,133 @@
+package blockchain_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/rulesng/coinmetrics-go-sdk/blockchain"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func registerFacadeJson(url, body string) {
+	httpmock.RegisterResponder(http.MethodGet, url, func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(http.StatusOK, body)
+		resp.Header.Set(`Content-Type`, `application/json`)
+		return resp, nil
+	})
+}
+
+func TestBlockchainV2(t *testing.T) {
+	url := fmt.Sprintf(`%s%s/blockchain-v2/eth`, constants.TestEndpoint, constants.ApiVersion)
+	registerFacadeJson(url+`/blocks`, `{"data":[
+		{"block_hash":"hash-2","parent_block_hash":"hash-1","height":"2","consensus_time":"2022-05-01T00:10:00.000000000Z","miner_time":"2022-05-01T00:10:00.000000000Z","n_transactions":"1","n_balance_updates":"2","stale":"true"}
+	]}`)
+	registerFacadeJson(url+`/transactions/tx1`, `{"txid":"tx1","amount":"1","height":"2","block_hash":"hash-2","consensus_time":"2022-05-01T00:10:00.000000000Z","n_balance_updates":"2",
+		"balance_updates":[{"account":"addrA","change":"-1","chain_sequence_number":"1","credit":"false"},{"account":"addrB","change":"1","chain_sequence_number":"2","credit":"true"}]}`)
+
+	chain := blockchain.NewBlockchain(_coinmetrics)
+	assert.Equal(t, blockchain.Auto, chain.Generation(`eth`))
+	blocks, err := chain.Blocks(context.Background(), `eth`, blockchain.Query{})
+	assert.Nil(t, err)
+	assert.Equal(t, []blockchain.BlockInfo{{
+		Hash: `hash-2`, ParentHash: `hash-1`, Height: 2,
+		ConsensusTime: `2022-05-01T00:10:00.000000000Z`, MinerTime: `2022-05-01T00:10:00.000000000Z`,
+		NTransactions: `1`, NBalanceUpdates: `2`, Stale: true,
+	}}, blocks)
+	assert.Equal(t, blockchain.V2, chain.Generation(`eth`))
+
+	tx, err := chain.Transaction(context.Background(), `eth`, `tx1`)
+	assert.Nil(t, err)
+	assert.Equal(t, `tx1`, tx.Id)
+	assert.Equal(t, int64(2), tx.Height)
+	assert.Equal(t, 2, len(tx.BalanceUpdates))
+	assert.Equal(t, `tx1`, tx.BalanceUpdates[1].TransactionId)
+	assert.Equal(t, `hash-2`, tx.BalanceUpdates[1].BlockHash)
+	assert.True(t, tx.BalanceUpdates[1].Credit())
+	assert.False(t, tx.BalanceUpdates[0].Credit())
+}
+
+func TestBlockchainFallsBackToV1(t *testing.T) {
+	v1 := fmt.Sprintf(`%s%s/blockchain/ltc`, constants.TestEndpoint, constants.ApiVersion)
+	v2 := fmt.Sprintf(`%s%s/blockchain-v2/ltc`, constants.TestEndpoint, constants.ApiVersion)
+	v2Calls := 0
+	httpmock.RegisterResponder(http.MethodGet, `=~^`+v2+`/`, func(req *http.Request) (*http.Response, error) {
+		v2Calls++
+		return httpmock.NewStringResponse(http.StatusForbidden, `{"error":{"type":"forbidden","message":"Requested resource is not available with supplied credentials."}}`), nil
+	})
+	registerFacadeJson(v1+`/balance-updates`, `{"data":[
+		{"account":"addrA","change":"-1","previous_balance":"1","new_balance":"0","chain_sequence_number":"1","transaction_hash":"hash-tx1","block_hash":"hash-2","height":"2","consensus_time":"2022-05-01T00:10:00.000000000Z"}
+	],"next_page_token":"page2"}`)
+	registerFacadeJson(v1+`/accounts`, `{"data":[{"account":"addrA","balance":"0","n_debits":"1","n_credits":"1"}]}`)
+
+	chain := blockchain.NewBlockchain(_coinmetrics)
+	updates, err := chain.BalanceUpdates(context.Background(), `ltc`, blockchain.Query{Accounts: []string{`addrA`}, Limit: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, []blockchain.BalanceUpdate{{
+		Account: `addrA`, Change: `-1`, PreviousBalance: `1`, NewBalance: `0`, ChainSequenceNumber: `1`,
+		TransactionId: `hash-tx1`, BlockHash: `hash-2`, Height: 2, ConsensusTime: `2022-05-01T00:10:00.000000000Z`,
+	}}, updates)
+	assert.Equal(t, blockchain.V1, chain.Generation(`ltc`))
+	assert.Equal(t, 1, v2Calls)
+
+	accounts, err := chain.Accounts(context.Background(), `ltc`, []string{`addrA`})
+	assert.Nil(t, err)
+	assert.Equal(t, `0`, string(accounts[0].Balance))
+	assert.Equal(t, 1, v2Calls)
+}
+
+func TestBlockchainForcedGeneration(t *testing.T) {
+	v2 := fmt.Sprintf(`%s%s/blockchain-v2/ltc`, constants.TestEndpoint, constants.ApiVersion)
+	httpmock.RegisterResponder(http.MethodGet, v2+`/accounts`, httpmock.NewStringResponder(http.StatusForbidden, `{}`))
+
+	chain := blockchain.NewBlockchain(_coinmetrics, blockchain.WithGeneration(blockchain.V2))
+	_, err := chain.Accounts(context.Background(), `ltc`, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, blockchain.V2, chain.Generation(`ltc`))
+}
+
+func TestBlockchainErrorsOfRequest(t *testing.T) {
+	v1 := fmt.Sprintf(`%s%s/blockchain/doge`, constants.TestEndpoint, constants.ApiVersion)
+	v2 := fmt.Sprintf(`%s%s/blockchain-v2/doge`, constants.TestEndpoint, constants.ApiVersion)
+	v1Calls := 0
+	httpmock.RegisterResponder(http.MethodGet, `=~^`+v1+`/`, func(req *http.Request) (*http.Response, error) {
+		v1Calls++
+		resp := httpmock.NewStringResponse(http.StatusOK, `{"data":[]}`)
+		resp.Header.Set(`Content-Type`, `application/json`)
+		return resp, nil
+	})
+	httpmock.RegisterResponder(http.MethodGet, v2+`/blocks/unknown`, httpmock.NewStringResponder(http.StatusNotFound, `{"error":{"type":"not_found","message":"Block not found."}}`))
+	httpmock.RegisterResponder(http.MethodGet, v2+`/blocks`, httpmock.NewStringResponder(http.StatusBadRequest, `{"error":{"type":"bad_parameter","message":"Bad parameter 'start_height'. Must be a number."}}`))
+
+	// unknown hash and bad params are returned as is
+	chain := blockchain.NewBlockchain(_coinmetrics)
+	_, err := chain.Block(context.Background(), `doge`, `unknown`)
+	assert.EqualError(t, err, `api error 404 not_found: Block not found.`)
+	_, err = chain.Blocks(context.Background(), `doge`, blockchain.Query{})
+	assert.EqualError(t, err, `api error 400 bad_parameter: Bad parameter 'start_height'. Must be a number.`)
+	assert.Equal(t, 0, v1Calls)
+	assert.Equal(t, blockchain.Auto, chain.Generation(`doge`))
+
+	// rejected asset is retried on v1, but asset is not pinned to v1
+	httpmock.RegisterResponder(http.MethodGet, v2+`/blocks`, httpmock.NewStringResponder(http.StatusBadRequest, `{"error":{"type":"bad_parameter","message":"Bad parameter 'asset'. Value 'doge' is not supported."}}`))
+	_, err = chain.Blocks(context.Background(), `doge`, blockchain.Query{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, v1Calls)
+	assert.Equal(t, blockchain.Auto, chain.Generation(`doge`))
+	// fallback does not depend on wording of message
+	httpmock.RegisterResponder(http.MethodGet, v2+`/blocks`, httpmock.NewStringResponder(http.StatusBadRequest, `{"error":{"type":"bad_parameter","message":"Value 'doge' of parameter 'asset' is not supported."}}`))
+	_, err = chain.Blocks(context.Background(), `doge`, blockchain.Query{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, v1Calls)
+
+	// transaction ids differ between generations, lookup is not retried on v1
+	httpmock.RegisterResponder(http.MethodGet, `=~^`+v2+`/transactions`, httpmock.NewStringResponder(http.StatusForbidden, `{"error":{"type":"forbidden","message":"Requested resource is not available with supplied credentials."}}`))
+	_, err = chain.Transaction(context.Background(), `doge`, `tx1`)
+	assert.EqualError(t, err, `api error 403 forbidden: Requested resource is not available with supplied credentials.`)
+	_, err = chain.Transactions(context.Background(), `doge`, blockchain.Query{Transactions: []string{`tx1`}})
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, v1Calls)
+}
@@ -0,0 +1,387 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// ErrReorgTooDeep is returned when common ancestor of new canonical chain is below reorg window
+var ErrReorgTooDeep = errors.New(constants.ReorgTooDeep)
+
+// DefaultMaxFollowInterval is longest wait of Follower between polls backing off from failures
+const DefaultMaxFollowInterval = 5 * time.Minute
+
+// EventKind tells whether block joined or left canonical chain
+type EventKind string
+
+// Kinds of events produced by Follower
+const (
+	Connected    EventKind = "connected"
+	Disconnected EventKind = "disconnected"
+)
+
+// Event is emitted by Follower for every block connected to or disconnected from canonical chain
+type Event struct {
+	Kind  EventKind
+	Block Block
+}
+
+// BlockRef identifies block of canonical chain
+type BlockRef struct {
+	Height int64
+	Hash   api.BlockchainBlockHash
+}
+
+// Follower tracks canonical chain of asset. Tip is the longest chain reported by asset chains,
+// ties between competing tips are resolved by number of mining pools working on them.
+// When canonical chain switches to other branch, blocks of abandoned branch are disconnected
+// from tip down to common ancestor before blocks of new branch are connected.
+type Follower struct {
+	walker      *Walker
+	start       int64
+	rollback    func(height int64) error
+	maxInterval time.Duration
+	onError     func(error)
+	canonical   []BlockRef
+}
+
+// callbackError marks errors returned by callbacks, they stop Run instead of being retried
+type callbackError struct {
+	err error
+}
+
+func (e callbackError) Error() string {
+	return e.err.Error()
+}
+
+func (e callbackError) Unwrap() error {
+	return e.err
+}
+
+// FollowerOption allows to customize Follower
+type FollowerOption func(*Follower)
+
+// WithRollback is called with lowest disconnected height once all blocks of reorg are disconnected
+// and before blocks of new branch are connected, so downstream state can be rolled back at once
+func WithRollback(fn func(height int64) error) FollowerOption {
+	return func(f *Follower) {
+		f.rollback = fn
+	}
+}
+
+// WithMaxFollowInterval limits how long Run waits between polls backing off from failures
+func WithMaxFollowInterval(d time.Duration) FollowerOption {
+	return func(f *Follower) {
+		f.maxInterval = d
+	}
+}
+
+// WithFollowerErrorHandler receives errors of polls retried by Run, by default they are logged
+func WithFollowerErrorHandler(fn func(error)) FollowerOption {
+	return func(f *Follower) {
+		f.onError = fn
+	}
+}
+
+// NewFollower creates follower connecting blocks from height on. Client, poll interval, reorg window
+// and concurrency of full block fetches are taken from walker.
+func NewFollower(walker *Walker, from int64, opts ...FollowerOption) *Follower {
+	f := Follower{
+		walker:      walker,
+		start:       from,
+		maxInterval: DefaultMaxFollowInterval,
+		onError: func(err error) {
+			log.Printf(`blockchain: %s`, err)
+		},
+	}
+	for _, opt := range opts {
+		opt(&f)
+	}
+	return &f
+}
+
+// Canonical returns connected blocks within reorg window ordered by height
+func (f *Follower) Canonical() []BlockRef {
+	return append([]BlockRef(nil), f.canonical...)
+}
+
+// Run polls chain until ctx is done. Returning ErrStopWalk from fn stops following without error. Failed polls, like
+// network errors, 429 or 5xx, are passed to error handler and retried with poll interval doubling up to max interval.
+// Run returns only when ctx is done, on ErrReorgTooDeep or with error of fn or rollback.
+func (f *Follower) Run(ctx context.Context, fn func(Event) error) error {
+	interval := f.walker.pollInterval
+	for {
+		err := f.Poll(ctx, func(event Event) error {
+			err := fn(event)
+			if err != nil && !errors.Is(err, ErrStopWalk) {
+				return callbackError{err}
+			}
+			return err
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var callbackErr callbackError
+		switch {
+		case err == nil:
+			interval = f.walker.pollInterval
+		case errors.As(err, &callbackErr):
+			return callbackErr.err
+		case errors.Is(err, ErrStopWalk):
+			return nil
+		case errors.Is(err, ErrReorgTooDeep):
+			return err
+		default:
+			f.onError(err)
+			if interval *= 2; interval > f.maxInterval {
+				interval = f.maxInterval
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Poll brings canonical chain up to current tip emitting events for every change
+func (f *Follower) Poll(ctx context.Context, fn func(Event) error) error {
+	tip, err := f.tip(ctx)
+	if err != nil || tip == nil {
+		return err
+	}
+	if f.contains(*tip) {
+		if tip.Height == f.next()-1 {
+			return nil
+		}
+	} else if catchUp := tip.Height - f.window(); catchUp >= f.next() {
+		// Blocks deep below tip are final enough to be walked on main chain
+		if err := f.walker.walk(ctx, f.next(), catchUp, MainChain, func(block Block) error {
+			return f.connect(block, fn)
+		}); err != nil {
+			return err
+		}
+	}
+	return f.reconcile(ctx, *tip, fn)
+}
+
+// reconcile follows parent links from tip down to connected block and switches canonical chain to that branch
+func (f *Follower) reconcile(ctx context.Context, tip BlockRef, fn func(Event) error) error {
+	lower := f.next()
+	if len(f.canonical) > 0 {
+		lower = f.canonical[0].Height
+	}
+	if tip.Height < lower {
+		return nil
+	}
+	blocks := map[api.BlockchainBlockHash]api.BlockchainBlockInfoV2{}
+	err := listBlocks(ctx, f.walker.client, f.walker.asset, lower, tip.Height, AllChains, false, func(page []api.BlockchainBlockInfoV2) error {
+		for _, info := range page {
+			blocks[info.BlockHash] = info
+		}
+		return nil
+	}, f.walker.reqEditors...)
+	if err != nil {
+		return err
+	}
+
+	// branch holds blocks of new canonical chain above common ancestor, from tip down
+	var branch []api.BlockchainBlockInfoV2
+	ancestor, found := int64(0), false
+	lowest := tip.Height + 1
+	for hash := tip.Hash; ; {
+		info, ok := blocks[hash]
+		if !ok {
+			break
+		}
+		height, err := ParseHeight(info.Height)
+		if err != nil {
+			return err
+		}
+		if f.contains(BlockRef{Height: height, Hash: hash}) {
+			ancestor, found = height, true
+			break
+		}
+		branch = append(branch, info)
+		lowest = height
+		if info.ParentBlockHash == nil {
+			break
+		}
+		hash = *info.ParentBlockHash
+	}
+	if !found {
+		switch {
+		case len(branch) == 0:
+			// Tip is not listed yet, it is picked up by next poll
+			return nil
+		case len(f.canonical) == 0 && lowest == lower:
+			ancestor = lower - 1
+		default:
+			return ErrReorgTooDeep
+		}
+	}
+
+	var abandoned []api.BlockchainBlockInfoV2
+	for i := len(f.canonical) - 1; i >= 0 && f.canonical[i].Height > ancestor; i-- {
+		ref := f.canonical[i]
+		abandoned = append(abandoned, api.BlockchainBlockInfoV2{BlockHash: ref.Hash, Height: api.BlockchainBlockHeight(strconv.FormatInt(ref.Height, 10))})
+	}
+	if err := f.walker.yield(ctx, abandoned, func(block Block) error {
+		return f.disconnect(block, fn)
+	}); err != nil {
+		return err
+	}
+	if len(abandoned) > 0 && f.rollback != nil {
+		if err := f.rollback(ancestor + 1); err != nil {
+			return callbackError{err}
+		}
+	}
+
+	for i, j := 0, len(branch)-1; i < j; i, j = i+1, j-1 {
+		branch[i], branch[j] = branch[j], branch[i]
+	}
+	return f.walker.yield(ctx, branch, func(block Block) error {
+		return f.connect(block, fn)
+	})
+}
+
+// connect emits block and appends it to canonical chain once it is consumed
+func (f *Follower) connect(block Block, fn func(Event) error) error {
+	err := fn(Event{Kind: Connected, Block: block})
+	if err != nil && !errors.Is(err, ErrStopWalk) {
+		return err
+	}
+	f.canonical = append(f.canonical, BlockRef{Height: block.Height, Hash: block.BlockHash})
+	if extra := int64(len(f.canonical)) - f.window() - 1; extra > 0 {
+		f.canonical = f.canonical[extra:]
+	}
+	return err
+}
+
+// disconnect emits block and removes it from top of canonical chain once it is consumed
+func (f *Follower) disconnect(block Block, fn func(Event) error) error {
+	err := fn(Event{Kind: Disconnected, Block: block})
+	if err != nil && !errors.Is(err, ErrStopWalk) {
+		return err
+	}
+	f.canonical = f.canonical[:len(f.canonical)-1]
+	return err
+}
+
+func (f *Follower) contains(ref BlockRef) bool {
+	for _, block := range f.canonical {
+		if block == ref {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Follower) next() int64 {
+	if len(f.canonical) == 0 {
+		return f.start
+	}
+	return f.canonical[len(f.canonical)-1].Height + 1
+}
+
+func (f *Follower) window() int64 {
+	if f.walker.reorgWindow < 1 {
+		return 1
+	}
+	return f.walker.reorgWindow
+}
+
+// tip picks tip of longest chain from latest asset chains, ties are resolved by mining pool tips
+// and then in favour of already connected block
+func (f *Follower) tip(ctx context.Context) (*BlockRef, error) {
+	pageSize := api.PageSize(1)
+	pagingFrom := api.GetAssetChainsParamsPagingFrom(pagingFromEnd)
+	res, err := f.walker.client.GetAssetChainsWithResponse(ctx, &api.GetAssetChainsParams{
+		Assets:     api.AssetId(f.walker.asset),
+		PageSize:   &pageSize,
+		PagingFrom: &pagingFrom,
+	}, f.walker.reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	if res.JSON200 == nil {
+		return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+	}
+	if len(res.JSON200.Data) == 0 {
+		return nil, nil
+	}
+	latest := res.JSON200.Data[len(res.JSON200.Data)-1]
+	if len(f.canonical) > 0 && latest.Reorg != nil && string(*latest.Reorg) == `true` && latest.ReorgDepth != nil {
+		if depth, err := strconv.ParseInt(string(*latest.ReorgDepth), 10, 64); err == nil && depth > f.window() {
+			return nil, ErrReorgTooDeep
+		}
+	}
+
+	var pools map[api.BlockchainBlockHash]int64
+	var best *BlockRef
+	for _, chain := range latest.Chains {
+		var candidate *BlockRef
+		for _, block := range chain {
+			height, err := ParseHeight(block.Height)
+			if err != nil {
+				return nil, err
+			}
+			if candidate == nil || height > candidate.Height {
+				candidate = &BlockRef{Height: height, Hash: block.Hash}
+			}
+		}
+		switch {
+		case candidate == nil:
+			continue
+		case best == nil || candidate.Height > best.Height:
+			best = candidate
+		case candidate.Height == best.Height && candidate.Hash != best.Hash:
+			if pools == nil {
+				if pools, err = f.poolCounts(ctx); err != nil {
+					return nil, err
+				}
+			}
+			if pools[candidate.Hash] > pools[best.Hash] || (pools[candidate.Hash] == pools[best.Hash] && f.contains(*candidate)) {
+				best = candidate
+			}
+		}
+	}
+	return best, nil
+}
+
+// poolCounts returns number of mining pools working on every tip of latest mining pool tips summary
+func (f *Follower) poolCounts(ctx context.Context) (map[api.BlockchainBlockHash]int64, error) {
+	pageSize := api.PageSize(1)
+	pagingFrom := api.GetTimeseriesMiningPoolTipsSummaryParamsPagingFrom(pagingFromEnd)
+	res, err := f.walker.client.GetTimeseriesMiningPoolTipsSummaryWithResponse(ctx, &api.GetTimeseriesMiningPoolTipsSummaryParams{
+		Assets:     api.AssetId(f.walker.asset),
+		PageSize:   &pageSize,
+		PagingFrom: &pagingFrom,
+	}, f.walker.reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	if res.JSON200 == nil {
+		return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+	}
+	pools := map[api.BlockchainBlockHash]int64{}
+	if len(res.JSON200.Data) == 0 {
+		return pools, nil
+	}
+	for _, tip := range res.JSON200.Data[len(res.JSON200.Data)-1].Tips {
+		count, err := strconv.ParseInt(string(tip.PoolCount), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		pools[tip.Hash] = count
+	}
+	return pools, nil
+}
@@ -0,0 +1,148 @@
+package blockchain_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/rulesng/coinmetrics-go-sdk/blockchain"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+type followerEvents []string
+
+func (e *followerEvents) handle(event blockchain.Event) error {
+	*e = append(*e, fmt.Sprintf(`%s %s`, event.Kind, event.Block.BlockHash))
+	return nil
+}
+
+func TestFollowerReorg(t *testing.T) {
+	chain := newTestChain(20)
+	chain.register()
+
+	var rollbacks []int64
+	walker := blockchain.NewWalker(_coinmetrics, `btc`, blockchain.WithReorgWindow(3))
+	follower := blockchain.NewFollower(walker, 17, blockchain.WithRollback(func(height int64) error {
+		rollbacks = append(rollbacks, height)
+		return nil
+	}))
+
+	var events followerEvents
+	assert.Nil(t, follower.Poll(context.Background(), events.handle))
+	assert.Equal(t, followerEvents{`connected hash-17`, `connected hash-18`, `connected hash-19`, `connected hash-20`}, events)
+
+	// Nothing changes until tip moves
+	events = nil
+	assert.Nil(t, follower.Poll(context.Background(), events.handle))
+	assert.Nil(t, events)
+
+	// Blocks 19 and 20 are orphaned by longer branch
+	chain.markStale(`hash-19`)
+	chain.markStale(`hash-20`)
+	chain.add(19, `b`)
+	chain.add(20, `b`)
+	chain.add(21, `b`)
+	assert.Nil(t, follower.Poll(context.Background(), events.handle))
+	assert.Equal(t, followerEvents{
+		`disconnected hash-20`,
+		`disconnected hash-19`,
+		`connected hash-19b`,
+		`connected hash-20b`,
+		`connected hash-21b`,
+	}, events)
+	assert.Equal(t, []int64{19}, rollbacks)
+	assert.Equal(t, []blockchain.BlockRef{{Height: 18, Hash: `hash-18`}, {Height: 19, Hash: `hash-19b`}, {Height: 20, Hash: `hash-20b`}, {Height: 21, Hash: `hash-21b`}}, follower.Canonical())
+}
+
+func TestFollowerCatchUp(t *testing.T) {
+	newTestChain(250).register()
+	walker := blockchain.NewWalker(_coinmetrics, `btc`)
+	follower := blockchain.NewFollower(walker, 0)
+
+	var heights []int64
+	assert.Nil(t, follower.Poll(context.Background(), func(event blockchain.Event) error {
+		assert.Equal(t, blockchain.Connected, event.Kind)
+		heights = append(heights, event.Block.Height)
+		return nil
+	}))
+	assert.Equal(t, heightRange(0, 250), heights)
+}
+
+func TestFollowerPrefersTipWithMorePools(t *testing.T) {
+	chain := newTestChain(5)
+	chain.add(5, `b`)
+	chain.pools = map[string]int{`hash-5`: 1, `hash-5b`: 3}
+	chain.register()
+
+	follower := blockchain.NewFollower(blockchain.NewWalker(_coinmetrics, `btc`), 4)
+	var events followerEvents
+	assert.Nil(t, follower.Poll(context.Background(), events.handle))
+	assert.Equal(t, followerEvents{`connected hash-4`, `connected hash-5b`}, events)
+
+	events = nil
+	chain.pools = map[string]int{`hash-5`: 4, `hash-5b`: 3}
+	assert.Nil(t, follower.Poll(context.Background(), events.handle))
+	assert.Equal(t, followerEvents{`disconnected hash-5b`, `connected hash-5`}, events)
+}
+
+func TestFollowerReorgTooDeep(t *testing.T) {
+	chain := newTestChain(10)
+	chain.register()
+
+	walker := blockchain.NewWalker(_coinmetrics, `btc`, blockchain.WithReorgWindow(2))
+	follower := blockchain.NewFollower(walker, 5)
+	var events followerEvents
+	assert.Nil(t, follower.Poll(context.Background(), events.handle))
+
+	for height := 7; height <= 10; height++ {
+		chain.markStale(fmt.Sprintf(`hash-%d`, height))
+	}
+	for height := 7; height <= 11; height++ {
+		chain.add(height, `b`)
+	}
+	assert.Equal(t, blockchain.ErrReorgTooDeep, follower.Poll(context.Background(), events.handle))
+}
+
+func TestFollowerRunRecovers(t *testing.T) {
+	chain := newTestChain(5)
+	chain.register()
+	failures := 0
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf(`%s%s/timeseries/asset-chains`, constants.TestEndpoint, constants.ApiVersion), func(req *http.Request) (*http.Response, error) {
+		if failures < 2 {
+			failures++
+			return httpmock.NewStringResponse(http.StatusInternalServerError, ``), nil
+		}
+		return chain.chains(req)
+	})
+
+	var errs []error
+	walker := blockchain.NewWalker(_coinmetrics, `btc`, blockchain.WithPollInterval(time.Millisecond))
+	follower := blockchain.NewFollower(walker, 4, blockchain.WithMaxFollowInterval(5*time.Millisecond), blockchain.WithFollowerErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+	var events followerEvents
+	err := follower.Run(context.Background(), func(event blockchain.Event) error {
+		_ = events.handle(event)
+		if event.Block.Height == 5 {
+			return blockchain.ErrStopWalk
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, followerEvents{`connected hash-4`, `connected hash-5`}, events)
+	if assert.Len(t, errs, 2) {
+		assert.EqualError(t, errs[0], `api error 500: Internal Server Error`)
+	}
+
+	// errors of callback stop following
+	chain.add(6, ``)
+	err = follower.Run(context.Background(), func(event blockchain.Event) error {
+		return errors.New(`downstream failed`)
+	})
+	assert.EqualError(t, err, `downstream failed`)
+}
@@ -0,0 +1,455 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+)
+
+// NodeKind is the type of flow graph node
+type NodeKind string
+
+// Kinds of flow graph nodes
+const (
+	TransactionNode NodeKind = "transaction"
+	AccountNode     NodeKind = "account"
+)
+
+// Defaults of GraphBuilder
+const (
+	DefaultMaxHops   = 2
+	DefaultMaxFanOut = 25
+	DefaultMaxNodes  = 1000
+)
+
+// Node is transaction or account of flow graph
+type Node struct {
+	// Id is unique within graph, it is Label prefixed with kind
+	Id    string
+	Kind  NodeKind
+	Label string
+	// Depth is number of hops from seed
+	Depth int
+	// Height and Time of transaction, for accounts block where account was reached
+	Height int64
+	Time   string
+	// Amount is sum of debits of transaction, empty for accounts
+	Amount string
+	// Truncated is set when some neighbours were left out because of fan-out or node limit
+	Truncated bool
+}
+
+// Edge is flow of funds, from account to transaction for debits and from transaction to account for credits
+type Edge struct {
+	From   string
+	To     string
+	Amount string
+}
+
+// FlowGraph is graph of transactions and accounts linked by balance updates
+type FlowGraph struct {
+	Nodes []*Node
+	Edges []Edge
+
+	nodes   map[string]*Node
+	amounts map[[2]string]*big.Rat
+	updates map[api.BlockchainChainSequenceNumber]bool
+}
+
+func newFlowGraph() *FlowGraph {
+	return &FlowGraph{
+		nodes:   map[string]*Node{},
+		amounts: map[[2]string]*big.Rat{},
+		updates: map[api.BlockchainChainSequenceNumber]bool{},
+	}
+}
+
+// Node returns node by id or nil
+func (g *FlowGraph) Node(id string) *Node {
+	return g.nodes[id]
+}
+
+// TransactionId returns node id of transaction
+func TransactionId(txid string) string {
+	return fmt.Sprintf(`%s:%s`, TransactionNode, txid)
+}
+
+// AccountId returns node id of account
+func AccountId(account string) string {
+	return fmt.Sprintf(`%s:%s`, AccountNode, account)
+}
+
+func (g *FlowGraph) node(kind NodeKind, label string, depth int) (*Node, bool) {
+	id := fmt.Sprintf(`%s:%s`, kind, label)
+	if node, ok := g.nodes[id]; ok {
+		return node, false
+	}
+	node := &Node{Id: id, Kind: kind, Label: label, Depth: depth}
+	g.nodes[id] = node
+	g.Nodes = append(g.Nodes, node)
+	return node, true
+}
+
+// link adds balance update to graph, updates are counted once however many times they are reached
+func (g *FlowGraph) link(tx, account *Node, update api.BlockchainTransactionBalanceUpdateV2) error {
+	if g.updates[update.ChainSequenceNumber] {
+		return nil
+	}
+	g.updates[update.ChainSequenceNumber] = true
+	change, err := parseAmount(string(update.Change))
+	if err != nil {
+		return err
+	}
+	key := [2]string{tx.Id, account.Id}
+	if change.Sign() < 0 {
+		key = [2]string{account.Id, tx.Id}
+	}
+	amount, ok := g.amounts[key]
+	if !ok {
+		amount = new(big.Rat)
+		g.amounts[key] = amount
+		g.Edges = append(g.Edges, Edge{From: key[0], To: key[1]})
+	}
+	amount.Add(amount, change.Abs(change))
+	return nil
+}
+
+func (g *FlowGraph) finish() {
+	for i, edge := range g.Edges {
+		g.Edges[i].Amount = FormatAmount(g.amounts[[2]string{edge.From, edge.To}])
+	}
+}
+
+// GraphBuilder expands flow graph from seed transaction or account through balance updates
+type GraphBuilder struct {
+	client     api.ClientWithResponsesInterface
+	asset      api.BlockchainAsset
+	maxHops    int
+	maxFanOut  int
+	maxNodes   int
+	reqEditors []api.RequestEditorFn
+}
+
+// GraphOption allows to customize GraphBuilder
+type GraphOption func(*GraphBuilder)
+
+// WithMaxHops limits distance of nodes from seed, transaction to account is single hop
+func WithMaxHops(n int) GraphOption {
+	return func(b *GraphBuilder) {
+		b.maxHops = n
+	}
+}
+
+// WithMaxFanOut limits number of neighbours expanded from single node, transactions keep largest balance updates
+// and accounts keep earliest transactions after block they were reached in
+func WithMaxFanOut(n int) GraphOption {
+	return func(b *GraphBuilder) {
+		if n > 0 {
+			b.maxFanOut = n
+		}
+	}
+}
+
+// WithMaxNodes limits size of the whole graph
+func WithMaxNodes(n int) GraphOption {
+	return func(b *GraphBuilder) {
+		if n > 0 {
+			b.maxNodes = n
+		}
+	}
+}
+
+// WithGraphRequestEditors are passed to every call made by graph builder
+func WithGraphRequestEditors(fns ...api.RequestEditorFn) GraphOption {
+	return func(b *GraphBuilder) {
+		b.reqEditors = append(b.reqEditors, fns...)
+	}
+}
+
+// NewGraphBuilder creates graph builder over transactions of asset
+func NewGraphBuilder(client api.ClientWithResponsesInterface, asset string, opts ...GraphOption) *GraphBuilder {
+	b := GraphBuilder{
+		client:    client,
+		asset:     api.BlockchainAsset(asset),
+		maxHops:   DefaultMaxHops,
+		maxFanOut: DefaultMaxFanOut,
+		maxNodes:  DefaultMaxNodes,
+	}
+	for _, opt := range opts {
+		opt(&b)
+	}
+	return &b
+}
+
+// FromTransaction builds graph around transaction
+func (b *GraphBuilder) FromTransaction(ctx context.Context, txid string) (*FlowGraph, error) {
+	g := newFlowGraph()
+	seed, _ := g.node(TransactionNode, txid, 0)
+	return b.expand(ctx, g, seed)
+}
+
+// FromAccount builds graph around account following its transactions from the beginning
+func (b *GraphBuilder) FromAccount(ctx context.Context, account string) (*FlowGraph, error) {
+	g := newFlowGraph()
+	seed, _ := g.node(AccountNode, account, 0)
+	return b.expand(ctx, g, seed)
+}
+
+// expand visits nodes breadth first so that node limit keeps nodes closest to seed
+func (b *GraphBuilder) expand(ctx context.Context, g *FlowGraph, seed *Node) (*FlowGraph, error) {
+	queue := []*Node{seed}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		var next []*Node
+		var err error
+		if node.Kind == TransactionNode {
+			next, err = b.expandTransaction(ctx, g, node)
+		} else {
+			next, err = b.expandAccount(ctx, g, node)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, neighbour := range next {
+			if neighbour.Depth < b.maxHops {
+				queue = append(queue, neighbour)
+			}
+		}
+	}
+	g.finish()
+	return g, nil
+}
+
+// add creates neighbour of node unless graph is full, new nodes are returned for expansion
+func (b *GraphBuilder) add(g *FlowGraph, node *Node, kind NodeKind, label string) (*Node, bool) {
+	if existing := g.nodes[fmt.Sprintf(`%s:%s`, kind, label)]; existing != nil {
+		return existing, false
+	}
+	if len(g.Nodes) >= b.maxNodes {
+		node.Truncated = true
+		return nil, false
+	}
+	return g.node(kind, label, node.Depth+1)
+}
+
+func (b *GraphBuilder) expandTransaction(ctx context.Context, g *FlowGraph, node *Node) ([]*Node, error) {
+	res, err := b.client.GetBlockchainV2FullTransactionWithResponse(ctx, b.asset, api.BlockchainTransactionId(node.Label), &api.GetBlockchainV2FullTransactionParams{}, b.reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	if res.JSON200 == nil {
+		return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+	}
+	tx := res.JSON200
+	if node.Height, err = ParseHeight(tx.Height); err != nil {
+		return nil, err
+	}
+	node.Time, node.Amount = string(tx.ConsensusTime), string(tx.Amount)
+	if tx.BalanceUpdates == nil {
+		return nil, nil
+	}
+
+	// Largest flows are kept when fan-out is limited
+	updates := append(api.BlockchainTransactionBalanceUpdatesV2(nil), *tx.BalanceUpdates...)
+	changes := map[api.BlockchainChainSequenceNumber]*big.Rat{}
+	for _, update := range updates {
+		change, err := parseAmount(string(update.Change))
+		if err != nil {
+			return nil, err
+		}
+		changes[update.ChainSequenceNumber] = change.Abs(change)
+	}
+	sort.SliceStable(updates, func(i, j int) bool {
+		return changes[updates[i].ChainSequenceNumber].Cmp(changes[updates[j].ChainSequenceNumber]) > 0
+	})
+
+	var next []*Node
+	accounts := map[string]bool{}
+	for _, update := range updates {
+		account := string(update.Account)
+		if !accounts[account] && len(accounts) == b.maxFanOut {
+			node.Truncated = true
+			continue
+		}
+		accounts[account] = true
+		neighbour, created := b.add(g, node, AccountNode, account)
+		if neighbour == nil {
+			continue
+		}
+		if created {
+			neighbour.Height, neighbour.Time = node.Height, node.Time
+			next = append(next, neighbour)
+		}
+		if err := g.link(node, neighbour, update); err != nil {
+			return nil, err
+		}
+	}
+	return next, nil
+}
+
+func (b *GraphBuilder) expandAccount(ctx context.Context, g *FlowGraph, node *Node) ([]*Node, error) {
+	accounts := api.BlockchainAccounts{node.Label}
+	chain := MainChain
+	pageSize := api.PageSize(b.maxFanOut)
+	params := api.GetBlockchainV2ListOfBalanceUpdatesParams{Accounts: &accounts, Chain: &chain, PageSize: &pageSize}
+	if node.Depth > 0 {
+		// Funds are followed forward from block where account was reached
+		start := api.BlockchainStartHeight(node.Height)
+		params.StartHeight = &start
+	}
+
+	var next []*Node
+	txids := map[string]bool{}
+	err := pages(func(token *api.NextPageToken) (*api.NextPageToken, error) {
+		params.NextPageToken = token
+		res, err := b.client.GetBlockchainV2ListOfBalanceUpdatesWithResponse(ctx, b.asset, &params, b.reqEditors...)
+		if err != nil {
+			return nil, err
+		}
+		if res.JSON200 == nil {
+			return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+		}
+		for _, update := range res.JSON200.Data {
+			if update.Txid == nil {
+				continue
+			}
+			txid := string(*update.Txid)
+			if !txids[txid] && len(txids) == b.maxFanOut {
+				node.Truncated = true
+				return nil, nil
+			}
+			txids[txid] = true
+			neighbour, created := b.add(g, node, TransactionNode, txid)
+			if neighbour == nil {
+				continue
+			}
+			if created {
+				if neighbour.Height, err = ParseHeight(update.Height); err != nil {
+					return nil, err
+				}
+				neighbour.Time = string(update.ConsensusTime)
+				next = append(next, neighbour)
+			}
+			if err := g.link(neighbour, node, update.BlockchainTransactionBalanceUpdateV2); err != nil {
+				return nil, err
+			}
+		}
+		return res.JSON200.NextPageToken, nil
+	})
+	return next, err
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	Id   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	Id   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes graph in GraphML format, node and edge attributes are exported as data keys
+func (g *FlowGraph) WriteGraphML(w io.Writer) error {
+	doc := graphML{
+		Xmlns: `http://graphml.graphdrawing.org/xmlns`,
+		Keys: []graphMLKey{
+			{Id: `kind`, For: `node`, Name: `kind`, Type: `string`},
+			{Id: `label`, For: `node`, Name: `label`, Type: `string`},
+			{Id: `depth`, For: `node`, Name: `depth`, Type: `int`},
+			{Id: `height`, For: `node`, Name: `height`, Type: `long`},
+			{Id: `time`, For: `node`, Name: `time`, Type: `string`},
+			{Id: `truncated`, For: `node`, Name: `truncated`, Type: `boolean`},
+			{Id: `amount`, For: `all`, Name: `amount`, Type: `string`},
+		},
+		Graph: graphMLGraph{EdgeDefault: `directed`},
+	}
+	for _, node := range g.Nodes {
+		data := []graphMLData{
+			{Key: `kind`, Value: string(node.Kind)},
+			{Key: `label`, Value: node.Label},
+			{Key: `depth`, Value: fmt.Sprint(node.Depth)},
+			{Key: `height`, Value: fmt.Sprint(node.Height)},
+			{Key: `time`, Value: node.Time},
+			{Key: `truncated`, Value: fmt.Sprint(node.Truncated)},
+		}
+		if node.Amount != `` {
+			data = append(data, graphMLData{Key: `amount`, Value: node.Amount})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{Id: node.Id, Data: data})
+	}
+	for _, edge := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: edge.From, Target: edge.To, Data: []graphMLData{{Key: `amount`, Value: edge.Amount}}})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent(``, `  `)
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteDOT writes graph in Graphviz DOT format, transactions are drawn as boxes and accounts as ellipses
+func (g *FlowGraph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph flow {\n")
+	for _, node := range g.Nodes {
+		shape := `ellipse`
+		if node.Kind == TransactionNode {
+			shape = `box`
+		}
+		style := ``
+		if node.Truncated {
+			style = `, style=dashed`
+		}
+		fmt.Fprintf(&b, "  %s [label=%s, shape=%s%s];\n", dotQuote(node.Id), dotQuote(node.Label), shape, style)
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", dotQuote(edge.From), dotQuote(edge.To), dotQuote(edge.Amount))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
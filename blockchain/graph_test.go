@@ -0,0 +1,107 @@
+package blockchain_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/rulesng/coinmetrics-go-sdk/blockchain"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+var testTransactions = map[string]string{
+	`tx1`: `{"txid":"tx1","amount":"1","height":"10","block_hash":"hash-10","consensus_time":"2022-05-01T00:00:00.000000000Z","min_chain_sequence_number":"1","max_chain_sequence_number":"3","n_balance_updates":"3",
+		"balance_updates":[
+			{"account":"addrC","change":"0.29","chain_sequence_number":"3"},
+			{"account":"addrA","change":"-1","chain_sequence_number":"1"},
+			{"account":"addrB","change":"0.7","chain_sequence_number":"2"}
+		]}`,
+}
+
+var testAccountUpdates = map[string]string{
+	`addrA`: `[{"account":"addrA","change":"-1","chain_sequence_number":"1","txid":"tx1","height":"10","consensus_time":"2022-05-01T00:00:00.000000000Z"}]`,
+	`addrB`: `[{"account":"addrB","change":"0.7","chain_sequence_number":"2","txid":"tx1","height":"10","consensus_time":"2022-05-01T00:00:00.000000000Z"},
+		{"account":"addrB","change":"-0.7","chain_sequence_number":"10","txid":"tx2","height":"11","consensus_time":"2022-05-01T00:10:00.000000000Z"}]`,
+	`addrC`: `[{"account":"addrC","change":"0.29","chain_sequence_number":"3","txid":"tx1","height":"10","consensus_time":"2022-05-01T00:00:00.000000000Z"}]`,
+}
+
+func registerGraphResponders() {
+	url := fmt.Sprintf(`%s%s/blockchain-v2/btc`, constants.TestEndpoint, constants.ApiVersion)
+	httpmock.RegisterResponder(http.MethodGet, `=~^`+url+`/transactions/([^/?]+)`, func(req *http.Request) (*http.Response, error) {
+		txid, _ := httpmock.GetSubmatch(req, 1)
+		resp := httpmock.NewStringResponse(http.StatusOK, testTransactions[txid])
+		resp.Header.Set(`Content-Type`, `application/json`)
+		return resp, nil
+	})
+	httpmock.RegisterResponder(http.MethodGet, url+`/balance-updates`, func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(http.StatusOK, fmt.Sprintf(`{"data":%s}`, testAccountUpdates[req.URL.Query().Get(`accounts`)]))
+		resp.Header.Set(`Content-Type`, `application/json`)
+		return resp, nil
+	})
+}
+
+func TestGraphFromTransaction(t *testing.T) {
+	registerGraphResponders()
+	graph, err := blockchain.NewGraphBuilder(_coinmetrics, `btc`).FromTransaction(context.Background(), `tx1`)
+	assert.Nil(t, err)
+
+	var nodes []string
+	for _, node := range graph.Nodes {
+		nodes = append(nodes, fmt.Sprintf(`%s depth=%d height=%d`, node.Id, node.Depth, node.Height))
+	}
+	assert.Equal(t, []string{
+		`transaction:tx1 depth=0 height=10`,
+		`account:addrA depth=1 height=10`,
+		`account:addrB depth=1 height=10`,
+		`account:addrC depth=1 height=10`,
+		`transaction:tx2 depth=2 height=11`,
+	}, nodes)
+	assert.Equal(t, []blockchain.Edge{
+		{From: `account:addrA`, To: `transaction:tx1`, Amount: `1`},
+		{From: `transaction:tx1`, To: `account:addrB`, Amount: `0.7`},
+		{From: `transaction:tx1`, To: `account:addrC`, Amount: `0.29`},
+		{From: `account:addrB`, To: `transaction:tx2`, Amount: `0.7`},
+	}, graph.Edges)
+	assert.Equal(t, `1`, graph.Node(blockchain.TransactionId(`tx1`)).Amount)
+}
+
+func TestGraphFanOut(t *testing.T) {
+	registerGraphResponders()
+	graph, err := blockchain.NewGraphBuilder(_coinmetrics, `btc`, blockchain.WithMaxFanOut(2), blockchain.WithMaxHops(1)).FromTransaction(context.Background(), `tx1`)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(graph.Nodes))
+	assert.True(t, graph.Node(blockchain.TransactionId(`tx1`)).Truncated)
+	assert.Nil(t, graph.Node(blockchain.AccountId(`addrC`)))
+
+	var dot bytes.Buffer
+	assert.Nil(t, graph.WriteDOT(&dot))
+	assert.Equal(t, `digraph flow {
+  "transaction:tx1" [label="tx1", shape=box, style=dashed];
+  "account:addrA" [label="addrA", shape=ellipse];
+  "account:addrB" [label="addrB", shape=ellipse];
+  "account:addrA" -> "transaction:tx1" [label="1"];
+  "transaction:tx1" -> "account:addrB" [label="0.7"];
+}
+`, dot.String())
+}
+
+func TestGraphFromAccountGraphML(t *testing.T) {
+	registerGraphResponders()
+	graph, err := blockchain.NewGraphBuilder(_coinmetrics, `btc`, blockchain.WithMaxHops(1)).FromAccount(context.Background(), `addrB`)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(graph.Nodes))
+
+	var out bytes.Buffer
+	assert.Nil(t, graph.WriteGraphML(&out))
+	graphML := out.String()
+	assert.True(t, strings.HasPrefix(graphML, `<?xml version="1.0" encoding="UTF-8"?>`))
+	assert.Contains(t, graphML, `<node id="account:addrB">`)
+	assert.Contains(t, graphML, `<edge source="transaction:tx1" target="account:addrB">`)
+	assert.Contains(t, graphML, `<edge source="account:addrB" target="transaction:tx2">`)
+	assert.Contains(t, graphML, `<data key="amount">0.7</data>`)
+}
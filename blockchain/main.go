@@ -0,0 +1,143 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// Chain types accepted by `chain` param of blockchain endpoints
+const (
+	MainChain api.BlockchainChainType = "main"
+	AllChains api.BlockchainChainType = "all"
+)
+
+// Paging directions of list endpoints
+const (
+	pagingFromStart api.GetBlockchainV2ListOfBlocksParamsPagingFrom = "start"
+	pagingFromEnd   api.GetBlockchainV2ListOfBlocksParamsPagingFrom = "end"
+)
+
+// decodeData converts `data` of list responses, which is generated as interface{}, into typed slice
+func decodeData(data interface{}, v interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// ParseHeight converts block height returned by api into number
+func ParseHeight(height api.BlockchainBlockHeight) (int64, error) {
+	return strconv.ParseInt(string(height), 10, 64)
+}
+
+// IsStale tells if block is flagged as stale, api omits the flag for blocks on main chain
+func IsStale(stale *api.BlockchainStaleBlock) bool {
+	return stale != nil && strings.EqualFold(string(*stale), `true`)
+}
+
+// pages calls fetch with token of every next page until last page is reached
+func pages(fetch func(token *api.NextPageToken) (*api.NextPageToken, error)) error {
+	var token *api.NextPageToken
+	for {
+		next, err := fetch(token)
+		if err != nil || next == nil {
+			return err
+		}
+		token = next
+	}
+}
+
+// listBlocks pages through blocks between heights and passes every page to fn ordered by height,
+// descending when backward is set
+func listBlocks(ctx context.Context, client api.ClientWithResponsesInterface, asset api.BlockchainAsset, from, to int64, chain api.BlockchainChainType, backward bool, fn func([]api.BlockchainBlockInfoV2) error, reqEditors ...api.RequestEditorFn) error {
+	start, end := api.BlockchainStartHeight(from), api.BlockchainEndHeight(to)
+	pageSize := api.PageSize(constants.DefaultPageSize)
+	pagingFrom := pagingFromStart
+	if backward {
+		pagingFrom = pagingFromEnd
+	}
+	params := api.GetBlockchainV2ListOfBlocksParams{
+		StartHeight: &start,
+		EndHeight:   &end,
+		Chain:       &chain,
+		PageSize:    &pageSize,
+		PagingFrom:  &pagingFrom,
+	}
+	for {
+		res, err := client.GetBlockchainV2ListOfBlocksWithResponse(ctx, asset, &params, reqEditors...)
+		if err != nil {
+			return err
+		}
+		if res.JSON200 == nil {
+			return coinmetrics.NewApiError(res.StatusCode(), res.Body)
+		}
+		var page []api.BlockchainBlockInfoV2
+		if err := decodeData(res.JSON200.Data, &page); err != nil {
+			return err
+		}
+		if err := sortBlocks(page, backward); err != nil {
+			return err
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		if res.JSON200.NextPageToken == nil || len(page) == 0 {
+			return nil
+		}
+		params.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// sortBlocks orders blocks by height, api orders pages the same way regardless of paging direction
+func sortBlocks(blocks []api.BlockchainBlockInfoV2, backward bool) error {
+	heights := make(map[api.BlockchainBlockHeight]int64, len(blocks))
+	for _, block := range blocks {
+		height, err := ParseHeight(block.Height)
+		if err != nil {
+			return err
+		}
+		heights[block.Height] = height
+	}
+	sort.SliceStable(blocks, func(i, j int) bool {
+		if backward {
+			return heights[blocks[i].Height] > heights[blocks[j].Height]
+		}
+		return heights[blocks[i].Height] < heights[blocks[j].Height]
+	})
+	return nil
+}
+
+// tip returns latest block of main chain
+func tip(ctx context.Context, client api.ClientWithResponsesInterface, asset api.BlockchainAsset, reqEditors ...api.RequestEditorFn) (*api.BlockchainBlockInfoV2, error) {
+	pageSize := api.PageSize(1)
+	pagingFrom := pagingFromEnd
+	chain := MainChain
+	params := api.GetBlockchainV2ListOfBlocksParams{
+		Chain:      &chain,
+		PageSize:   &pageSize,
+		PagingFrom: &pagingFrom,
+	}
+	res, err := client.GetBlockchainV2ListOfBlocksWithResponse(ctx, asset, &params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	if res.JSON200 == nil {
+		return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+	}
+	var blocks []api.BlockchainBlockInfoV2
+	if err := decodeData(res.JSON200.Data, &blocks); err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+	return &blocks[len(blocks)-1], nil
+}
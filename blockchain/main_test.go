@@ -0,0 +1,185 @@
+package blockchain_test
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+var _coinmetrics coinmetrics.CoinMetrics
+
+func TestMain(m *testing.M) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	var _err error
+	_coinmetrics, _err = coinmetrics.InitClient(constants.TestEndpoint, constants.TestKey)
+	if _err != nil {
+		fmt.Println(_err)
+	}
+	os.Exit(m.Run())
+}
+
+// testChain serves `/blockchain-v2/btc/blocks` endpoints from blocks kept in memory
+type testChain struct {
+	mu     sync.Mutex
+	blocks []api.BlockchainBlockInfoV2
+	// pools working on tips, reported by mining pool tips summary
+	pools map[string]int
+}
+
+func newTestChain(tip int) *testChain {
+	c := testChain{}
+	for height := 0; height <= tip; height++ {
+		c.add(height, ``)
+	}
+	return &c
+}
+
+// add appends main chain block at height on top of latest non stale block at height below,
+// suffix distinguishes blocks of competing branches
+func (c *testChain) add(height int, suffix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	block := api.BlockchainBlockInfoV2{
+		BlockHash:     api.BlockchainBlockHash(fmt.Sprintf(`hash-%d%s`, height, suffix)),
+		Height:        api.BlockchainBlockHeight(strconv.Itoa(height)),
+		ConsensusTime: `2022-05-01T00:00:00.000000000Z`,
+	}
+	for i := len(c.blocks) - 1; i >= 0; i-- {
+		if string(c.blocks[i].Height) == strconv.Itoa(height-1) && c.blocks[i].Stale == nil {
+			parent := c.blocks[i].BlockHash
+			block.ParentBlockHash = &parent
+			break
+		}
+	}
+	c.blocks = append(c.blocks, block)
+}
+
+func (c *testChain) markStale(hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stale := api.BlockchainStaleBlock(`true`)
+	for i := range c.blocks {
+		if string(c.blocks[i].BlockHash) == hash {
+			c.blocks[i].Stale = &stale
+		}
+	}
+}
+
+func (c *testChain) register() {
+	url := fmt.Sprintf(`%s%s/blockchain-v2/btc/blocks`, constants.TestEndpoint, constants.ApiVersion)
+	httpmock.RegisterResponder(http.MethodGet, url, c.list)
+	httpmock.RegisterResponder(http.MethodGet, `=~^`+url+`/([^/?]+)`, c.full)
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf(`%s%s/timeseries/asset-chains`, constants.TestEndpoint, constants.ApiVersion), c.chains)
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf(`%s%s/timeseries/mining-pool-tips-summary`, constants.TestEndpoint, constants.ApiVersion), c.tips)
+}
+
+// tipsLocked returns blocks without children
+func (c *testChain) tipsLocked() []api.BlockchainBlockInfoV2 {
+	parents := map[api.BlockchainBlockHash]bool{}
+	for _, block := range c.blocks {
+		if block.ParentBlockHash != nil {
+			parents[*block.ParentBlockHash] = true
+		}
+	}
+	var tips []api.BlockchainBlockInfoV2
+	for _, block := range c.blocks {
+		if !parents[block.BlockHash] {
+			tips = append(tips, block)
+		}
+	}
+	return tips
+}
+
+func (c *testChain) chains(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	chains := api.AssetChains{Asset: `btc`}
+	for _, tip := range c.tipsLocked() {
+		chains.Chains = append(chains.Chains, api.AssetChain{{Hash: tip.BlockHash, Height: tip.Height, Time: string(tip.ConsensusTime)}})
+	}
+	return httpmock.NewJsonResponse(http.StatusOK, api.AssetChainsResponse{Data: []api.AssetChains{chains}})
+}
+
+func (c *testChain) tips(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	summary := api.MiningPoolTipsSummary{Asset: `btc`}
+	for hash, count := range c.pools {
+		summary.Tips = append(summary.Tips, struct {
+			Hash      api.BlockchainBlockHash   `json:"hash"`
+			Height    api.BlockchainBlockHeight `json:"height"`
+			LastTime  api.Time                  `json:"last_time"`
+			PoolCount api.PoolCount             `json:"pool_count"`
+		}{Hash: api.BlockchainBlockHash(hash), PoolCount: api.PoolCount(strconv.Itoa(count))})
+	}
+	return httpmock.NewJsonResponse(http.StatusOK, api.MiningPoolTipsSummaryResponse{Data: []api.MiningPoolTipsSummary{summary}})
+}
+
+func (c *testChain) list(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	query := req.URL.Query()
+	start, end := int64(0), int64(1<<62)
+	if value := query.Get(`start_height`); value != `` {
+		start, _ = strconv.ParseInt(value, 10, 64)
+	}
+	if value := query.Get(`end_height`); value != `` {
+		end, _ = strconv.ParseInt(value, 10, 64)
+	}
+	var blocks []api.BlockchainBlockInfoV2
+	for _, block := range c.blocks {
+		height, _ := strconv.ParseInt(string(block.Height), 10, 64)
+		if height < start || height > end || (block.Stale != nil && query.Get(`chain`) != `all`) {
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+	sort.SliceStable(blocks, func(i, j int) bool {
+		hi, _ := strconv.Atoi(string(blocks[i].Height))
+		hj, _ := strconv.Atoi(string(blocks[j].Height))
+		return hi < hj
+	})
+
+	pageSize, _ := strconv.Atoi(query.Get(`page_size`))
+	offset, _ := strconv.Atoi(query.Get(`next_page_token`))
+	remaining := len(blocks) - offset
+	if pageSize > remaining {
+		pageSize = remaining
+	}
+	var page []api.BlockchainBlockInfoV2
+	if query.Get(`paging_from`) == `end` {
+		page = blocks[remaining-pageSize : remaining]
+	} else {
+		page = blocks[offset : offset+pageSize]
+	}
+	response := api.BlockchainBlocksResponseV2{Data: page}
+	if offset+pageSize < len(blocks) {
+		token := api.NextPageToken(strconv.Itoa(offset + pageSize))
+		response.NextPageToken = &token
+	}
+	return httpmock.NewJsonResponse(http.StatusOK, response)
+}
+
+func (c *testChain) full(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hash, _ := httpmock.GetSubmatch(req, 1)
+	for _, block := range c.blocks {
+		if string(block.BlockHash) == hash {
+			return httpmock.NewJsonResponse(http.StatusOK, api.BlockchainFullBlockResponseV2{BlockchainBlockInfoV2: block})
+		}
+	}
+	return httpmock.NewJsonResponse(http.StatusNotFound, map[string]interface{}{
+		`error`: map[string]string{`type`: `not_found`, `message`: `Block not found.`},
+	})
+}
@@ -0,0 +1,300 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// ErrStopWalk can be returned by callback to stop walking without error
+var ErrStopWalk = errors.New(constants.StopWalk)
+
+// Defaults of Walker
+const (
+	DefaultConcurrency  = 4
+	DefaultPollInterval = 10 * time.Second
+	DefaultReorgWindow  = 6
+)
+
+// Block is full block with parsed height, Stale is set for blocks which are not part of main chain
+type Block struct {
+	api.BlockchainFullBlockResponseV2
+	Height int64
+	Stale  bool
+}
+
+// Walker iterates blocks of asset by height fetching full blocks concurrently
+type Walker struct {
+	client             api.ClientWithResponsesInterface
+	asset              api.BlockchainAsset
+	concurrency        int
+	pollInterval       time.Duration
+	reorgWindow        int64
+	chain              api.BlockchainChainType
+	includeSubAccounts bool
+	reqEditors         []api.RequestEditorFn
+}
+
+// WalkerOption allows to customize Walker
+type WalkerOption func(*Walker)
+
+// WithConcurrency limits number of full blocks fetched at the same time
+func WithConcurrency(n int) WalkerOption {
+	return func(w *Walker) {
+		if n > 0 {
+			w.concurrency = n
+		}
+	}
+}
+
+// WithPollInterval sets how often Follow checks for new blocks
+func WithPollInterval(d time.Duration) WalkerOption {
+	return func(w *Walker) {
+		w.pollInterval = d
+	}
+}
+
+// WithReorgWindow sets how many blocks below tip Follow re-checks for reorganizations
+func WithReorgWindow(n int64) WalkerOption {
+	return func(w *Walker) {
+		w.reorgWindow = n
+	}
+}
+
+// WithStaleBlocks makes Walk yield stale blocks as well, they are yielded next to main chain block of same height
+func WithStaleBlocks() WalkerOption {
+	return func(w *Walker) {
+		w.chain = AllChains
+	}
+}
+
+// WithSubAccounts requests sub-accounts in balance updates of full blocks
+func WithSubAccounts() WalkerOption {
+	return func(w *Walker) {
+		w.includeSubAccounts = true
+	}
+}
+
+// WithWalkerRequestEditors are passed to every call made by walker
+func WithWalkerRequestEditors(fns ...api.RequestEditorFn) WalkerOption {
+	return func(w *Walker) {
+		w.reqEditors = append(w.reqEditors, fns...)
+	}
+}
+
+// NewWalker creates walker over blocks of asset
+func NewWalker(client api.ClientWithResponsesInterface, asset string, opts ...WalkerOption) *Walker {
+	w := Walker{
+		client:       client,
+		asset:        api.BlockchainAsset(asset),
+		concurrency:  DefaultConcurrency,
+		pollInterval: DefaultPollInterval,
+		reorgWindow:  DefaultReorgWindow,
+		chain:        MainChain,
+	}
+	for _, opt := range opts {
+		opt(&w)
+	}
+	return &w
+}
+
+// Tip returns height of latest block of main chain, -1 when chain has no blocks yet
+func (w *Walker) Tip(ctx context.Context) (int64, error) {
+	block, err := tip(ctx, w.client, w.asset, w.reqEditors...)
+	if err != nil || block == nil {
+		return -1, err
+	}
+	return ParseHeight(block.Height)
+}
+
+// Walk calls fn for every block between heights from and to, both inclusive, in height order.
+// Blocks are walked backward when from is greater than to. Returning ErrStopWalk from fn stops walking without error.
+func (w *Walker) Walk(ctx context.Context, from, to int64, fn func(Block) error) error {
+	return ignoreStop(w.walk(ctx, from, to, w.chain, fn))
+}
+
+func (w *Walker) walk(ctx context.Context, from, to int64, chain api.BlockchainChainType, fn func(Block) error) error {
+	backward := from > to
+	if backward {
+		from, to = to, from
+	}
+	return listBlocks(ctx, w.client, w.asset, from, to, chain, backward, func(page []api.BlockchainBlockInfoV2) error {
+		return w.yield(ctx, page, fn)
+	}, w.reqEditors...)
+}
+
+// Follow walks blocks from height on and keeps following the tip until ctx is done.
+// Blocks already yielded which become stale within reorg window are yielded again with Stale set,
+// followed by blocks which replaced them on main chain.
+func (w *Walker) Follow(ctx context.Context, from int64, fn func(Block) error) error {
+	next := from
+	// hashes of yielded main chain blocks within reorg window
+	yielded := map[int64]api.BlockchainBlockHash{}
+	record := func(block Block) error {
+		if !block.Stale {
+			yielded[block.Height] = block.BlockHash
+		}
+		return fn(block)
+	}
+	for {
+		tipHeight, err := w.Tip(ctx)
+		if err != nil {
+			return cancelled(ctx, err)
+		}
+		if err := w.checkReorg(ctx, next, yielded, record); err != nil {
+			return cancelled(ctx, ignoreStop(err))
+		}
+		if tipHeight >= next {
+			if err := w.walk(ctx, next, tipHeight, MainChain, record); err != nil {
+				return cancelled(ctx, ignoreStop(err))
+			}
+			next = tipHeight + 1
+		}
+		for height := range yielded {
+			if height < next-w.reorgWindow {
+				delete(yielded, height)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(w.pollInterval):
+		}
+	}
+}
+
+// checkReorg lists all chains below next height and yields blocks which became stale and their main chain replacements
+func (w *Walker) checkReorg(ctx context.Context, next int64, yielded map[int64]api.BlockchainBlockHash, fn func(Block) error) error {
+	if len(yielded) == 0 || w.reorgWindow <= 0 {
+		return nil
+	}
+	var stale, replaced []api.BlockchainBlockInfoV2
+	err := listBlocks(ctx, w.client, w.asset, next-w.reorgWindow, next-1, AllChains, false, func(page []api.BlockchainBlockInfoV2) error {
+		for _, info := range page {
+			height, err := ParseHeight(info.Height)
+			if err != nil {
+				return err
+			}
+			hash, ok := yielded[height]
+			switch {
+			case !ok:
+			case IsStale(info.Stale) && hash == info.BlockHash:
+				stale = append(stale, info)
+			case !IsStale(info.Stale) && hash != info.BlockHash:
+				replaced = append(replaced, info)
+			}
+		}
+		return nil
+	}, w.reqEditors...)
+	if err != nil {
+		return err
+	}
+	for _, info := range stale {
+		height, _ := ParseHeight(info.Height)
+		delete(yielded, height)
+	}
+	sort.SliceStable(replaced, func(i, j int) bool {
+		hi, _ := ParseHeight(replaced[i].Height)
+		hj, _ := ParseHeight(replaced[j].Height)
+		return hi < hj
+	})
+	return w.yield(ctx, append(stale, replaced...), fn)
+}
+
+// yield fetches full blocks with bounded concurrency and passes them to fn in order of infos
+func (w *Walker) yield(ctx context.Context, infos []api.BlockchainBlockInfoV2, fn func(Block) error) error {
+	if len(infos) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+
+	type result struct {
+		block Block
+		err   error
+	}
+	results := make([]chan result, len(infos))
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
+	slots := make(chan struct{}, w.concurrency)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i, info := range infos {
+			select {
+			case <-ctx.Done():
+				return
+			case slots <- struct{}{}:
+			}
+			wg.Add(1)
+			go func(i int, hash api.BlockchainBlockHash) {
+				defer wg.Done()
+				block, err := w.Block(ctx, hash)
+				results[i] <- result{block: block, err: err}
+			}(i, info.BlockHash)
+		}
+	}()
+
+	for i := range infos {
+		var r result
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case r = <-results[i]:
+		}
+		<-slots
+		if r.err != nil {
+			return r.err
+		}
+		if err := fn(r.block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Block fetches full block by hash
+func (w *Walker) Block(ctx context.Context, hash api.BlockchainBlockHash) (Block, error) {
+	params := api.GetBlockchainV2FullBlockParams{}
+	if w.includeSubAccounts {
+		include := api.BlockchainIncludeSubAccounts(true)
+		params.IncludeSubAccounts = &include
+	}
+	res, err := w.client.GetBlockchainV2FullBlockWithResponse(ctx, w.asset, hash, &params, w.reqEditors...)
+	if err != nil {
+		return Block{}, err
+	}
+	if res.JSON200 == nil {
+		return Block{}, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+	}
+	height, err := ParseHeight(res.JSON200.Height)
+	if err != nil {
+		return Block{}, err
+	}
+	return Block{BlockchainFullBlockResponseV2: *res.JSON200, Height: height, Stale: IsStale(res.JSON200.Stale)}, nil
+}
+
+func ignoreStop(err error) error {
+	if errors.Is(err, ErrStopWalk) {
+		return nil
+	}
+	return err
+}
+
+// cancelled returns error of ctx once it is done, so that request errors caused by cancellation are reported as such
+func cancelled(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
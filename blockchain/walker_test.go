@@ -0,0 +1,138 @@
+package blockchain_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/rulesng/coinmetrics-go-sdk/blockchain"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func walkHeights(t *testing.T, walker *blockchain.Walker, from, to int64) []int64 {
+	var heights []int64
+	err := walker.Walk(context.Background(), from, to, func(block blockchain.Block) error {
+		assert.Equal(t, fmt.Sprintf(`hash-%d`, block.Height), string(block.BlockHash))
+		heights = append(heights, block.Height)
+		return nil
+	})
+	assert.Nil(t, err)
+	return heights
+}
+
+func heightRange(from, to int64) []int64 {
+	var heights []int64
+	for height := from; height != to; {
+		heights = append(heights, height)
+		if from < to {
+			height++
+		} else {
+			height--
+		}
+	}
+	return append(heights, to)
+}
+
+func TestWalkForward(t *testing.T) {
+	newTestChain(300).register()
+	walker := blockchain.NewWalker(_coinmetrics, `btc`, blockchain.WithConcurrency(8))
+	assert.Equal(t, heightRange(10, 250), walkHeights(t, walker, 10, 250))
+}
+
+func TestWalkBackward(t *testing.T) {
+	newTestChain(300).register()
+	walker := blockchain.NewWalker(_coinmetrics, `btc`)
+	assert.Equal(t, heightRange(250, 10), walkHeights(t, walker, 250, 10))
+}
+
+func TestWalkStop(t *testing.T) {
+	newTestChain(300).register()
+	walker := blockchain.NewWalker(_coinmetrics, `btc`, blockchain.WithConcurrency(2))
+	var heights []int64
+	err := walker.Walk(context.Background(), 0, 300, func(block blockchain.Block) error {
+		heights = append(heights, block.Height)
+		if len(heights) == 5 {
+			return blockchain.ErrStopWalk
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, heightRange(0, 4), heights)
+}
+
+func TestWalkFailAuthentication(t *testing.T) {
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf(`%s%s/blockchain-v2/btc/blocks`, constants.TestEndpoint, constants.ApiVersion),
+		httpmock.NewStringResponder(http.StatusUnauthorized, `{"error":{"type":"unauthorized","message":"Requested resource requires authorization."}}`))
+	walker := blockchain.NewWalker(_coinmetrics, `btc`)
+	err := walker.Walk(context.Background(), 0, 10, func(block blockchain.Block) error { return nil })
+	apiError, ok := err.(coinmetrics.ApiError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusUnauthorized, apiError.StatusCode)
+}
+
+func TestWalkStaleBlocks(t *testing.T) {
+	chain := newTestChain(10)
+	chain.markStale(`hash-9`)
+	chain.add(9, `b`)
+	chain.register()
+
+	var hashes []string
+	walker := blockchain.NewWalker(_coinmetrics, `btc`, blockchain.WithStaleBlocks())
+	err := walker.Walk(context.Background(), 8, 10, func(block blockchain.Block) error {
+		hashes = append(hashes, fmt.Sprintf(`%s stale=%t`, block.BlockHash, block.Stale))
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`hash-8 stale=false`, `hash-9 stale=true`, `hash-9b stale=false`, `hash-10 stale=false`}, hashes)
+}
+
+func TestFollowDetectsStaleBlocks(t *testing.T) {
+	chain := newTestChain(9)
+	chain.register()
+
+	var hashes []string
+	walker := blockchain.NewWalker(_coinmetrics, `btc`, blockchain.WithPollInterval(time.Millisecond))
+	err := walker.Follow(context.Background(), 8, func(block blockchain.Block) error {
+		hashes = append(hashes, fmt.Sprintf(`%s stale=%t`, block.BlockHash, block.Stale))
+		switch block.BlockHash {
+		case `hash-9`:
+			if !block.Stale {
+				// Block 9 gets orphaned and chain grows on top of its sibling
+				chain.markStale(`hash-9`)
+				chain.add(9, `b`)
+				chain.add(10, ``)
+			}
+		case `hash-10`:
+			return blockchain.ErrStopWalk
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		`hash-8 stale=false`,
+		`hash-9 stale=false`,
+		`hash-9 stale=true`,
+		`hash-9b stale=false`,
+		`hash-10 stale=false`,
+	}, hashes)
+}
+
+func TestFollowStopsWithContext(t *testing.T) {
+	newTestChain(3).register()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var heights []int64
+	walker := blockchain.NewWalker(_coinmetrics, `btc`, blockchain.WithPollInterval(time.Millisecond))
+	err := walker.Follow(ctx, 0, func(block blockchain.Block) error {
+		heights = append(heights, block.Height)
+		return nil
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, heightRange(0, 3), heights)
+}
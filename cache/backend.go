@@ -0,0 +1,215 @@
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry is cached response
+type Entry struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body"`
+	// Expires is zero for responses which never change
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// expired tells whether entry is stale at now
+func (e Entry) expired(now time.Time) bool {
+	return !e.Expires.IsZero() && !now.Before(e.Expires)
+}
+
+// Backend stores entries by key
+type Backend interface {
+	Get(key string) (Entry, bool, error)
+	Put(key string, entry Entry) error
+	Delete(key string) error
+}
+
+// Memory is in-memory Backend evicting least recently used entries
+type Memory struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+type memoryItem struct {
+	key   string
+	entry Entry
+}
+
+// NewMemory creates memory backend holding at most capacity entries
+func NewMemory(capacity int) *Memory {
+	return &Memory{capacity: capacity, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// Get returns entry and marks it as recently used
+func (m *Memory) Get(key string) (Entry, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	element, ok := m.entries[key]
+	if !ok {
+		return Entry{}, false, nil
+	}
+	m.order.MoveToFront(element)
+	return element.Value.(*memoryItem).entry, true, nil
+}
+
+// Put stores entry, least recently used entry is evicted when memory is full
+func (m *Memory) Put(key string, entry Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if element, ok := m.entries[key]; ok {
+		element.Value.(*memoryItem).entry = entry
+		m.order.MoveToFront(element)
+		return nil
+	}
+	m.entries[key] = m.order.PushFront(&memoryItem{key: key, entry: entry})
+	for m.capacity > 0 && m.order.Len() > m.capacity {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryItem).key)
+	}
+	return nil
+}
+
+// Delete removes entry
+func (m *Memory) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if element, ok := m.entries[key]; ok {
+		m.order.Remove(element)
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+// Len returns number of entries
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// Disk is Backend storing entry per file in directory, it survives restarts and can be shared by processes
+type Disk struct {
+	dir string
+}
+
+// NewDisk creates disk backend in dir, the directory is created when missing
+func NewDisk(dir string) (*Disk, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Disk{dir: dir}, nil
+}
+
+// path returns file of key, keys are hashed since they are urls
+func (d *Disk) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(d.dir, name[:2], name+`.json`)
+}
+
+// Get reads entry of key
+func (d *Disk) Get(key string) (Entry, bool, error) {
+	data, err := ioutil.ReadFile(d.path(key))
+	if os.IsNotExist(err) {
+		return Entry{}, false, nil
+	}
+	if err != nil {
+		return Entry{}, false, err
+	}
+	var entry Entry
+	if err = json.Unmarshal(data, &entry); err != nil {
+		return Entry{}, false, err
+	}
+	return entry, true, nil
+}
+
+// Put writes entry of key to temporary file renamed over the previous one, so readers never see partial entry
+func (d *Disk) Put(key string, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	path := d.path(key)
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), `.tmp-*`)
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Delete removes entry of key
+func (d *Disk) Delete(key string) error {
+	err := os.Remove(d.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Tiered combines backends from fastest to slowest, like memory in front of disk. Entries found in slower backend
+// are copied to faster ones.
+type Tiered []Backend
+
+// Get returns entry from the first backend holding key
+func (t Tiered) Get(key string) (Entry, bool, error) {
+	for i, backend := range t {
+		entry, ok, err := backend.Get(key)
+		if err != nil {
+			return Entry{}, false, err
+		}
+		if ok {
+			for _, faster := range t[:i] {
+				if err = faster.Put(key, entry); err != nil {
+					return entry, true, err
+				}
+			}
+			return entry, true, nil
+		}
+	}
+	return Entry{}, false, nil
+}
+
+// Put stores entry in every backend
+func (t Tiered) Put(key string, entry Entry) error {
+	for _, backend := range t {
+		if err := backend.Put(key, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes entry from every backend
+func (t Tiered) Delete(key string) error {
+	for _, backend := range t {
+		if err := backend.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+// Package cache is caching api.HttpRequestDoer for the client. Responses which can not change, like time series with
+// `end_time` in the past, are stored forever and catalog responses for TTL. Entries are keyed on normalized url without
+// api key, so they can be shared by keys and stored on disk. Responses of `/catalog/*` depend on entitlements of key,
+// their entries are keyed on hash of api key too.
+//
+//	doer := cache.New(http.DefaultClient, cache.Tiered{cache.NewMemory(1000), disk})
+//	client, err := coinmetrics.InitClient(constants.Endpoint, key, api.WithHTTPClient(doer))
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// Defaults of Doer
+const (
+	DefaultCatalogTTL = time.Hour
+	// DefaultSettle is time after which data of closed interval is not revised anymore
+	DefaultSettle = time.Hour
+)
+
+// Header set on responses telling whether response was served from cache
+const (
+	Header = `X-Cache`
+	Hit    = `HIT`
+	Miss   = `MISS`
+)
+
+// Rule returns how long response of request can be cached, ok is false for requests which are not cached.
+// Zero ttl caches response forever.
+type Rule func(req *http.Request, now time.Time) (ttl time.Duration, ok bool)
+
+// Stats are counters of Doer
+type Stats struct {
+	Hits int64
+	// Misses are cacheable requests sent to api
+	Misses int64
+	// Bypasses are requests not cacheable by rules
+	Bypasses int64
+	Stores   int64
+	// Errors are failures of backend, requests are sent to api then
+	Errors int64
+}
+
+// Doer is api.HttpRequestDoer caching responses
+type Doer struct {
+	client  api.HttpRequestDoer
+	backend Backend
+	rule    Rule
+	now     func() time.Time
+	scrub   []string
+
+	hits, misses, bypasses, stores, errors int64
+}
+
+// Option allows to customize Doer
+type Option func(*Doer)
+
+// WithRule replaces cacheability rules, see DefaultRule
+func WithRule(rule Rule) Option {
+	return func(d *Doer) {
+		d.rule = rule
+	}
+}
+
+// WithClock sets source of current time
+func WithClock(now func() time.Time) Option {
+	return func(d *Doer) {
+		d.now = now
+	}
+}
+
+// New creates caching doer sending misses with client, http.DefaultClient is used when client is nil
+func New(client api.HttpRequestDoer, backend Backend, opts ...Option) *Doer {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	d := Doer{client: client, backend: backend, rule: DefaultRule(DefaultCatalogTTL, DefaultSettle), now: time.Now, scrub: []string{constants.ParamsApiKey}}
+	for _, opt := range opts {
+		opt(&d)
+	}
+	return &d
+}
+
+// DefaultRule caches catalog for catalogTTL and other GET requests forever when `end_time` is more
+// than settle in the past
+func DefaultRule(catalogTTL, settle time.Duration) Rule {
+	return func(req *http.Request, now time.Time) (time.Duration, bool) {
+		if req.Method != http.MethodGet {
+			return 0, false
+		}
+		path := apiPath(req.URL)
+		for _, prefix := range []string{`/catalog/`, `/catalog-all/`} {
+			if strings.HasPrefix(path, prefix) {
+				return catalogTTL, true
+			}
+		}
+		end, ok := parseTime(req.URL.Query().Get(`end_time`))
+		if !ok || !end.Add(settle).Before(now) {
+			return 0, false
+		}
+		return 0, true
+	}
+}
+
+// Stats returns counters
+func (d *Doer) Stats() Stats {
+	return Stats{
+		Hits:     atomic.LoadInt64(&d.hits),
+		Misses:   atomic.LoadInt64(&d.misses),
+		Bypasses: atomic.LoadInt64(&d.bypasses),
+		Stores:   atomic.LoadInt64(&d.stores),
+		Errors:   atomic.LoadInt64(&d.errors),
+	}
+}
+
+// Do serves request from cache or sends it and stores successful response
+func (d *Doer) Do(req *http.Request) (*http.Response, error) {
+	now := d.now()
+	ttl, ok := d.rule(req, now)
+	if !ok {
+		atomic.AddInt64(&d.bypasses, 1)
+		return d.client.Do(req)
+	}
+	key := d.Key(req)
+	entry, found, err := d.backend.Get(key)
+	if err != nil {
+		atomic.AddInt64(&d.errors, 1)
+	}
+	if found && !entry.expired(now) {
+		atomic.AddInt64(&d.hits, 1)
+		return entry.response(req, Hit), nil
+	}
+	atomic.AddInt64(&d.misses, 1)
+
+	res, err := d.client.Do(req)
+	if err != nil || res.StatusCode != http.StatusOK {
+		return res, err
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	entry = Entry{Status: res.StatusCode, Header: res.Header.Clone(), Body: d.scrubBody(req, body)}
+	entry.Header.Del(`Set-Cookie`)
+	// body may change by scrubbing
+	entry.Header.Del(`Content-Length`)
+	if ttl > 0 {
+		entry.Expires = now.Add(ttl)
+	}
+	if err = d.backend.Put(key, entry); err != nil {
+		atomic.AddInt64(&d.errors, 1)
+	} else {
+		atomic.AddInt64(&d.stores, 1)
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	res.Header.Set(Header, Miss)
+	return res, nil
+}
+
+// Key returns cache key of request, api key is removed and query params are sorted. Keys of `/catalog/*` requests end
+// with hash of api key, entries are shared only by callers with the same entitlements.
+func (d *Doer) Key(req *http.Request) string {
+	query := req.URL.Query()
+	for _, param := range d.scrub {
+		query.Del(param)
+	}
+	u := url.URL{Scheme: req.URL.Scheme, Host: req.URL.Host, Path: req.URL.Path, RawQuery: query.Encode()}
+	key := req.Method + ` ` + u.String()
+	if strings.HasPrefix(apiPath(req.URL), `/catalog/`) {
+		hash := sha256.Sum256([]byte(req.URL.Query().Get(constants.ParamsApiKey)))
+		key += ` ` + hex.EncodeToString(hash[:8])
+	}
+	return key
+}
+
+// apiPath returns path of url relative to api version
+func apiPath(u *url.URL) string {
+	path := u.Path
+	if i := strings.Index(path, `/`+constants.ApiVersion+`/`); i >= 0 {
+		path = path[i+len(constants.ApiVersion)+1:]
+	}
+	return path
+}
+
+// scrubBody removes api key of request from cached body, like from `next_page_url`. Cached next page url is not
+// usable as is, the client sends api key of its own with next page token.
+func (d *Doer) scrubBody(req *http.Request, body []byte) []byte {
+	query := req.URL.Query()
+	for _, param := range d.scrub {
+		for _, value := range query[param] {
+			if value != `` {
+				body = bytes.ReplaceAll(body, []byte(param+`=`+url.QueryEscape(value)), []byte(param+`=`))
+			}
+		}
+	}
+	return body
+}
+
+func (e Entry) response(req *http.Request, status string) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set(Header, status)
+	return &http.Response{
+		Status:        fmt.Sprintf(`%d %s`, e.Status, http.StatusText(e.Status)),
+		StatusCode:    e.Status,
+		Proto:         `HTTP/1.1`,
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// parseTime parses formats of time params accepted by api, times without zone are UTC
+func parseTime(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, `2006-01-02T15:04:05.999999999`, `2006-01-02`, `20060102`} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
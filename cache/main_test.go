@@ -0,0 +1,149 @@
+package cache_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/cache"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/rulesng/coinmetrics-go-sdk/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newServer(t *testing.T) (*mock.Server, string) {
+	server, err := mock.NewServer()
+	assert.Nil(t, err)
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	return server, ts.URL + `/`
+}
+
+func newClient(t *testing.T, endpoint string, backend cache.Backend) (*cache.Doer, coinmetrics.CoinMetrics) {
+	doer := cache.New(nil, backend, cache.WithClock(func() time.Time { return now }))
+	client, err := coinmetrics.InitClient(endpoint, constants.TestKey, api.WithHTTPClient(doer))
+	assert.Nil(t, err)
+	return doer, client
+}
+
+func TestClosedInterval(t *testing.T) {
+	server, endpoint := newServer(t)
+	doer, client := newClient(t, endpoint, cache.NewMemory(10))
+	ctx := context.Background()
+	end := api.EndTime(`2022-04-01`)
+	params := api.GetTimeseriesAssetMetricsParams{Assets: `btc`, Metrics: api.AssetMetrics{`PriceUSD`}, EndTime: &end}
+	first, err := client.GetTimeseriesAssetMetricsWithResponse(ctx, &params)
+	assert.Nil(t, err)
+	assert.Equal(t, cache.Miss, first.HTTPResponse.Header.Get(cache.Header))
+	second, err := client.GetTimeseriesAssetMetricsWithResponse(ctx, &params)
+	assert.Nil(t, err)
+	assert.Equal(t, cache.Hit, second.HTTPResponse.Header.Get(cache.Header))
+	assert.Equal(t, string(first.Body), string(second.Body))
+	if assert.NotNil(t, second.JSON200) {
+		assert.NotEmpty(t, second.JSON200.Data)
+	}
+	assert.Equal(t, 1, server.Calls(`/timeseries/asset-metrics`))
+
+	// open interval is always fetched
+	params.EndTime = nil
+	for i := 0; i < 2; i++ {
+		_, err = client.GetTimeseriesAssetMetricsWithResponse(ctx, &params)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 3, server.Calls(`/timeseries/asset-metrics`))
+	assert.Equal(t, cache.Stats{Hits: 1, Misses: 1, Bypasses: 2, Stores: 1}, doer.Stats())
+
+	// errors are not cached
+	server.InjectError(`/timeseries/asset-metrics`, http.StatusInternalServerError, 1)
+	params.EndTime = &end
+	params.Assets = `eth`
+	for _, status := range []int{http.StatusInternalServerError, http.StatusOK, http.StatusOK} {
+		res, err := client.GetTimeseriesAssetMetricsWithResponse(ctx, &params)
+		assert.Nil(t, err)
+		assert.Equal(t, status, res.StatusCode())
+	}
+	assert.Equal(t, 5, server.Calls(`/timeseries/asset-metrics`))
+}
+
+func TestCatalogTTL(t *testing.T) {
+	disk, err := cache.NewDisk(t.TempDir())
+	assert.Nil(t, err)
+	memory := cache.NewMemory(10)
+	server, endpoint := newServer(t)
+	_, client := newClient(t, endpoint, cache.Tiered{memory, disk})
+	ctx := context.Background()
+	_, err = client.GetCatalogAssetsWithResponse(ctx, &api.GetCatalogAssetsParams{})
+	assert.Nil(t, err)
+	_, err = client.GetCatalogAssetsWithResponse(ctx, &api.GetCatalogAssetsParams{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, server.Calls(`/catalog/assets`))
+
+	// disk entry survives restart and fills memory again
+	restarted := cache.NewMemory(10)
+	doer, client := newClient(t, endpoint, cache.Tiered{restarted, disk})
+	_, err = client.GetCatalogAssetsWithResponse(ctx, &api.GetCatalogAssetsParams{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, server.Calls(`/catalog/assets`))
+	assert.Equal(t, 1, restarted.Len())
+	assert.Equal(t, cache.Stats{Hits: 1}, doer.Stats())
+
+	now = now.Add(cache.DefaultCatalogTTL)
+	defer func() { now = now.Add(-cache.DefaultCatalogTTL) }()
+	_, err = client.GetCatalogAssetsWithResponse(ctx, &api.GetCatalogAssetsParams{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, server.Calls(`/catalog/assets`))
+}
+
+func TestKey(t *testing.T) {
+	doer := cache.New(nil, cache.NewMemory(1))
+	a, _ := http.NewRequest(http.MethodGet, `https://api.coinmetrics.io/v4/timeseries/asset-metrics?metrics=PriceUSD&assets=btc&api_key=one`, nil)
+	b, _ := http.NewRequest(http.MethodGet, `https://api.coinmetrics.io/v4/timeseries/asset-metrics?api_key=two&assets=btc&metrics=PriceUSD`, nil)
+	assert.Equal(t, doer.Key(a), doer.Key(b))
+	assert.Equal(t, `GET https://api.coinmetrics.io/v4/timeseries/asset-metrics?assets=btc&metrics=PriceUSD`, doer.Key(a))
+
+	// catalog of entitlements is shared only by the same key, full catalog by all keys
+	one, _ := http.NewRequest(http.MethodGet, `https://api.coinmetrics.io/v4/catalog/assets?api_key=one`, nil)
+	two, _ := http.NewRequest(http.MethodGet, `https://api.coinmetrics.io/v4/catalog/assets?api_key=two`, nil)
+	again, _ := http.NewRequest(http.MethodGet, `https://api.coinmetrics.io/v4/catalog/assets?api_key=one`, nil)
+	assert.NotEqual(t, doer.Key(one), doer.Key(two))
+	assert.Equal(t, doer.Key(one), doer.Key(again))
+	assert.NotContains(t, doer.Key(one), `one`)
+	allOne, _ := http.NewRequest(http.MethodGet, `https://api.coinmetrics.io/v4/catalog-all/assets?api_key=one`, nil)
+	allTwo, _ := http.NewRequest(http.MethodGet, `https://api.coinmetrics.io/v4/catalog-all/assets?api_key=two`, nil)
+	assert.Equal(t, doer.Key(allOne), doer.Key(allTwo))
+
+	rule := cache.DefaultRule(time.Minute, time.Hour)
+	for query, cached := range map[string]bool{
+		`end_time=2022-05-01T10:00:00Z`:           true,
+		`end_time=2022-05-01T11:30:00Z`:           false,
+		`end_time=2022-05-01`:                     true,
+		`end_time=2022-05-02`:                     false,
+		`start_time=2022-01-01`:                   false,
+		`end_time=2022-04-30T00:00:00.000000000Z`: true,
+	} {
+		req, _ := http.NewRequest(http.MethodGet, `https://api.coinmetrics.io/v4/timeseries/market-candles?`+query, nil)
+		_, ok := rule(req, now)
+		assert.Equal(t, cached, ok, query)
+	}
+}
+
+func TestMemoryEviction(t *testing.T) {
+	memory := cache.NewMemory(2)
+	for _, key := range []string{`a`, `b`} {
+		assert.Nil(t, memory.Put(key, cache.Entry{Status: http.StatusOK}))
+	}
+	_, ok, _ := memory.Get(`a`)
+	assert.True(t, ok)
+	assert.Nil(t, memory.Put(`c`, cache.Entry{Status: http.StatusOK}))
+	_, ok, _ = memory.Get(`b`)
+	assert.False(t, ok)
+	_, ok, _ = memory.Get(`a`)
+	assert.True(t, ok)
+	assert.Equal(t, 2, memory.Len())
+}
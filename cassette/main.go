@@ -0,0 +1,251 @@
+// Package cassette records http interactions of the client to files and replays them, so tests run without network.
+//
+//	recorder, err := cassette.New(`testdata/asset-metrics.json`, cassette.Auto)
+//	defer recorder.Save()
+//	client, err := coinmetrics.InitClient(constants.Endpoint, key, api.WithHTTPClient(recorder))
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// Mode of Recorder
+type Mode string
+
+// Modes of Recorder
+const (
+	// Record sends requests and stores interactions, existing cassette is overwritten on Save
+	Record Mode = `record`
+	// Replay serves stored interactions and never sends requests
+	Replay Mode = `replay`
+	// Auto replays when cassette file exists and records otherwise
+	Auto Mode = `auto`
+)
+
+// Scrubbed replaces values of secret params in recorded interactions
+const Scrubbed = `REDACTED`
+
+// Request is recorded request, query is normalized and scrubbed
+type Request struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query"`
+}
+
+// Response is recorded response
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// Interaction is request and response pair
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is content of cassette file
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is api.HttpRequestDoer recording or replaying interactions of cassette file
+type Recorder struct {
+	path   string
+	mode   Mode
+	client api.HttpRequestDoer
+	scrub  []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	// played counts replayed interactions of request, repeated requests are replayed in recorded order
+	played map[Request]int
+}
+
+// Option allows to customize Recorder
+type Option func(*Recorder)
+
+// WithClient sets client sending recorded requests, http.DefaultClient is used by default
+func WithClient(client api.HttpRequestDoer) Option {
+	return func(r *Recorder) {
+		r.client = client
+	}
+}
+
+// WithScrub scrubs params other than api key from recorded interactions
+func WithScrub(params ...string) Option {
+	return func(r *Recorder) {
+		r.scrub = append(r.scrub, params...)
+	}
+}
+
+// New creates recorder of cassette file at path, in Replay mode the file must exist
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := Recorder{path: path, mode: mode, client: http.DefaultClient, scrub: []string{constants.ParamsApiKey}, played: map[Request]int{}}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	if r.mode == Auto {
+		r.mode = Record
+		if _, err := os.Stat(path); err == nil {
+			r.mode = Replay
+		}
+	}
+	switch r.mode {
+	case Record:
+		return &r, nil
+	case Replay:
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf(`%s: %v`, path, err)
+		}
+		return &r, nil
+	}
+	return nil, fmt.Errorf(`%s: %s`, constants.UnsupportedCassetteMode, mode)
+}
+
+// Mode returns mode of recorder, Auto is resolved to Record or Replay
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Interactions returns recorded or loaded interactions
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+// Do records or replays request
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	if r.mode == Replay {
+		return r.replay(req)
+	}
+	return r.record(req)
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	key := r.request(req)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matches []Interaction
+	for _, interaction := range r.cassette.Interactions {
+		if interaction.Request == key {
+			matches = append(matches, interaction)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf(`%s: %s %s?%s`, constants.CassetteMiss, key.Method, key.Path, key.Query)
+	}
+	// the last interaction is replayed again once recorded ones are used up
+	i := r.played[key]
+	if i >= len(matches) {
+		i = len(matches) - 1
+	}
+	r.played[key]++
+	return matches[i].Response.http(req), nil
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	header := res.Header.Clone()
+	header.Del(`Set-Cookie`)
+	// body may change by scrubbing
+	header.Del(`Content-Length`)
+	response := Response{Status: res.StatusCode, Header: header, Body: r.scrubValues(req, string(body))}
+	for name, values := range response.Header {
+		for i := range values {
+			values[i] = r.scrubValues(req, values[i])
+		}
+		response.Header[name] = values
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{Request: r.request(req), Response: response})
+	r.mu.Unlock()
+
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return res, nil
+}
+
+// Save writes recorded interactions to cassette file, it does nothing in Replay mode
+func (r *Recorder) Save() error {
+	if r.mode == Replay {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, ``, `  `)
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// request returns key of request, scrubbed params are removed from query and remaining ones are sorted
+func (r *Recorder) request(req *http.Request) Request {
+	query := req.URL.Query()
+	for _, param := range r.scrub {
+		query.Del(param)
+	}
+	return Request{Method: req.Method, Path: req.URL.Path, Query: query.Encode()}
+}
+
+// scrubValues replaces values of scrubbed params of request in s, like api key of `next_page_url`
+func (r *Recorder) scrubValues(req *http.Request, s string) string {
+	query := req.URL.Query()
+	for _, param := range r.scrub {
+		for _, value := range query[param] {
+			if value == `` {
+				continue
+			}
+			s = strings.ReplaceAll(s, url.QueryEscape(value), Scrubbed)
+			s = strings.ReplaceAll(s, value, Scrubbed)
+		}
+	}
+	return s
+}
+
+func (res Response) http(req *http.Request) *http.Response {
+	header := res.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf(`%d %s`, res.Status, http.StatusText(res.Status)),
+		StatusCode:    res.Status,
+		Proto:         `HTTP/1.1`,
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(res.Body)),
+		ContentLength: int64(len(res.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,100 @@
+package cassette_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/cassette"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/rulesng/coinmetrics-go-sdk/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func fetchLevels(t *testing.T, client coinmetrics.CoinMetrics) []string {
+	pageSize := api.PageSize(2)
+	params := api.GetTimeseriesIndexLevelsParams{Indexes: `CMBI10`, PageSize: &pageSize}
+	var levels []string
+	for {
+		res, err := client.GetTimeseriesIndexLevelsWithResponse(context.Background(), &params)
+		if !assert.Nil(t, err) || !assert.NotNil(t, res.JSON200) {
+			return levels
+		}
+		for _, level := range res.JSON200.Data {
+			levels = append(levels, string(level.Level))
+		}
+		if res.JSON200.NextPageToken == nil {
+			return levels
+		}
+		params.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), `testdata`, `index-levels.json`)
+	server, err := mock.NewServer(mock.WithAPIKey(constants.TestKey))
+	assert.Nil(t, err)
+	ts := httptest.NewServer(server)
+
+	recorder, err := cassette.New(path, cassette.Auto)
+	assert.Nil(t, err)
+	assert.Equal(t, cassette.Record, recorder.Mode())
+	client, err := coinmetrics.InitClient(ts.URL+`/`, constants.TestKey, api.WithHTTPClient(recorder))
+	assert.Nil(t, err)
+	recorded := fetchLevels(t, client)
+	assert.Len(t, recorded, mock.DefaultSeriesLength)
+	assert.Nil(t, recorder.Save())
+	ts.Close()
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), constants.ParamsApiKey+`=`+constants.TestKey)
+	assert.Contains(t, string(data), constants.ParamsApiKey+`=`+cassette.Scrubbed)
+
+	// replay does not need server nor the same api key
+	recorder, err = cassette.New(path, cassette.Auto)
+	assert.Nil(t, err)
+	assert.Equal(t, cassette.Replay, recorder.Mode())
+	assert.Len(t, recorder.Interactions(), 3)
+	client, err = coinmetrics.InitClient(`http://127.0.0.1:1/`, `other`, api.WithHTTPClient(recorder))
+	assert.Nil(t, err)
+	assert.Equal(t, recorded, fetchLevels(t, client))
+
+	_, err = client.GetTimeseriesIndexLevelsWithResponse(context.Background(), &api.GetTimeseriesIndexLevelsParams{Indexes: `CMBIBTC`})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), constants.CassetteMiss)
+	}
+}
+
+func TestReplayNormalizedQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), `cassette.json`)
+	assert.Nil(t, ioutil.WriteFile(path, []byte(`{"interactions": [
+		{"request": {"method": "GET", "path": "/v4/catalog/assets", "query": "assets=btc&pretty=true"},
+		 "response": {"status": 200, "header": {"Content-Type": ["application/json"]}, "body": "{\"data\": []}"}},
+		{"request": {"method": "GET", "path": "/v4/catalog/assets", "query": "assets=btc&pretty=true"},
+		 "response": {"status": 429, "body": "{}"}}
+	]}`), 0o644))
+	recorder, err := cassette.New(path, cassette.Replay)
+	assert.Nil(t, err)
+	for _, status := range []int{http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		req, _ := http.NewRequest(http.MethodGet, `https://api.coinmetrics.io/v4/catalog/assets?pretty=true&api_key=x&assets=btc`, nil)
+		res, err := recorder.Do(req)
+		if assert.Nil(t, err) {
+			assert.Equal(t, status, res.StatusCode)
+		}
+	}
+	req, _ := http.NewRequest(http.MethodGet, `https://api.coinmetrics.io/v4/catalog/assets?assets=eth`, nil)
+	_, err = recorder.Do(req)
+	assert.NotNil(t, err)
+
+	_, err = cassette.New(filepath.Join(t.TempDir(), `missing.json`), cassette.Replay)
+	assert.NotNil(t, err)
+	_, err = cassette.New(path, `rewind`)
+	assert.True(t, strings.HasPrefix(err.Error(), constants.UnsupportedCassetteMode))
+}
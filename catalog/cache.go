@@ -0,0 +1,282 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// Cache keeps catalog snapshot in memory, persists it to local snapshot file and refreshes it in background once ttl expires
+type Cache struct {
+	client     api.ClientWithResponsesInterface
+	ttl        time.Duration
+	path       string
+	reqEditors []api.RequestEditorFn
+	onError    func(error)
+
+	mu          sync.RWMutex
+	snapshot    *Snapshot
+	index       *index
+	validator   *Validator
+	subscribers []Subscriber
+
+	// setMu serializes replacing snapshot together with notifying subscribers about its diff
+	setMu sync.Mutex
+
+	startMu sync.Mutex
+	started bool
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// CacheOption allows to customize Cache
+type CacheOption func(*Cache)
+
+// WithSnapshotFile persists every loaded snapshot to path and reads it on Start, so restarts do not need to call api
+func WithSnapshotFile(path string) CacheOption {
+	return func(c *Cache) {
+		c.path = path
+	}
+}
+
+// WithErrorHandler receives errors of background refresh, by default they are ignored and previous snapshot is served
+func WithErrorHandler(fn func(error)) CacheOption {
+	return func(c *Cache) {
+		c.onError = fn
+	}
+}
+
+// WithRequestEditors are passed to every catalog call made by cache
+func WithRequestEditors(fns ...api.RequestEditorFn) CacheOption {
+	return func(c *Cache) {
+		c.reqEditors = append(c.reqEditors, fns...)
+	}
+}
+
+// WithSubscriber is notified with Diff of previous and new snapshot after every refresh which changed catalog
+func WithSubscriber(fn Subscriber) CacheOption {
+	return func(c *Cache) {
+		c.subscribers = append(c.subscribers, fn)
+	}
+}
+
+// NewCache creates cache which will refresh snapshot every ttl, call Start to load it. Non-positive ttl never refreshes,
+// snapshot is loaded once by Start and then only by Refresh.
+func NewCache(client api.ClientWithResponsesInterface, ttl time.Duration, opts ...CacheOption) *Cache {
+	c := Cache{
+		client:  client,
+		ttl:     ttl,
+		onError: func(error) {},
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &c
+}
+
+// Start loads snapshot file when configured and fetches catalog if there is no snapshot yet or it is older than ttl.
+// Background refresh runs until ctx is done or Close is called. Cache can be started only once.
+func (c *Cache) Start(ctx context.Context) error {
+	c.startMu.Lock()
+	defer c.startMu.Unlock()
+	if c.started {
+		return errors.New(constants.AlreadyStarted)
+	}
+	c.started = true
+	if c.path != `` {
+		if snapshot, err := ReadSnapshot(c.path); err == nil {
+			_ = c.set(snapshot, false)
+		}
+	}
+	if snapshot := c.Snapshot(); snapshot == nil || (c.ttl > 0 && snapshot.Age() >= c.ttl) {
+		if err := c.Refresh(ctx); err != nil {
+			if snapshot == nil {
+				return err
+			}
+			// Stale snapshot is still served until next refresh succeeds
+			c.onError(err)
+		}
+	}
+
+	if c.ttl <= 0 {
+		return nil
+	}
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+	go c.run(ctx)
+	return nil
+}
+
+// Close stops background refresh
+func (c *Cache) Close() {
+	c.startMu.Lock()
+	defer c.startMu.Unlock()
+	if c.cancel == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+}
+
+func (c *Cache) run(ctx context.Context) {
+	defer close(c.done)
+	timer := time.NewTimer(c.ttl - c.Snapshot().Age())
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			if err := c.Refresh(ctx); err != nil && ctx.Err() == nil {
+				c.onError(err)
+			}
+			timer.Reset(c.ttl)
+		}
+	}
+}
+
+// Refresh fetches catalog, writes it to snapshot file and replaces snapshot served by cache
+func (c *Cache) Refresh(ctx context.Context) error {
+	snapshot, err := Load(ctx, c.client, c.reqEditors...)
+	if err != nil {
+		return err
+	}
+	return c.set(snapshot, c.path != ``)
+}
+
+// Subscribe adds subscriber which is notified about catalog changes found by following refreshes
+func (c *Cache) Subscribe(fn Subscriber) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subscribers = append(c.subscribers, fn)
+}
+
+// set optionally saves snapshot file, replaces served snapshot and notifies subscribers. Snapshots older than served
+// one are dropped, so concurrent refreshes deliver diffs in order and never go back.
+func (c *Cache) set(snapshot *Snapshot, save bool) error {
+	c.setMu.Lock()
+	defer c.setMu.Unlock()
+	if previous := c.Snapshot(); previous != nil && snapshot.Time.Before(previous.Time) {
+		return nil
+	}
+	if save {
+		if err := snapshot.Save(c.path); err != nil {
+			return err
+		}
+	}
+	idx := newIndex(snapshot)
+	c.mu.Lock()
+	previous := c.snapshot
+	c.snapshot = snapshot
+	c.index = idx
+	c.validator = &Validator{idx}
+	subscribers := c.subscribers
+	c.mu.Unlock()
+
+	if previous == nil || len(subscribers) == 0 {
+		return nil
+	}
+	events := Diff(previous, snapshot)
+	if len(events) == 0 {
+		return nil
+	}
+	for _, subscriber := range subscribers {
+		subscriber(events)
+	}
+	return nil
+}
+
+func (c *Cache) current() *index {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.index == nil {
+		return newIndex(&Snapshot{})
+	}
+	return c.index
+}
+
+// Snapshot returns snapshot currently served by cache, it must not be modified
+func (c *Cache) Snapshot() *Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.snapshot
+}
+
+// Validator returns validator built on current snapshot
+func (c *Cache) Validator() *Validator {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.validator == nil {
+		return NewValidator(&Snapshot{})
+	}
+	return c.validator
+}
+
+// Asset returns catalog information of asset
+func (c *Cache) Asset(asset api.AssetId) (api.AssetInfo, bool) {
+	info, ok := c.current().assets[string(asset)]
+	return info, ok
+}
+
+// AssetMetrics returns metrics available for asset together with their frequencies
+func (c *Cache) AssetMetrics(asset api.AssetId) []api.AssetMetricInfo {
+	info, ok := c.current().assets[string(asset)]
+	if !ok || info.Metrics == nil {
+		return nil
+	}
+	return *info.Metrics
+}
+
+// AssetMetricFrequencies returns frequencies at which metric is available for asset
+func (c *Cache) AssetMetricFrequencies(asset api.AssetId, metric api.MetricId) []string {
+	return c.current().assetMetrics[string(asset)][string(metric)]
+}
+
+// Metric returns catalog information of metric
+func (c *Cache) Metric(metric api.MetricId) (api.MetricInfo, bool) {
+	info, ok := c.current().metrics[string(metric)]
+	return info, ok
+}
+
+// Market returns catalog information of market
+func (c *Cache) Market(id api.MarketId) (api.MarketInfo, bool) {
+	info, ok := c.current().markets[string(id)]
+	return info, ok
+}
+
+// Exchange returns catalog information of exchange
+func (c *Cache) Exchange(exchange api.ExchangeId) (api.ExchangeInfo, bool) {
+	info, ok := c.current().exchanges[string(exchange)]
+	return info, ok
+}
+
+// ExchangeMarkets returns information of all markets listed on exchange
+func (c *Cache) ExchangeMarkets(exchange api.ExchangeId) []api.MarketInfo {
+	return c.current().exchangeMarkets[string(exchange)]
+}
+
+// QueryMarkets starts query over markets of current snapshot
+func (c *Cache) QueryMarkets() *MarketQuery {
+	return QueryMarkets(c.currentSnapshot())
+}
+
+// QueryMetrics starts query over metrics of current snapshot
+func (c *Cache) QueryMetrics() *MetricQuery {
+	return QueryMetrics(c.currentSnapshot())
+}
+
+// QueryAssets starts query over assets of current snapshot
+func (c *Cache) QueryAssets() *AssetQuery {
+	return QueryAssets(c.currentSnapshot())
+}
+
+func (c *Cache) currentSnapshot() *Snapshot {
+	if snapshot := c.Snapshot(); snapshot != nil {
+		return snapshot
+	}
+	return &Snapshot{}
+}
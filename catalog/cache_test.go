@@ -0,0 +1,136 @@
+package catalog_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/catalog"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheLookups(t *testing.T) {
+	registerCatalogResponders()
+	cache := catalog.NewCache(_coinmetrics, time.Hour)
+	assert.Nil(t, cache.Start(context.Background()))
+	defer cache.Close()
+
+	asset, ok := cache.Asset(`btc`)
+	assert.True(t, ok)
+	assert.Equal(t, api.FullName(`Bitcoin`), asset.FullName)
+	assert.Equal(t, 2, len(cache.AssetMetrics(`btc`)))
+	assert.Equal(t, []string{`1b`, `1d`}, cache.AssetMetricFrequencies(`btc`, `AdrActCnt`))
+	assert.Nil(t, cache.AssetMetricFrequencies(`eth`, `AdrActCnt`))
+
+	metric, ok := cache.Metric(`PriceUSD`)
+	assert.True(t, ok)
+	assert.Equal(t, api.Category(`Market`), metric.Category)
+
+	market, ok := cache.Market(`binance-BTCUSDT-future`)
+	assert.True(t, ok)
+	assert.Equal(t, `binance`, market.Exchange)
+	_, ok = cache.Market(`binance-ETHUSDT-future`)
+	assert.False(t, ok)
+
+	markets := cache.ExchangeMarkets(`coinbase`)
+	assert.Equal(t, 1, len(markets))
+	assert.Equal(t, api.MarketId(`coinbase-btc-usd-spot`), markets[0].Market)
+
+	assert.Nil(t, cache.Validator().Validate(&api.GetTimeseriesMarketTradesParams{Markets: `coinbase-btc-usd-spot`}))
+}
+
+func TestCacheSnapshotFile(t *testing.T) {
+	dir, err := ioutil.TempDir(``, `catalog`)
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, `catalog.json`)
+
+	registerCatalogResponders()
+	httpmock.ZeroCallCounters()
+	cache := catalog.NewCache(_coinmetrics, time.Hour, catalog.WithSnapshotFile(path))
+	assert.Nil(t, cache.Start(context.Background()))
+	cache.Close()
+	assert.Equal(t, 11, httpmock.GetTotalCallCount())
+
+	saved, err := catalog.ReadSnapshot(path)
+	assert.Nil(t, err)
+	assert.Equal(t, cache.Snapshot().Assets, saved.Assets)
+
+	// Fresh snapshot file is served without calling api
+	httpmock.ZeroCallCounters()
+	cache = catalog.NewCache(_coinmetrics, time.Hour, catalog.WithSnapshotFile(path))
+	assert.Nil(t, cache.Start(context.Background()))
+	cache.Close()
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+	_, ok := cache.Asset(`eth`)
+	assert.True(t, ok)
+}
+
+func TestCacheServesStaleSnapshotWhenRefreshFails(t *testing.T) {
+	dir, err := ioutil.TempDir(``, `catalog`)
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, `catalog.json`)
+
+	registerCatalogResponders()
+	snapshot, err := catalog.Load(context.Background(), _coinmetrics)
+	assert.Nil(t, err)
+	snapshot.Time = snapshot.Time.Add(-2 * time.Hour)
+	assert.Nil(t, snapshot.Save(path))
+
+	httpmock.RegisterResponder(http.MethodGet, catalogUrl(`assets`), jsonResponder(http.StatusInternalServerError, `{}`))
+	defer registerCatalogResponders()
+
+	var errs []error
+	cache := catalog.NewCache(_coinmetrics, time.Hour, catalog.WithSnapshotFile(path), catalog.WithErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+	assert.Nil(t, cache.Start(context.Background()))
+	cache.Close()
+	assert.Equal(t, 1, len(errs))
+	_, ok := cache.Asset(`btc`)
+	assert.True(t, ok)
+
+	// Without snapshot file there is nothing to serve
+	cache = catalog.NewCache(_coinmetrics, time.Hour)
+	assert.NotNil(t, cache.Start(context.Background()))
+}
+
+func TestCacheBackgroundRefresh(t *testing.T) {
+	registerCatalogResponders()
+	httpmock.ZeroCallCounters()
+	cache := catalog.NewCache(_coinmetrics, 20*time.Millisecond)
+	assert.Nil(t, cache.Start(context.Background()))
+	first := cache.Snapshot()
+	time.Sleep(100 * time.Millisecond)
+	cache.Close()
+	assert.True(t, cache.Snapshot().Time.After(first.Time))
+	assert.True(t, httpmock.GetTotalCallCount() > 11)
+}
+
+func TestCacheWithoutTTLNeverRefreshes(t *testing.T) {
+	registerCatalogResponders()
+	httpmock.ZeroCallCounters()
+	cache := catalog.NewCache(_coinmetrics, 0)
+	assert.Nil(t, cache.Start(context.Background()))
+	calls := httpmock.GetTotalCallCount()
+	time.Sleep(50 * time.Millisecond)
+	cache.Close()
+	assert.NotNil(t, cache.Snapshot())
+	assert.Equal(t, calls, httpmock.GetTotalCallCount())
+}
+
+func TestCacheStartsOnce(t *testing.T) {
+	registerCatalogResponders()
+	cache := catalog.NewCache(_coinmetrics, time.Hour)
+	assert.Nil(t, cache.Start(context.Background()))
+	defer cache.Close()
+	assert.EqualError(t, cache.Start(context.Background()), constants.AlreadyStarted)
+}
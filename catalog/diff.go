@@ -0,0 +1,315 @@
+package catalog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+)
+
+// EventKind tells what happened to catalog resource
+type EventKind string
+
+// Kinds of events produced by Diff
+const (
+	Added   EventKind = "added"
+	Removed EventKind = "removed"
+	Changed EventKind = "changed"
+)
+
+// Resource is the type of catalog entry an event refers to
+type Resource string
+
+// Resources compared by Diff
+const (
+	ResourceAsset       Resource = "asset"
+	ResourceMarket      Resource = "market"
+	ResourceMetric      Resource = "metric"
+	ResourceExchange    Resource = "exchange"
+	ResourceIndex       Resource = "index"
+	ResourceInstitution Resource = "institution"
+	ResourceAlertRule   Resource = "alert_rule"
+)
+
+// Event describes single difference between two snapshots.
+// For Changed events Field names changed attribute, list attributes report Added and Removed items
+// while single value attributes report Old and New value.
+type Event struct {
+	Kind     EventKind
+	Resource Resource
+	Id       string
+	Field    string
+	Added    []string
+	Removed  []string
+	Old      string
+	New      string
+}
+
+// String returns human readable description of event
+func (e Event) String() string {
+	if e.Kind != Changed {
+		return fmt.Sprintf(`%s %s %s`, e.Resource, e.Id, e.Kind)
+	}
+	if e.Added == nil && e.Removed == nil {
+		return fmt.Sprintf(`%s %s %s: %q -> %q`, e.Resource, e.Id, e.Field, e.Old, e.New)
+	}
+	return fmt.Sprintf(`%s %s %s: +[%s] -[%s]`, e.Resource, e.Id, e.Field, strings.Join(e.Added, ` `), strings.Join(e.Removed, ` `))
+}
+
+// Subscriber receives events every time catalog changes
+type Subscriber func(events []Event)
+
+// entry is comparable form of catalog record, time ranges are left out as they move with every refresh
+type entry struct {
+	lists  map[string][]string
+	values map[string]string
+}
+
+// Diff compares two snapshots and returns added, removed and changed catalog entries ordered by resource and id
+func Diff(old, new *Snapshot) []Event {
+	if old == nil {
+		old = &Snapshot{}
+	}
+	if new == nil {
+		new = &Snapshot{}
+	}
+	var events []Event
+	events = append(events, diffEntries(ResourceAsset, assetEntries(old), assetEntries(new))...)
+	events = append(events, diffEntries(ResourceMarket, marketEntries(old), marketEntries(new))...)
+	events = append(events, diffEntries(ResourceMetric, metricEntries(old), metricEntries(new))...)
+	events = append(events, diffEntries(ResourceExchange, exchangeEntries(old), exchangeEntries(new))...)
+	events = append(events, diffEntries(ResourceIndex, indexEntries(old), indexEntries(new))...)
+	events = append(events, diffEntries(ResourceInstitution, institutionEntries(old), institutionEntries(new))...)
+	events = append(events, diffEntries(ResourceAlertRule, alertRuleEntries(old), alertRuleEntries(new))...)
+	return events
+}
+
+func diffEntries(resource Resource, old, new map[string]entry) []Event {
+	var events []Event
+	for _, id := range sortedIds(old, new) {
+		before, inOld := old[id]
+		after, inNew := new[id]
+		switch {
+		case !inOld:
+			events = append(events, Event{Kind: Added, Resource: resource, Id: id})
+		case !inNew:
+			events = append(events, Event{Kind: Removed, Resource: resource, Id: id})
+		default:
+			events = append(events, diffEntry(resource, id, before, after)...)
+		}
+	}
+	return events
+}
+
+func diffEntry(resource Resource, id string, old, new entry) []Event {
+	var events []Event
+	for _, field := range sortedFields(old.values, new.values) {
+		if old.values[field] != new.values[field] {
+			events = append(events, Event{Kind: Changed, Resource: resource, Id: id, Field: field, Old: old.values[field], New: new.values[field]})
+		}
+	}
+	fields := map[string]bool{}
+	for field := range old.lists {
+		fields[field] = true
+	}
+	for field := range new.lists {
+		fields[field] = true
+	}
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+	for _, field := range names {
+		added, removed := diffLists(old.lists[field], new.lists[field])
+		if len(added) > 0 || len(removed) > 0 {
+			events = append(events, Event{Kind: Changed, Resource: resource, Id: id, Field: field, Added: added, Removed: removed})
+		}
+	}
+	return events
+}
+
+func diffLists(old, new []string) (added, removed []string) {
+	before := map[string]bool{}
+	for _, item := range old {
+		before[item] = true
+	}
+	after := map[string]bool{}
+	for _, item := range new {
+		after[item] = true
+		if !before[item] {
+			added = append(added, item)
+		}
+	}
+	for _, item := range old {
+		if !after[item] {
+			removed = append(removed, item)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+func sortedIds(old, new map[string]entry) []string {
+	ids := make([]string, 0, len(old)+len(new))
+	for id := range old {
+		ids = append(ids, id)
+	}
+	for id := range new {
+		if _, ok := old[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func sortedFields(old, new map[string]string) []string {
+	fields := make([]string, 0, len(old)+len(new))
+	for field := range old {
+		fields = append(fields, field)
+	}
+	for field := range new {
+		if _, ok := old[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func assetEntries(s *Snapshot) map[string]entry {
+	entries := map[string]entry{}
+	for _, asset := range s.Assets {
+		e := entry{lists: map[string][]string{}, values: map[string]string{`full_name`: string(asset.FullName)}}
+		if asset.Exchanges != nil {
+			for _, exchange := range *asset.Exchanges {
+				e.lists[`exchanges`] = append(e.lists[`exchanges`], string(exchange))
+			}
+		}
+		if asset.Markets != nil {
+			for _, market := range *asset.Markets {
+				e.lists[`markets`] = append(e.lists[`markets`], string(market))
+			}
+		}
+		if asset.Metrics != nil {
+			for _, metric := range *asset.Metrics {
+				for _, frequency := range metric.Frequencies {
+					e.lists[`metrics`] = append(e.lists[`metrics`], metricFrequency(metric.Metric, frequency.Frequency))
+				}
+			}
+		}
+		entries[string(asset.Asset)] = e
+	}
+	return entries
+}
+
+func marketEntries(s *Snapshot) map[string]entry {
+	entries := map[string]entry{}
+	for _, info := range s.Markets {
+		e := entry{lists: map[string][]string{}, values: map[string]string{`type`: string(info.Type)}}
+		for data, timeRange := range marketData(info) {
+			if timeRange != nil {
+				e.lists[`data`] = append(e.lists[`data`], string(data))
+			}
+		}
+		entries[string(info.Market)] = e
+	}
+	return entries
+}
+
+func metricEntries(s *Snapshot) map[string]entry {
+	entries := map[string]entry{}
+	for _, metric := range s.Metrics {
+		e := entry{lists: map[string][]string{}, values: map[string]string{
+			`full_name`:   string(metric.FullName),
+			`description`: string(metric.Description),
+			`category`:    string(metric.Category),
+			`subcategory`: string(metric.Subcategory),
+			`unit`:        string(metric.Unit),
+			`data_type`:   string(metric.DataType),
+			`type`:        string(metric.Type),
+		}}
+		for _, frequency := range metric.Frequencies {
+			e.lists[`frequencies`] = append(e.lists[`frequencies`], frequency.Frequency)
+		}
+		entries[string(metric.Metric)] = e
+	}
+	return entries
+}
+
+func exchangeEntries(s *Snapshot) map[string]entry {
+	entries := map[string]entry{}
+	for _, exchange := range s.Exchanges {
+		e := entry{lists: map[string][]string{}, values: map[string]string{}}
+		for _, market := range exchange.Markets {
+			e.lists[`markets`] = append(e.lists[`markets`], string(market))
+		}
+		if exchange.Metrics != nil {
+			for _, metric := range *exchange.Metrics {
+				for _, frequency := range metric.Frequencies {
+					e.lists[`metrics`] = append(e.lists[`metrics`], metricFrequency(metric.Metric, frequency.Frequency))
+				}
+			}
+		}
+		entries[string(exchange.Exchange)] = e
+	}
+	return entries
+}
+
+func indexEntries(s *Snapshot) map[string]entry {
+	entries := map[string]entry{}
+	for _, index := range s.Indexes {
+		e := entry{lists: map[string][]string{}, values: map[string]string{
+			`full_name`:   string(index.FullName),
+			`description`: string(index.Description),
+		}}
+		for _, frequency := range index.Frequencies {
+			e.lists[`frequencies`] = append(e.lists[`frequencies`], frequency.Frequency)
+		}
+		entries[string(index.Index)] = e
+	}
+	return entries
+}
+
+func institutionEntries(s *Snapshot) map[string]entry {
+	entries := map[string]entry{}
+	for _, institution := range s.Institutions {
+		e := entry{lists: map[string][]string{}, values: map[string]string{}}
+		if institution.Metrics != nil {
+			for _, metric := range *institution.Metrics {
+				for _, frequency := range metric.Frequencies {
+					e.lists[`metrics`] = append(e.lists[`metrics`], metricFrequency(metric.Metric, frequency.Frequency))
+				}
+			}
+		}
+		entries[string(institution.Institution)] = e
+	}
+	return entries
+}
+
+func alertRuleEntries(s *Snapshot) map[string]entry {
+	entries := map[string]entry{}
+	for _, rule := range s.AssetAlertRules {
+		e := entry{lists: map[string][]string{}, values: map[string]string{}}
+		for _, condition := range rule.Conditions {
+			threshold := ``
+			if condition.Threshold != nil {
+				threshold = string(*condition.Threshold)
+			}
+			constituents := make([]string, 0, len(condition.Constituents))
+			for _, constituent := range condition.Constituents {
+				constituents = append(constituents, string(constituent))
+			}
+			e.lists[`conditions`] = append(e.lists[`conditions`], fmt.Sprintf(`%s threshold=%s (%s)`, strings.Join(constituents, `/`), threshold, condition.Description))
+		}
+		entries[fmt.Sprintf(`%s/%s`, rule.Asset, rule.Name)] = e
+	}
+	return entries
+}
+
+func metricFrequency(metric api.MetricId, frequency string) string {
+	return fmt.Sprintf(`%s@%s`, metric, frequency)
+}
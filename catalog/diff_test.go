@@ -0,0 +1,91 @@
+package catalog_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/catalog"
+	"github.com/stretchr/testify/assert"
+)
+
+func getSnapshot(t *testing.T) *catalog.Snapshot {
+	snapshot := catalog.Snapshot{}
+	assert.Nil(t, json.Unmarshal([]byte(testSnapshot), &snapshot))
+	return &snapshot
+}
+
+func TestDiffSameSnapshot(t *testing.T) {
+	old := getSnapshot(t)
+	new := getSnapshot(t)
+	// Moving time ranges are not reported as changes
+	new.Markets[0].MaxTime = `2022-05-02T00:00:00.000000000Z`
+	assert.Nil(t, catalog.Diff(old, new))
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	old := getSnapshot(t)
+	new := getSnapshot(t)
+
+	// eth disappears, btc gains PriceUSD at 1h
+	new.Assets = new.Assets[:1]
+	metrics := *new.Assets[0].Metrics
+	metrics[0].Frequencies = append(metrics[0].Frequencies, api.AssetMetricFrequency{Frequency: `1h`})
+	// binance future is delisted and kraken market is listed
+	new.Markets = append(new.Markets[:1], api.MarketInfo{Market: `kraken-btc-usd-spot`, Exchange: `kraken`, Type: `spot`})
+	// PriceUSD is available at 1h and gets new description
+	new.Metrics[0].Frequencies = append(new.Metrics[0].Frequencies, api.MetricFrequency{Frequency: `1h`})
+	new.Metrics[0].Description = `Closing price`
+	// alert rule threshold changes
+	threshold := api.AssetAlertSubRuleThreshold(`1`)
+	new.AssetAlertRules[0].Conditions[0].Threshold = &threshold
+	new.Institutions = nil
+
+	var events []string
+	for _, event := range catalog.Diff(old, new) {
+		events = append(events, event.String())
+	}
+	assert.Equal(t, []string{
+		`asset btc metrics: +[PriceUSD@1h] -[]`,
+		`asset eth removed`,
+		`market binance-BTCUSDT-future removed`,
+		`market kraken-btc-usd-spot added`,
+		`metric PriceUSD description: "Fixed closing price" -> "Closing price"`,
+		`metric PriceUSD frequencies: +[1h] -[]`,
+		`institution grayscale removed`,
+		`alert_rule btc/block_count_empty_6b_hi conditions: +[block_count_empty_6b threshold=1 (At least one empty block)] -[block_count_empty_6b threshold=0 (At least one empty block)]`,
+	}, events)
+
+	removed := catalog.Diff(old, new)[1]
+	assert.Equal(t, catalog.Event{Kind: catalog.Removed, Resource: catalog.ResourceAsset, Id: `eth`}, removed)
+}
+
+func TestCacheNotifiesSubscribers(t *testing.T) {
+	registerCatalogResponders()
+	var events []catalog.Event
+	cache := catalog.NewCache(_coinmetrics, time.Hour, catalog.WithSubscriber(func(e []catalog.Event) {
+		events = append(events, e...)
+	}))
+	assert.Nil(t, cache.Start(context.Background()))
+	defer cache.Close()
+
+	// Unchanged catalog does not notify
+	assert.Nil(t, cache.Refresh(context.Background()))
+	assert.Nil(t, events)
+
+	body := testCatalogResources()[`markets`]
+	body = strings.Replace(body, `"market":"binance-BTCUSDT-future"`, `"market":"binance-ETHUSDT-future"`, 1)
+	httpmock.RegisterResponder(http.MethodGet, catalogUrl(`markets`), jsonResponder(http.StatusOK, body))
+	defer registerCatalogResponders()
+
+	assert.Nil(t, cache.Refresh(context.Background()))
+	assert.Equal(t, []catalog.Event{
+		{Kind: catalog.Removed, Resource: catalog.ResourceMarket, Id: `binance-BTCUSDT-future`},
+		{Kind: catalog.Added, Resource: catalog.ResourceMarket, Id: `binance-ETHUSDT-future`},
+	}, events)
+}
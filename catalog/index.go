@@ -0,0 +1,91 @@
+package catalog
+
+import (
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+)
+
+// index contains lookup tables built from snapshot
+type index struct {
+	assets          map[string]api.AssetInfo
+	assetMetrics    map[string]map[string][]string
+	metrics         map[string]api.MetricInfo
+	frequencies     map[string]bool
+	markets         map[string]api.MarketInfo
+	marketMetrics   map[string]map[string][]string
+	exchanges       map[string]api.ExchangeInfo
+	exchangeMarkets map[string][]api.MarketInfo
+	exchangeMetrics map[string]map[string][]string
+
+	// names are kept to look for suggestions
+	assetNames     []string
+	metricNames    []string
+	frequencyNames []string
+	marketNames    []string
+	exchangeNames  []string
+}
+
+func newIndex(snapshot *Snapshot) *index {
+	idx := index{
+		assets:          map[string]api.AssetInfo{},
+		assetMetrics:    map[string]map[string][]string{},
+		metrics:         map[string]api.MetricInfo{},
+		frequencies:     map[string]bool{},
+		markets:         map[string]api.MarketInfo{},
+		marketMetrics:   map[string]map[string][]string{},
+		exchanges:       map[string]api.ExchangeInfo{},
+		exchangeMarkets: map[string][]api.MarketInfo{},
+		exchangeMetrics: map[string]map[string][]string{},
+	}
+	for _, asset := range snapshot.Assets {
+		idx.assets[string(asset.Asset)] = asset
+		idx.assetNames = append(idx.assetNames, string(asset.Asset))
+		metrics := map[string][]string{}
+		if asset.Metrics != nil {
+			for _, metric := range *asset.Metrics {
+				for _, frequency := range metric.Frequencies {
+					metrics[string(metric.Metric)] = append(metrics[string(metric.Metric)], frequency.Frequency)
+					idx.frequencies[frequency.Frequency] = true
+				}
+			}
+		}
+		idx.assetMetrics[string(asset.Asset)] = metrics
+	}
+	for _, metric := range snapshot.Metrics {
+		idx.metrics[string(metric.Metric)] = metric
+		idx.metricNames = append(idx.metricNames, string(metric.Metric))
+		for _, frequency := range metric.Frequencies {
+			idx.frequencies[frequency.Frequency] = true
+		}
+	}
+	for _, info := range snapshot.Markets {
+		idx.markets[string(info.Market)] = info
+		idx.marketNames = append(idx.marketNames, string(info.Market))
+		idx.exchangeMarkets[info.Exchange] = append(idx.exchangeMarkets[info.Exchange], info)
+	}
+	for _, info := range snapshot.MarketMetrics {
+		metrics := map[string][]string{}
+		for _, metric := range info.Metrics {
+			for _, frequency := range metric.Frequencies {
+				metrics[string(metric.Metric)] = append(metrics[string(metric.Metric)], frequency.Frequency)
+			}
+		}
+		idx.marketMetrics[string(info.Market)] = metrics
+	}
+	for _, exchange := range snapshot.Exchanges {
+		idx.exchanges[string(exchange.Exchange)] = exchange
+		idx.exchangeNames = append(idx.exchangeNames, string(exchange.Exchange))
+		metrics := map[string][]string{}
+		if exchange.Metrics != nil {
+			for _, metric := range *exchange.Metrics {
+				for _, frequency := range metric.Frequencies {
+					metrics[string(metric.Metric)] = append(metrics[string(metric.Metric)], frequency.Frequency)
+				}
+			}
+		}
+		idx.exchangeMetrics[string(exchange.Exchange)] = metrics
+	}
+	for frequency := range idx.frequencies {
+		idx.frequencyNames = append(idx.frequencyNames, frequency)
+	}
+	return &idx
+}
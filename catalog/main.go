@@ -0,0 +1,174 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+)
+
+// Snapshot contains catalog resources loaded from `/catalog-all/*` endpoints at particular time
+type Snapshot struct {
+	Time            time.Time                      `json:"time"`
+	Assets          []api.AssetInfo                `json:"assets"`
+	Metrics         []api.MetricInfo               `json:"metrics"`
+	Markets         []api.MarketInfo               `json:"markets"`
+	MarketMetrics   []api.CatalogMarketMetricsInfo `json:"market_metrics"`
+	MarketCandles   []api.CatalogMarketCandlesInfo `json:"market_candles"`
+	Exchanges       []api.ExchangeInfo             `json:"exchanges"`
+	ExchangeAssets  []api.ExchangeAssetInfo        `json:"exchange_assets"`
+	AssetPairs      []api.PairInfo                 `json:"asset_pairs"`
+	Indexes         []api.IndexInfo                `json:"indexes"`
+	Institutions    []api.InstitutionInfo          `json:"institutions"`
+	AssetAlertRules []api.AssetAlertRuleInfo       `json:"asset_alert_rules"`
+}
+
+// Load will fetch all `/catalog-all/*` resources and build snapshot
+func Load(ctx context.Context, client api.ClientWithResponsesInterface, reqEditors ...api.RequestEditorFn) (*Snapshot, error) {
+	snapshot := Snapshot{Time: time.Now().UTC()}
+
+	assets, err := client.GetCatalogAllAssetsWithResponse(ctx, &api.GetCatalogAllAssetsParams{}, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	if assets.JSON200 == nil {
+		return nil, coinmetrics.NewApiError(assets.StatusCode(), assets.Body)
+	}
+	snapshot.Assets = assets.JSON200.Data
+
+	metrics, err := client.GetCatalogAllMetricsWithResponse(ctx, &api.GetCatalogAllMetricsParams{}, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	if metrics.JSON200 == nil {
+		return nil, coinmetrics.NewApiError(metrics.StatusCode(), metrics.Body)
+	}
+	snapshot.Metrics = metrics.JSON200.Data
+
+	markets, err := client.GetCatalogAllMarketsWithResponse(ctx, &api.GetCatalogAllMarketsParams{}, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	if markets.JSON200 == nil {
+		return nil, coinmetrics.NewApiError(markets.StatusCode(), markets.Body)
+	}
+	snapshot.Markets = markets.JSON200.Data
+
+	marketMetrics, err := client.GetCatalogAllMarketMetricsWithResponse(ctx, &api.GetCatalogAllMarketMetricsParams{}, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	if marketMetrics.JSON200 == nil {
+		return nil, coinmetrics.NewApiError(marketMetrics.StatusCode(), marketMetrics.Body)
+	}
+	snapshot.MarketMetrics = marketMetrics.JSON200.Data
+
+	marketCandles, err := client.GetCatalogAllMarketCandlesWithResponse(ctx, &api.GetCatalogAllMarketCandlesParams{}, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	if marketCandles.JSON200 == nil {
+		return nil, coinmetrics.NewApiError(marketCandles.StatusCode(), marketCandles.Body)
+	}
+	snapshot.MarketCandles = marketCandles.JSON200.Data
+
+	exchanges, err := client.GetCatalogAllExchangesWithResponse(ctx, &api.GetCatalogAllExchangesParams{}, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	if exchanges.JSON200 == nil {
+		return nil, coinmetrics.NewApiError(exchanges.StatusCode(), exchanges.Body)
+	}
+	snapshot.Exchanges = exchanges.JSON200.Data
+
+	exchangeAssets, err := client.GetCatalogAllExchangeAssetsWithResponse(ctx, &api.GetCatalogAllExchangeAssetsParams{}, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	if exchangeAssets.JSON200 == nil {
+		return nil, coinmetrics.NewApiError(exchangeAssets.StatusCode(), exchangeAssets.Body)
+	}
+	snapshot.ExchangeAssets = exchangeAssets.JSON200.Data
+
+	assetPairs, err := client.GetCatalogAllAssetPairsWithResponse(ctx, &api.GetCatalogAllAssetPairsParams{}, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	if assetPairs.JSON200 == nil {
+		return nil, coinmetrics.NewApiError(assetPairs.StatusCode(), assetPairs.Body)
+	}
+	snapshot.AssetPairs = assetPairs.JSON200.Data
+
+	indexes, err := client.GetCatalogAllIndexesWithResponse(ctx, &api.GetCatalogAllIndexesParams{}, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	if indexes.JSON200 == nil {
+		return nil, coinmetrics.NewApiError(indexes.StatusCode(), indexes.Body)
+	}
+	snapshot.Indexes = indexes.JSON200.Data
+
+	institutions, err := client.GetCatalogAllInstitutionsWithResponse(ctx, &api.GetCatalogAllInstitutionsParams{}, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	if institutions.JSON200 == nil {
+		return nil, coinmetrics.NewApiError(institutions.StatusCode(), institutions.Body)
+	}
+	snapshot.Institutions = institutions.JSON200.Data
+
+	alertRules, err := client.GetCatalogAllAssetAlertRulesWithResponse(ctx, &api.GetCatalogAllAssetAlertRulesParams{}, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	if alertRules.JSON200 == nil {
+		return nil, coinmetrics.NewApiError(alertRules.StatusCode(), alertRules.Body)
+	}
+	snapshot.AssetAlertRules = alertRules.JSON200.Data
+
+	return &snapshot, nil
+}
+
+// ReadSnapshot reads snapshot previously written by Save
+func ReadSnapshot(path string) (*Snapshot, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// Save writes snapshot as json to path, file is replaced atomically so readers never see partial snapshot
+func (s *Snapshot) Save(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+`.*.tmp`)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Age returns how long ago snapshot was loaded
+func (s *Snapshot) Age() time.Duration {
+	return time.Since(s.Time)
+}
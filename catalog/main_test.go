@@ -0,0 +1,99 @@
+package catalog_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/rulesng/coinmetrics-go-sdk/catalog"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+var _coinmetrics coinmetrics.CoinMetrics
+
+func TestMain(m *testing.M) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	var _err error
+	_coinmetrics, _err = coinmetrics.InitClient(constants.TestEndpoint, constants.TestKey)
+	if _err != nil {
+		fmt.Println(_err)
+	}
+	os.Exit(m.Run())
+}
+
+func TestLoadSnapshot(t *testing.T) {
+	registerCatalogResponders()
+	snapshot, err := catalog.Load(context.Background(), _coinmetrics)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(snapshot.Assets))
+	assert.Equal(t, 2, len(snapshot.Metrics))
+	assert.Equal(t, 2, len(snapshot.Markets))
+	assert.Equal(t, 1, len(snapshot.MarketMetrics))
+	assert.Equal(t, 1, len(snapshot.Exchanges))
+	assert.Equal(t, 1, len(snapshot.MarketCandles))
+	assert.Equal(t, 1, len(snapshot.ExchangeAssets))
+	assert.Equal(t, 1, len(snapshot.AssetPairs))
+	assert.Equal(t, 1, len(snapshot.Indexes))
+	assert.Equal(t, 1, len(snapshot.Institutions))
+	assert.Equal(t, 1, len(snapshot.AssetAlertRules))
+	assert.False(t, snapshot.Time.IsZero())
+}
+
+func TestLoadSnapshotFailAuthentication(t *testing.T) {
+	registerCatalogResponders()
+	httpmock.RegisterResponder(http.MethodGet, catalogUrl(`metrics`),
+		jsonResponder(http.StatusUnauthorized, `{"error":{"type":"unauthorized","message":"Requested resource requires authorization."}}`))
+	defer registerCatalogResponders()
+
+	snapshot, err := catalog.Load(context.Background(), _coinmetrics)
+	assert.Nil(t, snapshot)
+	apiError, ok := err.(coinmetrics.ApiError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusUnauthorized, apiError.StatusCode)
+	assert.Equal(t, `unauthorized`, apiError.Response.Error.Type)
+	assert.Equal(t, `api error 401 unauthorized: Requested resource requires authorization.`, err.Error())
+}
+
+func registerCatalogResponders() {
+	for resource, body := range testCatalogResources() {
+		httpmock.RegisterResponder(http.MethodGet, catalogUrl(resource), jsonResponder(http.StatusOK, body))
+	}
+}
+
+func jsonResponder(status int, body string) httpmock.Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(status, body)
+		resp.Header.Set(`Content-Type`, `application/json`)
+		return resp, nil
+	}
+}
+
+func catalogUrl(resource string) string {
+	return fmt.Sprintf(`%s%s/catalog-all/%s`, constants.TestEndpoint, constants.ApiVersion, resource)
+}
+
+// testCatalogResources splits test snapshot into bodies of `/catalog-all/*` responses
+func testCatalogResources() map[string]string {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(testSnapshot), &fields); err != nil {
+		panic(err)
+	}
+	names := map[string]string{`asset_pairs`: `pairs`, `asset_alert_rules`: `alerts`}
+	resources := map[string]string{}
+	for field, data := range fields {
+		name, ok := names[field]
+		if !ok {
+			name = strings.ReplaceAll(field, `_`, `-`)
+		}
+		resources[name] = fmt.Sprintf(`{"data":%s}`, data)
+	}
+	return resources
+}
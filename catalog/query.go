@@ -0,0 +1,424 @@
+package catalog
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/market"
+)
+
+// MarketData is the type of time series which market may provide
+type MarketData string
+
+// Market data listed in catalog
+const (
+	Trades       MarketData = "trades"
+	Quotes       MarketData = "quotes"
+	Orderbooks   MarketData = "orderbooks"
+	FundingRates MarketData = "funding_rates"
+	OpenInterest MarketData = "openinterest"
+	Liquidations MarketData = "liquidations"
+)
+
+// Weights of matches used to rank search results
+const (
+	idMatchScore          = 4
+	nameMatchScore        = 2
+	descriptionMatchScore = 1
+)
+
+// marketData returns time ranges of all data types of market, data which is not available is nil
+func marketData(info api.MarketInfo) map[MarketData]*api.MarketTimeRange {
+	return map[MarketData]*api.MarketTimeRange{
+		Trades:       info.Trades,
+		Quotes:       info.Quotes,
+		Orderbooks:   info.Orderbooks,
+		FundingRates: info.FundingRates,
+		OpenInterest: info.Openinterest,
+		Liquidations: info.Liquidations,
+	}
+}
+
+// parseTime parses catalog times like `2019-09-10T00:00:00.000000000Z`
+func parseTime(value string) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	return t, err == nil
+}
+
+// search splits text into lowercase terms, scoring checks that every term is present in one of the fields
+type search []string
+
+func newSearch(text string) search {
+	return search(strings.Fields(strings.ToLower(text)))
+}
+
+func (s search) score(id, name, description string) int {
+	id, name, description = strings.ToLower(id), strings.ToLower(name), strings.ToLower(description)
+	score := 0
+	for _, term := range s {
+		termScore := 0
+		if strings.Contains(id, term) {
+			termScore += idMatchScore
+		}
+		if strings.Contains(name, term) {
+			termScore += nameMatchScore
+		}
+		if strings.Contains(description, term) {
+			termScore += descriptionMatchScore
+		}
+		if termScore == 0 {
+			return 0
+		}
+		score += termScore
+	}
+	return score
+}
+
+// MarketQuery filters markets of snapshot, filters are combined with AND
+type MarketQuery struct {
+	markets []api.MarketInfo
+	filters []func(api.MarketInfo) bool
+}
+
+// QueryMarkets starts query over markets of snapshot
+func QueryMarkets(snapshot *Snapshot) *MarketQuery {
+	return &MarketQuery{markets: snapshot.Markets}
+}
+
+// Where adds custom filter
+func (q *MarketQuery) Where(fn func(api.MarketInfo) bool) *MarketQuery {
+	q.filters = append(q.filters, fn)
+	return q
+}
+
+// Exchange keeps markets listed on any of exchanges
+func (q *MarketQuery) Exchange(exchanges ...string) *MarketQuery {
+	return q.Where(func(info api.MarketInfo) bool {
+		for _, exchange := range exchanges {
+			if info.Exchange == exchange {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Type keeps markets of type spot, future or option
+func (q *MarketQuery) Type(marketType api.MarketType) *MarketQuery {
+	return q.Where(func(info api.MarketInfo) bool { return info.Type == marketType })
+}
+
+// Base keeps markets with base asset
+func (q *MarketQuery) Base(asset string) *MarketQuery {
+	return q.Where(func(info api.MarketInfo) bool { return info.Base != nil && string(*info.Base) == asset })
+}
+
+// Quote keeps markets with quote asset
+func (q *MarketQuery) Quote(asset string) *MarketQuery {
+	return q.Where(func(info api.MarketInfo) bool { return info.Quote != nil && string(*info.Quote) == asset })
+}
+
+// Asset keeps markets where asset is either base or quote
+func (q *MarketQuery) Asset(asset string) *MarketQuery {
+	return q.Where(func(info api.MarketInfo) bool {
+		return (info.Base != nil && string(*info.Base) == asset) || (info.Quote != nil && string(*info.Quote) == asset)
+	})
+}
+
+// Perpetual keeps futures without expiration
+func (q *MarketQuery) Perpetual() *MarketQuery {
+	return q.Where(func(info api.MarketInfo) bool { return info.Type == market.Future && info.Expiration == nil })
+}
+
+// With keeps markets which provide all data types
+func (q *MarketQuery) With(data ...MarketData) *MarketQuery {
+	return q.Where(func(info api.MarketInfo) bool {
+		available := marketData(info)
+		for _, d := range data {
+			if available[d] == nil {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Since keeps markets which provide data type from given time or earlier
+func (q *MarketQuery) Since(data MarketData, since time.Time) *MarketQuery {
+	return q.Where(func(info api.MarketInfo) bool {
+		timeRange := marketData(info)[data]
+		if timeRange == nil {
+			return false
+		}
+		minTime, ok := parseTime(string(timeRange.MinTime))
+		return ok && !minTime.After(since)
+	})
+}
+
+// ActiveSince keeps markets which have data after given time
+func (q *MarketQuery) ActiveSince(since time.Time) *MarketQuery {
+	return q.Where(func(info api.MarketInfo) bool {
+		maxTime, ok := parseTime(string(info.MaxTime))
+		return ok && !maxTime.Before(since)
+	})
+}
+
+// List returns matching markets ordered by market id
+func (q *MarketQuery) List() []api.MarketInfo {
+	var result []api.MarketInfo
+	for _, info := range q.markets {
+		if q.match(info) {
+			result = append(result, info)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Market < result[j].Market })
+	return result
+}
+
+// Ids returns ids of matching markets
+func (q *MarketQuery) Ids() api.MarketsIds {
+	var ids api.MarketsIds
+	for _, info := range q.List() {
+		ids = append(ids, info.Market)
+	}
+	return ids
+}
+
+// Count returns number of matching markets
+func (q *MarketQuery) Count() int {
+	return len(q.List())
+}
+
+func (q *MarketQuery) match(info api.MarketInfo) bool {
+	for _, filter := range q.filters {
+		if !filter(info) {
+			return false
+		}
+	}
+	return true
+}
+
+// MetricQuery filters metrics of snapshot, filters are combined with AND
+type MetricQuery struct {
+	metrics []api.MetricInfo
+	filters []func(api.MetricInfo) bool
+	search  search
+}
+
+// QueryMetrics starts query over metrics of snapshot
+func QueryMetrics(snapshot *Snapshot) *MetricQuery {
+	return &MetricQuery{metrics: snapshot.Metrics}
+}
+
+// Where adds custom filter
+func (q *MetricQuery) Where(fn func(api.MetricInfo) bool) *MetricQuery {
+	q.filters = append(q.filters, fn)
+	return q
+}
+
+// Category keeps metrics of category, comparison is case insensitive
+func (q *MetricQuery) Category(category string) *MetricQuery {
+	return q.Where(func(info api.MetricInfo) bool { return strings.EqualFold(string(info.Category), category) })
+}
+
+// Subcategory keeps metrics of subcategory, comparison is case insensitive
+func (q *MetricQuery) Subcategory(subcategory string) *MetricQuery {
+	return q.Where(func(info api.MetricInfo) bool { return strings.EqualFold(string(info.Subcategory), subcategory) })
+}
+
+// Frequency keeps metrics available at frequency
+func (q *MetricQuery) Frequency(frequency string) *MetricQuery {
+	return q.Where(func(info api.MetricInfo) bool {
+		for _, f := range info.Frequencies {
+			if f.Frequency == frequency {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Asset keeps metrics available for asset at any frequency
+func (q *MetricQuery) Asset(asset string) *MetricQuery {
+	return q.Where(func(info api.MetricInfo) bool {
+		for _, f := range info.Frequencies {
+			if f.Assets == nil {
+				continue
+			}
+			for _, a := range *f.Assets {
+				if string(a) == asset {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+// Reviewable keeps metrics which are reviewable by human
+func (q *MetricQuery) Reviewable() *MetricQuery {
+	return q.Where(func(info api.MetricInfo) bool { return info.Reviewable != nil && bool(*info.Reviewable) })
+}
+
+// Search keeps metrics where every word of text is found in id, full name, display name or description.
+// Results are ordered by relevance.
+func (q *MetricQuery) Search(text string) *MetricQuery {
+	q.search = newSearch(text)
+	return q
+}
+
+// List returns matching metrics ordered by relevance when searching, otherwise by metric id
+func (q *MetricQuery) List() []api.MetricInfo {
+	var result []api.MetricInfo
+	scores := map[api.MetricId]int{}
+	for _, info := range q.metrics {
+		if !q.match(info) {
+			continue
+		}
+		if len(q.search) > 0 {
+			name := string(info.FullName)
+			if info.DisplayName != nil {
+				name += ` ` + string(*info.DisplayName)
+			}
+			score := q.search.score(string(info.Metric), name, string(info.Description))
+			if score == 0 {
+				continue
+			}
+			scores[info.Metric] = score
+		}
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if scores[result[i].Metric] != scores[result[j].Metric] {
+			return scores[result[i].Metric] > scores[result[j].Metric]
+		}
+		return result[i].Metric < result[j].Metric
+	})
+	return result
+}
+
+// Ids returns ids of matching metrics
+func (q *MetricQuery) Ids() []api.MetricId {
+	var ids []api.MetricId
+	for _, info := range q.List() {
+		ids = append(ids, info.Metric)
+	}
+	return ids
+}
+
+func (q *MetricQuery) match(info api.MetricInfo) bool {
+	for _, filter := range q.filters {
+		if !filter(info) {
+			return false
+		}
+	}
+	return true
+}
+
+// AssetQuery filters assets of snapshot, filters are combined with AND
+type AssetQuery struct {
+	assets  []api.AssetInfo
+	filters []func(api.AssetInfo) bool
+	search  search
+}
+
+// QueryAssets starts query over assets of snapshot
+func QueryAssets(snapshot *Snapshot) *AssetQuery {
+	return &AssetQuery{assets: snapshot.Assets}
+}
+
+// Where adds custom filter
+func (q *AssetQuery) Where(fn func(api.AssetInfo) bool) *AssetQuery {
+	q.filters = append(q.filters, fn)
+	return q
+}
+
+// Exchange keeps assets traded on exchange
+func (q *AssetQuery) Exchange(exchange string) *AssetQuery {
+	return q.Where(func(info api.AssetInfo) bool {
+		if info.Exchanges == nil {
+			return false
+		}
+		for _, e := range *info.Exchanges {
+			if string(e) == exchange {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Metric keeps assets providing metric, when frequency is not empty metric must be available at that frequency
+func (q *AssetQuery) Metric(metric, frequency string) *AssetQuery {
+	return q.Where(func(info api.AssetInfo) bool {
+		if info.Metrics == nil {
+			return false
+		}
+		for _, m := range *info.Metrics {
+			if string(m.Metric) != metric {
+				continue
+			}
+			if frequency == `` {
+				return true
+			}
+			for _, f := range m.Frequencies {
+				if f.Frequency == frequency {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+// Search keeps assets where every word of text is found in id or full name, results are ordered by relevance
+func (q *AssetQuery) Search(text string) *AssetQuery {
+	q.search = newSearch(text)
+	return q
+}
+
+// List returns matching assets ordered by relevance when searching, otherwise by asset id
+func (q *AssetQuery) List() []api.AssetInfo {
+	var result []api.AssetInfo
+	scores := map[api.AssetId]int{}
+	for _, info := range q.assets {
+		if !q.match(info) {
+			continue
+		}
+		if len(q.search) > 0 {
+			score := q.search.score(string(info.Asset), string(info.FullName), ``)
+			if score == 0 {
+				continue
+			}
+			scores[info.Asset] = score
+		}
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if scores[result[i].Asset] != scores[result[j].Asset] {
+			return scores[result[i].Asset] > scores[result[j].Asset]
+		}
+		return result[i].Asset < result[j].Asset
+	})
+	return result
+}
+
+// Ids returns ids of matching assets
+func (q *AssetQuery) Ids() []api.AssetId {
+	var ids []api.AssetId
+	for _, info := range q.List() {
+		ids = append(ids, info.Asset)
+	}
+	return ids
+}
+
+func (q *AssetQuery) match(info api.AssetInfo) bool {
+	for _, filter := range q.filters {
+		if !filter(info) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package catalog_test
+
+import (
+	"testing"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/catalog"
+	"github.com/rulesng/coinmetrics-go-sdk/market"
+	"github.com/stretchr/testify/assert"
+)
+
+func getQuerySnapshot(t *testing.T) *catalog.Snapshot {
+	snapshot := getSnapshot(t)
+	expiration := api.FutureExpiration(`2022-06-24T08:00:00.000000000Z`)
+	snapshot.Markets = append(snapshot.Markets,
+		api.MarketInfo{Market: `binance-BTCUSDT_220624-future`, Exchange: `binance`, Type: market.Future, Expiration: &expiration,
+			FundingRates: &api.MarketTimeRange{MinTime: `2022-01-01T00:00:00.000000000Z`, MaxTime: `2022-05-01T00:00:00.000000000Z`}},
+		api.MarketInfo{Market: `bybit-BTCUSD-future`, Exchange: `bybit`, Type: market.Future,
+			FundingRates: &api.MarketTimeRange{MinTime: `2021-01-01T00:00:00.000000000Z`, MaxTime: `2022-05-01T00:00:00.000000000Z`}},
+		api.MarketInfo{Market: `binance-ETHUSDT-future`, Exchange: `binance`, Type: market.Future,
+			FundingRates: &api.MarketTimeRange{MinTime: `2020-01-01T00:00:00.000000000Z`, MaxTime: `2022-05-01T00:00:00.000000000Z`}},
+	)
+	return snapshot
+}
+
+func TestQueryPerpetualFuturesWithFundingRates(t *testing.T) {
+	snapshot := getQuerySnapshot(t)
+	since := time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	ids := catalog.QueryMarkets(snapshot).Exchange(`binance`).Perpetual().Since(catalog.FundingRates, since).Ids()
+	assert.Equal(t, api.MarketsIds{`binance-BTCUSDT-future`, `binance-ETHUSDT-future`}, ids)
+
+	ids = catalog.QueryMarkets(snapshot).Perpetual().Since(catalog.FundingRates, time.Date(2019, time.December, 1, 0, 0, 0, 0, time.UTC)).Ids()
+	assert.Equal(t, api.MarketsIds{`binance-BTCUSDT-future`}, ids)
+
+	assert.Equal(t, 4, catalog.QueryMarkets(snapshot).Type(market.Future).Count())
+	assert.Equal(t, 1, catalog.QueryMarkets(snapshot).With(catalog.Trades).Count())
+	assert.Equal(t, 1, catalog.QueryMarkets(snapshot).Base(`btc`).Quote(`usd`).Count())
+	assert.Equal(t, 0, catalog.QueryMarkets(snapshot).With(catalog.Trades, catalog.FundingRates).Count())
+}
+
+func TestQueryMetrics(t *testing.T) {
+	snapshot := getSnapshot(t)
+	assert.Equal(t, []api.MetricId{`AdrActCnt`}, catalog.QueryMetrics(snapshot).Category(`addresses`).Frequency(`1b`).Ids())
+	assert.Equal(t, []api.MetricId{`AdrActCnt`, `PriceUSD`}, catalog.QueryMetrics(snapshot).Frequency(`1d`).Asset(`btc`).Ids())
+	assert.Equal(t, []api.MetricId{`PriceUSD`}, catalog.QueryMetrics(snapshot).Asset(`eth`).Ids())
+	assert.Nil(t, catalog.QueryMetrics(snapshot).Category(`Market`).Frequency(`1b`).Ids())
+}
+
+func TestSearchMetricsAndAssets(t *testing.T) {
+	snapshot := getSnapshot(t)
+	assert.Equal(t, []api.MetricId{`AdrActCnt`}, catalog.QueryMetrics(snapshot).Search(`active addresses`).Ids())
+	assert.Equal(t, []api.MetricId{`PriceUSD`}, catalog.QueryMetrics(snapshot).Search(`closing PRICE`).Ids())
+
+	metrics := catalog.QueryMetrics(snapshot).Search(`a`).List()
+	assert.Equal(t, api.MetricId(`AdrActCnt`), metrics[0].Metric)
+
+	assert.Equal(t, []api.AssetId{`eth`}, catalog.QueryAssets(snapshot).Search(`ether`).Ids())
+	assert.Equal(t, []api.AssetId{`btc`}, catalog.QueryAssets(snapshot).Metric(`AdrActCnt`, `1b`).Ids())
+	assert.Equal(t, []api.AssetId{`btc`, `eth`}, catalog.QueryAssets(snapshot).Metric(`PriceUSD`, ``).Ids())
+}
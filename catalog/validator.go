@@ -0,0 +1,295 @@
+package catalog
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/rulesng/coinmetrics-go-sdk/market"
+)
+
+// maxSuggestions is the number of close matches reported for unknown value
+const maxSuggestions = 3
+
+// Problem describes single unsupported value of params
+type Problem struct {
+	Param       string
+	Value       string
+	Reason      string
+	Suggestions []string
+}
+
+// String returns human readable description of problem
+func (p Problem) String() string {
+	message := fmt.Sprintf(`%s %q: %s`, p.Param, p.Value, p.Reason)
+	if len(p.Suggestions) > 0 {
+		message = fmt.Sprintf(`%s, did you mean %s?`, message, strings.Join(p.Suggestions, `, `))
+	}
+	return message
+}
+
+// ValidationError is returned by Validator when params contain values which are not supported by catalog
+type ValidationError struct {
+	Problems []Problem
+}
+
+// Error lists all problems found in params
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Problems)+1)
+	lines = append(lines, constants.UnsupportedParams+`:`)
+	for _, problem := range e.Problems {
+		lines = append(lines, `  `+problem.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validator checks params against catalog snapshot before request is sent, so bad values fail without network round-trip
+type Validator struct {
+	*index
+}
+
+// NewValidator builds lookup tables from snapshot
+func NewValidator(snapshot *Snapshot) *Validator {
+	return &Validator{newIndex(snapshot)}
+}
+
+// Validate checks params struct of timeseries call, it returns *ValidationError listing all unsupported values.
+// Params of operations which are not backed by catalog are not checked and nil is returned.
+func (v *Validator) Validate(params interface{}) error {
+	c := checker{v: v}
+	switch p := params.(type) {
+	case *api.GetTimeseriesAssetMetricsParams:
+		c.assetMetrics(splitList(string(p.Assets)), p.Metrics, frequencyOrDefault((*string)(p.Frequency)))
+	case *api.GetTimeseriesMarketMetricsParams:
+		c.marketMetrics(p.Markets, p.Metrics, frequencyOrDefault((*string)(p.Frequency)))
+	case *api.GetTimeseriesExchangeMetricsParams:
+		c.exchangeMetrics(p.Exchanges, p.Metrics, frequencyOrDefault((*string)(p.Frequency)))
+	case *api.GetTimeseriesMarketCandlesParams:
+		c.marketsWith(p.Markets, `candles`, nil)
+	case *api.GetTimeseriesMarketTradesParams:
+		c.marketsWith(p.Markets, `trades`, func(info api.MarketInfo) bool { return info.Trades != nil })
+	case *api.GetTimeseriesMarketQuotesParams:
+		c.marketsWith(p.Markets, `quotes`, func(info api.MarketInfo) bool { return info.Quotes != nil })
+	case *api.GetTimeseriesMarketOrderbooksParams:
+		c.marketsWith(p.Markets, `orderbooks`, func(info api.MarketInfo) bool { return info.Orderbooks != nil })
+	case *api.GetTimeseriesMarketFundingRatesParams:
+		c.marketsWith(p.Markets, `funding rates`, func(info api.MarketInfo) bool { return info.FundingRates != nil })
+	case *api.GetTimeseriesMarketOpenInteresetParams:
+		c.marketsWith(p.Markets, `open interest`, func(info api.MarketInfo) bool { return info.Openinterest != nil })
+	case *api.GetTimeseriesMarketLiquidationsParams:
+		c.marketsWith(p.Markets, `liquidations`, func(info api.MarketInfo) bool { return info.Liquidations != nil })
+	case *api.GetTimeseriesMarketContractPricesParams:
+		c.marketsWith(p.Markets, `contract prices`, func(info api.MarketInfo) bool { return info.Type != market.Spot })
+	case *api.GetTimeseriesMarketImpliedVolatilityParams:
+		c.marketsWith(p.Markets, `implied volatility`, func(info api.MarketInfo) bool { return info.Type == market.Option })
+	case *api.GetTimeseriesMarketGreeksParams:
+		c.marketsWith(p.Markets, `greeks`, func(info api.MarketInfo) bool { return info.Type == market.Option })
+	default:
+		return nil
+	}
+	if len(c.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: c.problems}
+}
+
+// checker collects problems of single Validate call
+type checker struct {
+	v        *Validator
+	problems []Problem
+}
+
+func (c *checker) add(param, value, reason string, suggestions []string) {
+	c.problems = append(c.problems, Problem{Param: param, Value: value, Reason: reason, Suggestions: suggestions})
+}
+
+func (c *checker) frequency(frequency string) {
+	if !c.v.frequencies[frequency] {
+		c.add(`frequency`, frequency, `unknown frequency`, suggest(frequency, c.v.frequencyNames))
+	}
+}
+
+func (c *checker) assetMetrics(assets []string, metrics []string, frequency string) {
+	c.frequency(frequency)
+	known := assets[:0:0]
+	for _, asset := range assets {
+		if asset == `*` {
+			continue
+		}
+		if _, ok := c.v.assets[asset]; !ok {
+			c.add(`assets`, asset, `unknown asset`, suggest(asset, c.v.assetNames))
+			continue
+		}
+		known = append(known, asset)
+	}
+	for _, metric := range metrics {
+		if _, ok := c.v.metrics[metric]; !ok {
+			c.add(`metrics`, metric, `unknown metric`, suggest(metric, c.v.metricNames))
+			continue
+		}
+		for _, asset := range known {
+			c.combination(`metrics`, metric, `asset `+asset, c.v.assetMetrics[asset], frequency)
+		}
+	}
+}
+
+func (c *checker) marketMetrics(markets []string, metrics []string, frequency string) {
+	known := c.markets(markets)
+	for _, metric := range metrics {
+		for _, id := range known {
+			c.combination(`metrics`, metric, `market `+id, c.v.marketMetrics[id], frequency)
+		}
+	}
+}
+
+func (c *checker) exchangeMetrics(exchanges []string, metrics []string, frequency string) {
+	known := exchanges[:0:0]
+	for _, exchange := range exchanges {
+		if _, ok := c.v.exchanges[exchange]; !ok {
+			c.add(`exchanges`, exchange, `unknown exchange`, suggest(exchange, c.v.exchangeNames))
+			continue
+		}
+		known = append(known, exchange)
+	}
+	for _, metric := range metrics {
+		for _, exchange := range known {
+			c.combination(`metrics`, metric, `exchange `+exchange, c.v.exchangeMetrics[exchange], frequency)
+		}
+	}
+}
+
+// combination checks that metric is available for entity at given frequency
+func (c *checker) combination(param, metric, entity string, available map[string][]string, frequency string) {
+	frequencies, ok := available[metric]
+	if !ok {
+		c.add(param, metric, `not available for `+entity, suggest(metric, keys(available)))
+		return
+	}
+	for _, f := range frequencies {
+		if f == frequency {
+			return
+		}
+	}
+	c.add(param, metric, fmt.Sprintf(`not available for %s at frequency %s (available: %s)`, entity, frequency, strings.Join(frequencies, `, `)), nil)
+}
+
+// markets checks markets and patterns like `exchange-*`, it returns known market ids
+func (c *checker) markets(values []string) []string {
+	var known []string
+	for _, value := range values {
+		if strings.Contains(value, `*`) {
+			matched := false
+			for id := range c.v.markets {
+				if ok, _ := path.Match(value, id); ok {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				c.add(`markets`, value, `pattern does not match any market`, nil)
+			}
+			continue
+		}
+		if _, ok := c.v.markets[value]; !ok {
+			reason := `unknown market`
+			if _, err := market.Parse(api.MarketId(value)); err != nil {
+				reason = err.Error()
+			}
+			c.add(`markets`, value, reason, suggest(value, c.v.marketNames))
+			continue
+		}
+		known = append(known, value)
+	}
+	return known
+}
+
+// marketsWith checks comma separated markets and that every market provides requested data
+func (c *checker) marketsWith(markets api.MarketId, data string, supports func(api.MarketInfo) bool) {
+	for _, id := range c.markets(splitList(string(markets))) {
+		if supports != nil && !supports(c.v.markets[id]) {
+			c.add(`markets`, id, data+` are not available for market`, nil)
+		}
+	}
+}
+
+func frequencyOrDefault(frequency *string) string {
+	if frequency == nil || *frequency == `` {
+		return constants.DefaultFrequency
+	}
+	return *frequency
+}
+
+func splitList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, `,`) {
+		if v = strings.TrimSpace(v); v != `` {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func keys(m map[string][]string) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	return result
+}
+
+// suggest returns closest candidates to value, ordered by edit distance
+func suggest(value string, candidates []string) []string {
+	type match struct {
+		candidate string
+		distance  int
+	}
+	lower := strings.ToLower(value)
+	limit := len(value)/3 + 1
+	var matches []match
+	for _, candidate := range candidates {
+		distance := levenshtein(lower, strings.ToLower(candidate))
+		if distance <= limit || strings.HasPrefix(strings.ToLower(candidate), lower) {
+			matches = append(matches, match{candidate, distance})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].candidate < matches[j].candidate
+	})
+	var suggestions []string
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, matches[i].candidate)
+	}
+	return suggestions
+}
+
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j] + 1
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+			if previous[j-1]+cost < current[j] {
+				current[j] = previous[j-1] + cost
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
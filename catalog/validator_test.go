@@ -0,0 +1,159 @@
+package catalog_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/catalog"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSnapshot = `{
+	"assets": [
+		{"asset":"btc","full_name":"Bitcoin","metrics":[{"metric":"PriceUSD","frequencies":[{"frequency":"1d","min_time":"2010-07-18T00:00:00.000000000Z","max_time":"2022-05-01T00:00:00.000000000Z"}]},{"metric":"AdrActCnt","frequencies":[{"frequency":"1b","min_time":"2009-01-03T18:15:05.000000000Z","max_time":"2022-05-01T00:00:00.000000000Z"},{"frequency":"1d","min_time":"2009-01-03T00:00:00.000000000Z","max_time":"2022-05-01T00:00:00.000000000Z"}]}]},
+		{"asset":"eth","full_name":"Ethereum","metrics":[{"metric":"PriceUSD","frequencies":[{"frequency":"1d","min_time":"2015-08-08T00:00:00.000000000Z","max_time":"2022-05-01T00:00:00.000000000Z"}]}]}
+	],
+	"metrics": [
+		{"metric":"PriceUSD","full_name":"Price, USD","description":"Fixed closing price","category":"Market","subcategory":"Price","unit":"USD","data_type":"decimal","type":"Price","frequencies":[{"frequency":"1d","assets":["btc","eth"]}]},
+		{"metric":"AdrActCnt","full_name":"Addresses, active, count","description":"Active addresses","category":"Addresses","subcategory":"Active","unit":"Addresses","data_type":"bigint","type":"Sum","frequencies":[{"frequency":"1b","assets":["btc"]},{"frequency":"1d","assets":["btc"]}]}
+	],
+	"markets": [
+		{"market":"coinbase-btc-usd-spot","exchange":"coinbase","type":"spot","base":"btc","quote":"usd","min_time":"2015-01-01T00:00:00.000000000Z","max_time":"2022-05-01T00:00:00.000000000Z","trades":{"min_time":"2015-01-01T00:00:00.000000000Z","max_time":"2022-05-01T00:00:00.000000000Z"}},
+		{"market":"binance-BTCUSDT-future","exchange":"binance","type":"future","min_time":"2019-09-08T00:00:00.000000000Z","max_time":"2022-05-01T00:00:00.000000000Z","funding_rates":{"min_time":"2019-09-10T00:00:00.000000000Z","max_time":"2022-05-01T00:00:00.000000000Z"}}
+	],
+	"market_metrics": [
+		{"market":"binance-BTCUSDT-future","metrics":[{"metric":"liquidations_reported_future_buy_units_1h","frequencies":[{"frequency":"1h","min_time":"2020-01-01T00:00:00.000000000Z","max_time":"2022-05-01T00:00:00.000000000Z"}]}]}
+	],
+	"market_candles": [
+		{"market":"coinbase-btc-usd-spot","frequencies":[{"frequency":"1m","min_time":"2015-01-01T00:00:00.000000000Z","max_time":"2022-05-01T00:00:00.000000000Z"}]}
+	],
+	"exchanges": [
+		{"exchange":"coinbase","markets":["coinbase-btc-usd-spot"],"min_time":"2015-01-01T00:00:00.000000000Z","max_time":"2022-05-01T00:00:00.000000000Z","metrics":[{"metric":"volume_reported_spot_usd_1d","frequencies":[{"frequency":"1d","min_time":"2015-01-01T00:00:00.000000000Z","max_time":"2022-05-01T00:00:00.000000000Z"}]}]}
+	],
+	"exchange_assets": [
+		{"exchange_asset":"coinbase-btc","metrics":[{"metric":"volume_trusted_spot_usd_1d","frequencies":[{"frequency":"1d","min_time":"2015-01-01T00:00:00.000000000Z","max_time":"2022-05-01T00:00:00.000000000Z"}]}]}
+	],
+	"asset_pairs": [
+		{"pair":"btc-usd","metrics":[{"metric":"volume_trusted_spot_usd_1d","frequencies":[{"frequency":"1d","min_time":"2015-01-01T00:00:00.000000000Z","max_time":"2022-05-01T00:00:00.000000000Z"}]}]}
+	],
+	"indexes": [
+		{"index":"CMBIBTC","full_name":"CMBI Bitcoin Index","description":"Bitcoin index","frequencies":[{"frequency":"15s","min_time":"2010-07-18T00:00:00.000000000Z","max_time":"2022-05-01T00:00:00.000000000Z"}]}
+	],
+	"institutions": [
+		{"institution":"grayscale","metrics":[{"metric":"gbtc_total_assets","frequencies":[{"frequency":"1d","min_time":"2019-01-01T00:00:00.000000000Z","max_time":"2022-05-01T00:00:00.000000000Z"}]}]}
+	],
+	"asset_alert_rules": [
+		{"asset":"btc","name":"block_count_empty_6b_hi","conditions":[{"description":"At least one empty block","threshold":"0","constituents":["block_count_empty_6b"]}]}
+	]
+}`
+
+func getValidator(t *testing.T) *catalog.Validator {
+	snapshot := catalog.Snapshot{}
+	err := json.Unmarshal([]byte(testSnapshot), &snapshot)
+	assert.Nil(t, err)
+	return catalog.NewValidator(&snapshot)
+}
+
+func TestValidateSupportedAssetMetrics(t *testing.T) {
+	frequency := api.AssetMetricsFrequency(`1b`)
+	err := getValidator(t).Validate(&api.GetTimeseriesAssetMetricsParams{
+		Assets:    `btc`,
+		Metrics:   api.AssetMetrics{`AdrActCnt`},
+		Frequency: &frequency,
+	})
+	assert.Nil(t, err)
+
+	err = getValidator(t).Validate(&api.GetTimeseriesAssetMetricsParams{
+		Assets:  `btc,eth`,
+		Metrics: api.AssetMetrics{`PriceUSD`},
+	})
+	assert.Nil(t, err)
+}
+
+func TestValidateUnknownAssetWithSuggestions(t *testing.T) {
+	err := getValidator(t).Validate(&api.GetTimeseriesAssetMetricsParams{
+		Assets:  `btcc`,
+		Metrics: api.AssetMetrics{`PriceUSd`},
+	})
+	validationError, ok := err.(*catalog.ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, []catalog.Problem{
+		{Param: `assets`, Value: `btcc`, Reason: `unknown asset`, Suggestions: []string{`btc`}},
+		{Param: `metrics`, Value: `PriceUSd`, Reason: `unknown metric`, Suggestions: []string{`PriceUSD`}},
+	}, validationError.Problems)
+	assert.True(t, strings.Contains(err.Error(), `assets "btcc": unknown asset, did you mean btc?`))
+}
+
+func TestValidateUnsupportedCombinations(t *testing.T) {
+	frequency := api.AssetMetricsFrequency(`1b`)
+	err := getValidator(t).Validate(&api.GetTimeseriesAssetMetricsParams{
+		Assets:    `btc,eth`,
+		Metrics:   api.AssetMetrics{`PriceUSD`, `AdrActCnt`},
+		Frequency: &frequency,
+	})
+	validationError, ok := err.(*catalog.ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{
+		`metrics "PriceUSD": not available for asset btc at frequency 1b (available: 1d)`,
+		`metrics "PriceUSD": not available for asset eth at frequency 1b (available: 1d)`,
+		`metrics "AdrActCnt": not available for asset eth`,
+	}, problems(validationError))
+}
+
+func TestValidateMarkets(t *testing.T) {
+	v := getValidator(t)
+	assert.Nil(t, v.Validate(&api.GetTimeseriesMarketTradesParams{Markets: `coinbase-btc-usd-spot,coinbase-*`}))
+	assert.Nil(t, v.Validate(&api.GetTimeseriesMarketFundingRatesParams{Markets: `binance-BTCUSDT-future`}))
+
+	err := v.Validate(&api.GetTimeseriesMarketFundingRatesParams{Markets: `coinbase-btc-usd-spot,kraken-*,coinbase-btc-usdd-spot`})
+	validationError, ok := err.(*catalog.ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{
+		`markets "kraken-*": pattern does not match any market`,
+		`markets "coinbase-btc-usdd-spot": unknown market, did you mean coinbase-btc-usd-spot?`,
+		`markets "coinbase-btc-usd-spot": funding rates are not available for market`,
+	}, problems(validationError))
+
+	err = v.Validate(&api.GetTimeseriesMarketGreeksParams{Markets: `binance-BTCUSDT-future`})
+	assert.NotNil(t, err)
+}
+
+func TestValidateMarketAndExchangeMetrics(t *testing.T) {
+	v := getValidator(t)
+	frequency := api.MarketMetricsFrequency(`1h`)
+	assert.Nil(t, v.Validate(&api.GetTimeseriesMarketMetricsParams{
+		Markets:   api.Markets{`binance-BTCUSDT-future`},
+		Metrics:   api.MarketMetricsParam{`liquidations_reported_future_buy_units_1h`},
+		Frequency: &frequency,
+	}))
+	assert.NotNil(t, v.Validate(&api.GetTimeseriesMarketMetricsParams{
+		Markets: api.Markets{`binance-BTCUSDT-future`},
+		Metrics: api.MarketMetricsParam{`liquidations_reported_future_buy_units_1h`},
+	}))
+
+	assert.Nil(t, v.Validate(&api.GetTimeseriesExchangeMetricsParams{
+		Exchanges: api.Exchanges{`coinbase`},
+		Metrics:   api.ExchangeMetricsParam{`volume_reported_spot_usd_1d`},
+	}))
+	err := v.Validate(&api.GetTimeseriesExchangeMetricsParams{
+		Exchanges: api.Exchanges{`coinbas`},
+		Metrics:   api.ExchangeMetricsParam{`volume_reported_spot_usd_1d`},
+	})
+	validationError, ok := err.(*catalog.ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{`exchanges "coinbas": unknown exchange, did you mean coinbase?`}, problems(validationError))
+}
+
+func TestValidateParamsWithoutCatalog(t *testing.T) {
+	assert.Nil(t, getValidator(t).Validate(&api.GetTimeseriesIndexLevelsParams{}))
+}
+
+func problems(err *catalog.ValidationError) []string {
+	var result []string
+	for _, problem := range err.Problems {
+		result = append(result, problem.String())
+	}
+	return result
+}
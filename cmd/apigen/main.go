@@ -0,0 +1,116 @@
+// Command apigen maintains generated client of `api/v4`, it is run by `go generate ./api/v4`.
+//
+// Usage:
+//
+//	apigen overlay -overlay overlay.yml v4.go
+//	apigen diff [-json] old.yml new.yml
+//	apigen sync -o sync.gen.go
+//
+// `overlay` applies customizations of the client to oapi-codegen output in place, so regenerating the client from spec
+// is deterministic. `diff` reports operations, params and schemas added or removed between spec versions. `sync`
+// generates WithResponseSync wrappers of package coinmetrics fetching all pages of paginated operations.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/rulesng/coinmetrics-go-sdk/openapi"
+)
+
+func main() {
+	err := run(os.Args[1:], os.Stdout, os.Stderr)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) < 1 {
+		usage(stderr)
+		return flag.ErrHelp
+	}
+	switch args[0] {
+	case `overlay`:
+		return overlayCommand(args[1:], stderr)
+	case `diff`:
+		return diffCommand(args[1:], stdout, stderr)
+	case `sync`:
+		return syncCommand(args[1:], stderr)
+	}
+	usage(stderr)
+	return fmt.Errorf(`%s: %s`, constants.UnknownCommand, args[0])
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:\n  apigen overlay -overlay overlay.yml <file.go>\n  apigen diff [-json] <old.yml> <new.yml>\n  apigen sync -o sync.gen.go")
+}
+
+func overlayCommand(args []string, stderr io.Writer) error {
+	fs := flag.NewFlagSet(`apigen overlay`, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	path := fs.String(`overlay`, `overlay.yml`, `overlay file`)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf(`%s: file`, constants.MissingFlag)
+	}
+	o, err := loadOverlay(*path)
+	if err != nil {
+		return err
+	}
+	source, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	patched, err := o.apply(source)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fs.Arg(0), patched, 0o644)
+}
+
+func diffCommand(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet(`apigen diff`, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	asJSON := fs.Bool(`json`, false, `write changes as json`)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return fmt.Errorf(`%s: old and new spec`, constants.MissingFlag)
+	}
+	var specs [2]*openapi.Spec
+	for i := range specs {
+		data, err := ioutil.ReadFile(fs.Arg(i))
+		if err != nil {
+			return err
+		}
+		if specs[i], err = openapi.Load(data); err != nil {
+			return fmt.Errorf(`%s: %v`, fs.Arg(i), err)
+		}
+	}
+	changes := openapi.Diff(specs[0], specs[1])
+	if *asJSON {
+		if changes == nil {
+			changes = []openapi.Change{}
+		}
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent(``, `  `)
+		return encoder.Encode(changes)
+	}
+	for _, change := range changes {
+		fmt.Fprintln(stdout, change)
+	}
+	return nil
+}
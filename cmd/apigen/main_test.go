@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/rulesng/coinmetrics-go-sdk/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRegenerateClient runs oapi-codegen pinned by go.mod like `go generate ./api/v4` in temporary directory and checks
+// overlay reproduces committed v4.go exactly
+func TestRegenerateClient(t *testing.T) {
+	if testing.Short() {
+		t.Skip(`builds oapi-codegen`)
+	}
+	dir := t.TempDir()
+	generator := filepath.Join(dir, `oapi-codegen`)
+	build := exec.Command(`go`, `build`, `-o`, generator, `github.com/deepmap/oapi-codegen/cmd/oapi-codegen`)
+	output, err := build.CombinedOutput()
+	if !assert.Nil(t, err, string(output)) {
+		return
+	}
+	for _, name := range []string{`oapi-codegen.yml`, `spec/v4.yml`} {
+		data, err := ioutil.ReadFile(filepath.Join(`../../api/v4`, name))
+		assert.Nil(t, err)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), data, 0o644))
+	}
+	generate := exec.Command(generator, `-config`, `oapi-codegen.yml`, `spec/v4.yml`)
+	generate.Dir = dir
+	output, err = generate.CombinedOutput()
+	if !assert.Nil(t, err, string(output)) {
+		return
+	}
+	generated, err := ioutil.ReadFile(filepath.Join(dir, `v4.go`))
+	assert.Nil(t, err)
+
+	o, err := loadOverlay(`../../api/v4/overlay.yml`)
+	assert.Nil(t, err)
+	patched, err := o.apply(generated)
+	assert.Nil(t, err)
+	client, err := ioutil.ReadFile(`../../api/v4/v4.go`)
+	assert.Nil(t, err)
+	assert.True(t, bytes.Equal(client, patched), `v4.go differs from output of go generate ./api/v4`)
+
+	// applying overlay twice fails instead of silently changing nothing
+	_, err = o.apply(patched)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), constants.InvalidPatch)
+	}
+}
+
+func TestOverlayCommand(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, `client.go`)
+	overlayPath := filepath.Join(dir, `overlay.yml`)
+	assert.Nil(t, ioutil.WriteFile(source, []byte("package v4\n\ntype Asset string\n\nvar a, b Asset\n"), 0o644))
+	assert.Nil(t, ioutil.WriteFile(overlayPath, []byte(`
+patches:
+  - reason: rename
+    match: '\bAsset\b'
+    replacement: AssetId
+    count: 2
+`), 0o644))
+	var stderr bytes.Buffer
+	assert.Nil(t, run([]string{`overlay`, `-overlay`, overlayPath, source}, &bytes.Buffer{}, &stderr))
+	patched, err := ioutil.ReadFile(source)
+	assert.Nil(t, err)
+	assert.Equal(t, "package v4\n\ntype AssetId string\n\nvar a, b AssetId\n", string(patched))
+
+	err = run([]string{`overlay`, `-overlay`, overlayPath, source}, &bytes.Buffer{}, &stderr)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), `rename: matched 0 times`)
+	}
+}
+
+func TestDiffCommand(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, `old.yml`)
+	new := filepath.Join(dir, `new.yml`)
+	assert.Nil(t, ioutil.WriteFile(old, []byte(`
+paths:
+  /catalog/assets:
+    get:
+      operationId: getCatalogAssets
+      parameters:
+        - {name: assets, in: query}
+`), 0o644))
+	assert.Nil(t, ioutil.WriteFile(new, []byte(`
+paths:
+  /catalog/assets:
+    get:
+      operationId: getCatalogAssets
+      parameters:
+        - {name: assets, in: query}
+        - {name: include, in: query}
+  /catalog/pairs:
+    get:
+      operationId: getCatalogPairs
+`), 0o644))
+	var stdout bytes.Buffer
+	assert.Nil(t, run([]string{`diff`, old, new}, &stdout, &bytes.Buffer{}))
+	assert.Equal(t, "param added /catalog/assets include\noperation added /catalog/pairs getCatalogPairs\n", stdout.String())
+
+	stdout.Reset()
+	assert.Nil(t, run([]string{`diff`, `-json`, new, new}, &stdout, &bytes.Buffer{}))
+	assert.Equal(t, "[]\n", stdout.String())
+
+	err := run([]string{`frobnicate`}, &stdout, &bytes.Buffer{})
+	assert.True(t, strings.HasPrefix(err.Error(), constants.UnknownCommand))
+}
+
+func TestSyncIsGenerated(t *testing.T) {
+	spec, err := openapi.V4()
+	assert.Nil(t, err)
+	client, err := ioutil.ReadFile(`../../api/v4/v4.go`)
+	assert.Nil(t, err)
+	generated, err := generateSync(spec, client)
+	assert.Nil(t, err)
+	current, err := ioutil.ReadFile(`../../coinmetrics/sync.gen.go`)
+	assert.Nil(t, err)
+	assert.True(t, bytes.Equal(generated, current), `coinmetrics/sync.gen.go is stale, run go generate ./coinmetrics`)
+	assert.Contains(t, string(generated), `func (c CoinMetrics) GetBlockchainV2ListOfBlocksWithResponseSync(ctx context.Context, asset api.BlockchainAsset, `)
+	assert.Equal(t, `blockHash`, lowerCamel(`block_hash`))
+}
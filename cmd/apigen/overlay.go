@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"regexp"
+
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"gopkg.in/yaml.v3"
+)
+
+// patch replaces matches of regular expression, expansion of replacement follows regexp.Expand
+type patch struct {
+	Reason      string `yaml:"reason"`
+	Match       string `yaml:"match"`
+	Replacement string `yaml:"replacement"`
+	// Count is exact number of matches, 0 accepts any positive number
+	Count int `yaml:"count"`
+}
+
+// overlay is ordered list of patches applied to generated code
+type overlay struct {
+	Patches []patch `yaml:"patches"`
+}
+
+func loadOverlay(path string) (*overlay, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var o overlay
+	if err = yaml.Unmarshal(data, &o); err != nil {
+		return nil, fmt.Errorf(`%s: %v`, path, err)
+	}
+	return &o, nil
+}
+
+// apply patches source and formats it, patch matching unexpected number of times fails, so overlay is updated together
+// with generator output
+func (o *overlay) apply(source []byte) ([]byte, error) {
+	for i, p := range o.Patches {
+		re, err := regexp.Compile(p.Match)
+		if err != nil {
+			return nil, fmt.Errorf(`%s %d: %v`, constants.InvalidPatch, i+1, err)
+		}
+		n := len(re.FindAllIndex(source, -1))
+		if n == 0 || (p.Count > 0 && n != p.Count) {
+			return nil, fmt.Errorf(`%s %d: %s: matched %d times`, constants.InvalidPatch, i+1, p.Reason, n)
+		}
+		source = re.ReplaceAll(source, []byte(p.Replacement))
+	}
+	return format.Source(source)
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/rulesng/coinmetrics-go-sdk/openapi"
+)
+
+// syncOperation is paginated operation of spec with Go types of the client
+type syncOperation struct {
+	Name         string
+	OperationID  string
+	Summary      string
+	PathParams   []syncParam
+	ParamsType   string
+	ResponseType string
+	// PageSizeType is empty when operation does not accept page size
+	PageSizeType string
+	// DataType is type of records, empty when data is not decoded as slice
+	DataType       string
+	HasNextPageURL bool
+	// Note is added to doc comment of wrapper
+	Note string
+}
+
+// syncNotes are notes of wrappers which replaced hand written ones with different signature
+var syncNotes = map[string]string{
+	`getTimeseriesInstitutionMetrics`: `Breaking change: it returned ([]interface{}, error) with data of every page before, records of all pages are in JSON200.Data now.`,
+}
+
+type syncParam struct {
+	Name string
+	Type string
+}
+
+var syncTemplate = template.Must(template.New(`sync`).Parse(`// Code generated by apigen sync. DO NOT EDIT.
+
+package coinmetrics
+
+import (
+	"context"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+)
+{{range .Skipped}}
+// {{.}}{{end}}
+{{range .Operations}}
+// {{.Name}}WithResponseSync To get all pages of {{.Summary}}
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/{{.OperationID}}
+// Returning: api.{{.ResponseType}}, error{{with .Note}}
+//
+// {{.}}{{end}}
+func (c CoinMetrics) {{.Name}}WithResponseSync(ctx context.Context, {{range .PathParams}}{{.Name}} {{.Type}}, {{end}}params *api.{{.ParamsType}}, reqEditors ...api.RequestEditorFn) (api.{{.ResponseType}}, error) {
+	page := *params
+	data := {{if .DataType}}[]{{.DataType}}{}{{else}}[]interface{}{}{{end}}
+	for {
+{{- if .PageSizeType}}
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := {{.PageSizeType}}(size)
+			page.PageSize = &pageSize
+		}
+{{- end}}
+		res, err := c.{{.Name}}WithResponse(ctx, {{range .PathParams}}{{.Name}}, {{end}}&page, reqEditors...)
+		if err != nil {
+			return api.{{.ResponseType}}{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+{{- if .DataType}}
+		records := res.JSON200.Data
+{{- else}}
+		records, err := pageRecords(res.JSON200.Data)
+		if err != nil {
+			return api.{{.ResponseType}}{}, err
+		}
+{{- end}}
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+{{- if .HasNextPageURL}}
+			res.JSON200.NextPageUrl = nil
+{{- end}}
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+{{end}}`))
+
+func syncCommand(args []string, stderr io.Writer) error {
+	fs := flag.NewFlagSet(`apigen sync`, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	output := fs.String(`o`, `sync.gen.go`, `output file`)
+	clientPath := fs.String(`client`, `../api/v4/v4.go`, `generated client of spec`)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	spec, err := openapi.V4()
+	if err != nil {
+		return err
+	}
+	client, err := ioutil.ReadFile(*clientPath)
+	if err != nil {
+		return err
+	}
+	source, err := generateSync(spec, client)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(*output, source, 0o644)
+}
+
+// generateSync generates WithResponseSync wrapper of every operation responding with `next_page_token`, Go types are
+// read from source of the client, so apigen does not import the client it generates
+func generateSync(spec *openapi.Spec, clientSource []byte) ([]byte, error) {
+	client, err := parseClient(clientSource)
+	if err != nil {
+		return nil, err
+	}
+	var operations []syncOperation
+	var skipped []string
+	for _, route := range spec.Routes() {
+		schema, _, ok := route.JSONResponse(spec, http.StatusOK)
+		if !ok {
+			continue
+		}
+		if _, paginated := schema.Properties[`next_page_token`]; !paginated {
+			continue
+		}
+		operation, reason := client.syncOperation(route)
+		if reason != `` {
+			skipped = append(skipped, fmt.Sprintf(`%s is skipped: %s`, route.Operation.OperationID, reason))
+			continue
+		}
+		operations = append(operations, operation)
+	}
+	var b bytes.Buffer
+	err = syncTemplate.Execute(&b, map[string]interface{}{`Operations`: operations, `Skipped`: skipped})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(b.Bytes())
+}
+
+// clientSource is declarations of the client package
+type clientSource struct {
+	// methods of ClientWithResponses by name
+	methods map[string]*ast.FuncType
+	types   map[string]ast.Expr
+}
+
+func parseClient(source []byte) (*clientSource, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), `v4.go`, source, 0)
+	if err != nil {
+		return nil, err
+	}
+	c := clientSource{methods: map[string]*ast.FuncType{}, types: map[string]ast.Expr{}}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil || len(decl.Recv.List) != 1 {
+				continue
+			}
+			if star, ok := decl.Recv.List[0].Type.(*ast.StarExpr); ok && isIdent(star.X, `ClientWithResponses`) {
+				c.methods[decl.Name.Name] = decl.Type
+			}
+		case *ast.GenDecl:
+			for _, s := range decl.Specs {
+				if s, ok := s.(*ast.TypeSpec); ok {
+					c.types[s.Name.Name] = s.Type
+				}
+			}
+		}
+	}
+	return &c, nil
+}
+
+// syncOperation reads Go types of operation from the client, reason is not empty when wrapper can not be generated
+func (c *clientSource) syncOperation(route openapi.Route) (syncOperation, string) {
+	id := route.Operation.OperationID
+	operation := syncOperation{
+		Name:        strings.ToUpper(id[:1]) + id[1:],
+		OperationID: id,
+		Summary:     strings.ToLower(strings.TrimSuffix(route.Operation.Summary, `.`)),
+		Note:        syncNotes[id],
+	}
+	method, ok := c.methods[operation.Name+`WithResponse`]
+	if !ok {
+		return operation, `no client method`
+	}
+	// context, path params, params and request editors
+	var in []ast.Expr
+	for _, field := range method.Params.List {
+		for range field.Names {
+			in = append(in, field.Type)
+		}
+	}
+	names := route.PathParams()
+	if len(in) != len(names)+3 || c.typeName(in[0]) != `context.Context` || method.Results.NumFields() != 2 {
+		return operation, fmt.Sprintf(`unexpected signature of %sWithResponse`, operation.Name)
+	}
+	for i, name := range names {
+		operation.PathParams = append(operation.PathParams, syncParam{Name: lowerCamel(name), Type: c.typeName(in[i+1])})
+	}
+	params, ok := pointerTo(in[len(names)+1])
+	if !ok {
+		return operation, fmt.Sprintf(`unexpected params %s`, c.typeName(in[len(names)+1]))
+	}
+	operation.ParamsType = params
+	if field, ok := c.field(params, `PageSize`); ok {
+		if star, ok := field.(*ast.StarExpr); ok {
+			operation.PageSizeType = c.typeName(star.X)
+		}
+	}
+	nextPageToken, ok := c.field(params, `NextPageToken`)
+	if _, pointer := nextPageToken.(*ast.StarExpr); !ok || !pointer {
+		return operation, `params without NextPageToken`
+	}
+
+	response, ok := pointerTo(method.Results.List[0].Type)
+	if !ok {
+		return operation, `unexpected response`
+	}
+	operation.ResponseType = response
+	json200, ok := c.field(response, `JSON200`)
+	if !ok {
+		return operation, `response without JSON200`
+	}
+	body, ok := pointerTo(json200)
+	if !ok {
+		return operation, `response without JSON200`
+	}
+	if _, ok := c.field(body, `NextPageToken`); !ok {
+		return operation, `response without NextPageToken`
+	}
+	_, operation.HasNextPageURL = c.field(body, `NextPageUrl`)
+	data, ok := c.field(body, `Data`)
+	if !ok {
+		return operation, `response without Data`
+	}
+	switch underlying := c.underlying(data).(type) {
+	case *ast.ArrayType:
+		if underlying.Len != nil {
+			return operation, fmt.Sprintf(`unexpected data %s`, c.typeName(data))
+		}
+		operation.DataType = c.typeName(underlying.Elt)
+	case *ast.InterfaceType:
+	default:
+		return operation, fmt.Sprintf(`unexpected data %s`, c.typeName(data))
+	}
+	return operation, ``
+}
+
+// underlying follows named types of the client to their definition
+func (c *clientSource) underlying(expr ast.Expr) ast.Expr {
+	for i := 0; i < len(c.types); i++ {
+		ident, ok := expr.(*ast.Ident)
+		if !ok {
+			break
+		}
+		definition, ok := c.types[ident.Name]
+		if !ok {
+			break
+		}
+		expr = definition
+	}
+	return expr
+}
+
+// field finds type of field of named struct, fields of embedded structs included
+func (c *clientSource) field(typeName, name string) (ast.Expr, bool) {
+	s, ok := c.underlying(ast.NewIdent(typeName)).(*ast.StructType)
+	if !ok {
+		return nil, false
+	}
+	for _, field := range s.Fields.List {
+		for _, n := range field.Names {
+			if n.Name == name {
+				return field.Type, true
+			}
+		}
+	}
+	for _, field := range s.Fields.List {
+		if len(field.Names) != 0 {
+			continue
+		}
+		embedded := field.Type
+		if star, ok := embedded.(*ast.StarExpr); ok {
+			embedded = star.X
+		}
+		if ident, ok := embedded.(*ast.Ident); ok {
+			if t, ok := c.field(ident.Name, name); ok {
+				return t, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// typeName returns name of type in package coinmetrics
+func (c *clientSource) typeName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if _, ok := c.types[expr.Name]; ok {
+			return `api.` + expr.Name
+		}
+		return expr.Name
+	case *ast.StarExpr:
+		return `*` + c.typeName(expr.X)
+	case *ast.ArrayType:
+		if expr.Len == nil {
+			return `[]` + c.typeName(expr.Elt)
+		}
+	case *ast.MapType:
+		return `map[` + c.typeName(expr.Key) + `]` + c.typeName(expr.Value)
+	case *ast.InterfaceType:
+		if expr.Methods.NumFields() == 0 {
+			return `interface{}`
+		}
+	}
+	var b bytes.Buffer
+	_ = printer.Fprint(&b, token.NewFileSet(), expr)
+	return b.String()
+}
+
+// pointerTo returns name of type pointed to by expr like `*Type`
+func pointerTo(expr ast.Expr) (string, bool) {
+	star, ok := expr.(*ast.StarExpr)
+	if !ok {
+		return ``, false
+	}
+	ident, ok := star.X.(*ast.Ident)
+	if !ok {
+		return ``, false
+	}
+	return ident.Name, true
+}
+
+func isIdent(expr ast.Expr, name string) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == name
+}
+
+// lowerCamel converts names like `block_hash` to `blockHash`
+func lowerCamel(name string) string {
+	parts := strings.Split(name, `_`)
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != `` {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, ``)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+)
+
+// command maps subcommand to operation of generated client, flags are taken from fields of params
+// and args are positional path params
+type command struct {
+	group     string
+	name      string
+	operation string
+	params    interface{}
+	args      []string
+}
+
+var commands = []command{
+	{group: `catalog`, name: `assets`, operation: `GetCatalogAssets`, params: api.GetCatalogAssetsParams{}},
+	{group: `catalog`, name: `asset-pairs`, operation: `GetCatalogAssetPairs`, params: api.GetCatalogAssetPairsParams{}},
+	{group: `catalog`, name: `metrics`, operation: `GetCatalogMetrics`, params: api.GetCatalogMetricsParams{}},
+	{group: `catalog`, name: `exchanges`, operation: `GetCatalogExchanges`, params: api.GetCatalogExchangesParams{}},
+	{group: `catalog`, name: `exchange-assets`, operation: `GetCatalogExchangeAssets`, params: api.GetCatalogExchangeAssetsParams{}},
+	{group: `catalog`, name: `indexes`, operation: `GetCatalogIndexes`, params: api.GetCatalogIndexesParams{}},
+	{group: `catalog`, name: `institutions`, operation: `GetCatalogInstitutions`, params: api.GetCatalogInstitutionsParams{}},
+	{group: `catalog`, name: `markets`, operation: `GetCatalogMarkets`, params: api.GetCatalogMarketsParams{}},
+	{group: `catalog`, name: `market-metrics`, operation: `GetCatalogMarketMetrics`, params: api.GetCatalogMarketMetricsParams{}},
+	{group: `catalog`, name: `market-candles`, operation: `GetCatalogMarketCandles`, params: api.GetCatalogMarketCandlesParams{}},
+	{group: `catalog`, name: `alerts`, operation: `GetCatalogAssetAlertRules`, params: api.GetCatalogAssetAlertRulesParams{}},
+
+	{group: `catalog-all`, name: `assets`, operation: `GetCatalogAllAssets`, params: api.GetCatalogAllAssetsParams{}},
+	{group: `catalog-all`, name: `asset-pairs`, operation: `GetCatalogAllAssetPairs`, params: api.GetCatalogAllAssetPairsParams{}},
+	{group: `catalog-all`, name: `metrics`, operation: `GetCatalogAllMetrics`, params: api.GetCatalogAllMetricsParams{}},
+	{group: `catalog-all`, name: `exchanges`, operation: `GetCatalogAllExchanges`, params: api.GetCatalogAllExchangesParams{}},
+	{group: `catalog-all`, name: `exchange-assets`, operation: `GetCatalogAllExchangeAssets`, params: api.GetCatalogAllExchangeAssetsParams{}},
+	{group: `catalog-all`, name: `indexes`, operation: `GetCatalogAllIndexes`, params: api.GetCatalogAllIndexesParams{}},
+	{group: `catalog-all`, name: `institutions`, operation: `GetCatalogAllInstitutions`, params: api.GetCatalogAllInstitutionsParams{}},
+	{group: `catalog-all`, name: `markets`, operation: `GetCatalogAllMarkets`, params: api.GetCatalogAllMarketsParams{}},
+	{group: `catalog-all`, name: `market-metrics`, operation: `GetCatalogAllMarketMetrics`, params: api.GetCatalogAllMarketMetricsParams{}},
+	{group: `catalog-all`, name: `market-candles`, operation: `GetCatalogAllMarketCandles`, params: api.GetCatalogAllMarketCandlesParams{}},
+	{group: `catalog-all`, name: `alerts`, operation: `GetCatalogAllAssetAlertRules`, params: api.GetCatalogAllAssetAlertRulesParams{}},
+
+	{group: `timeseries`, name: `asset-metrics`, operation: `GetTimeseriesAssetMetrics`, params: api.GetTimeseriesAssetMetricsParams{}},
+	{group: `timeseries`, name: `exchange-metrics`, operation: `GetTimeseriesExchangeMetrics`, params: api.GetTimeseriesExchangeMetricsParams{}},
+	{group: `timeseries`, name: `exchange-asset-metrics`, operation: `GetTimeseriesExchangeAssetMetrics`, params: api.GetTimeseriesExchangeAssetMetricsParams{}},
+	{group: `timeseries`, name: `pair-metrics`, operation: `GetTimeseriesPairMetrics`, params: api.GetTimeseriesPairMetricsParams{}},
+	{group: `timeseries`, name: `institution-metrics`, operation: `GetTimeseriesInstitutionMetrics`, params: api.GetTimeseriesInstitutionMetricsParams{}},
+	{group: `timeseries`, name: `index-levels`, operation: `GetTimeseriesIndexLevels`, params: api.GetTimeseriesIndexLevelsParams{}},
+	{group: `timeseries`, name: `index-constituents`, operation: `GetTimeseriesIndexConstituents`, params: api.GetTimeseriesIndexConstituentsParams{}},
+	{group: `timeseries`, name: `market-trades`, operation: `GetTimeseriesMarketTrades`, params: api.GetTimeseriesMarketTradesParams{}},
+	{group: `timeseries`, name: `market-candles`, operation: `GetTimeseriesMarketCandles`, params: api.GetTimeseriesMarketCandlesParams{}},
+	{group: `timeseries`, name: `market-quotes`, operation: `GetTimeseriesMarketQuotes`, params: api.GetTimeseriesMarketQuotesParams{}},
+	{group: `timeseries`, name: `market-orderbooks`, operation: `GetTimeseriesMarketOrderbooks`, params: api.GetTimeseriesMarketOrderbooksParams{}},
+	{group: `timeseries`, name: `market-funding-rates`, operation: `GetTimeseriesMarketFundingRates`, params: api.GetTimeseriesMarketFundingRatesParams{}},
+	{group: `timeseries`, name: `market-openinterest`, operation: `GetTimeseriesMarketOpenIntereset`, params: api.GetTimeseriesMarketOpenInteresetParams{}},
+	{group: `timeseries`, name: `market-liquidations`, operation: `GetTimeseriesMarketLiquidations`, params: api.GetTimeseriesMarketLiquidationsParams{}},
+	{group: `timeseries`, name: `market-metrics`, operation: `GetTimeseriesMarketMetrics`, params: api.GetTimeseriesMarketMetricsParams{}},
+	{group: `timeseries`, name: `market-greeks`, operation: `GetTimeseriesMarketGreeks`, params: api.GetTimeseriesMarketGreeksParams{}},
+	{group: `timeseries`, name: `market-implied-volatility`, operation: `GetTimeseriesMarketImpliedVolatility`, params: api.GetTimeseriesMarketImpliedVolatilityParams{}},
+	{group: `timeseries`, name: `market-contract-prices`, operation: `GetTimeseriesMarketContractPrices`, params: api.GetTimeseriesMarketContractPricesParams{}},
+	{group: `timeseries`, name: `mempool-feerates`, operation: `GetMempoolFeerates`, params: api.GetMempoolFeeratesParams{}},
+	{group: `timeseries`, name: `mining-pool-tips-summary`, operation: `GetTimeseriesMiningPoolTipsSummary`, params: api.GetTimeseriesMiningPoolTipsSummaryParams{}},
+	{group: `timeseries`, name: `asset-alerts`, operation: `GetAssetAlerts`, params: api.GetAssetAlertsParams{}},
+	{group: `timeseries`, name: `asset-chains`, operation: `GetAssetChains`, params: api.GetAssetChainsParams{}},
+
+	{group: `blockchain`, name: `blocks`, operation: `GetBlockchainV2ListOfBlocks`, params: api.GetBlockchainV2ListOfBlocksParams{}, args: []string{`asset`}},
+	{group: `blockchain`, name: `block`, operation: `GetBlockchainV2FullBlock`, params: api.GetBlockchainV2FullBlockParams{}, args: []string{`asset`, `block_hash`}},
+	{group: `blockchain`, name: `transactions`, operation: `GetBlockchainV2ListOfTransactions`, params: api.GetBlockchainV2ListOfTransactionsParams{}, args: []string{`asset`}},
+	{group: `blockchain`, name: `transaction`, operation: `GetBlockchainV2FullTransaction`, params: api.GetBlockchainV2FullTransactionParams{}, args: []string{`asset`, `txid`}},
+	{group: `blockchain`, name: `balance-updates`, operation: `GetBlockchainV2ListOfBalanceUpdates`, params: api.GetBlockchainV2ListOfBalanceUpdatesParams{}, args: []string{`asset`}},
+	{group: `blockchain`, name: `accounts`, operation: `GetBlockchainV2ListOfAccounts`, params: api.GetBlockchainV2ListOfAccountsParams{}, args: []string{`asset`}},
+	{group: `blockchain`, name: `sub-accounts`, operation: `GetBlockchainV2ListOfSubAccounts`, params: api.GetBlockchainV2ListOfSubAccountsParams{}, args: []string{`asset`}},
+	{group: `blockchain`, name: `transaction-tracker`, operation: `GetTransactionTracker`, params: api.GetTransactionTrackerParams{}, args: []string{`asset`}},
+
+	{group: `blockchain-v1`, name: `blocks`, operation: `GetBlockchainListOfBlocks`, params: api.GetBlockchainListOfBlocksParams{}, args: []string{`asset`}},
+	{group: `blockchain-v1`, name: `block`, operation: `GetBlockchainFullBlock`, params: api.GetBlockchainFullBlockParams{}, args: []string{`asset`, `block_hash`}},
+	{group: `blockchain-v1`, name: `transactions`, operation: `GetBlockchainListOfTransactions`, params: api.GetBlockchainListOfTransactionsParams{}, args: []string{`asset`}},
+	{group: `blockchain-v1`, name: `transaction`, operation: `GetBlockchainFullTransaction`, params: api.GetBlockchainFullTransactionParams{}, args: []string{`asset`, `transaction_hash`}},
+	{group: `blockchain-v1`, name: `balance-updates`, operation: `GetBlockchainListOfBalanceUpdates`, params: api.GetBlockchainListOfBalanceUpdatesParams{}, args: []string{`asset`}},
+	{group: `blockchain-v1`, name: `accounts`, operation: `GetBlockchainListOfAccounts`, params: api.GetBlockchainListOfAccountsParams{}, args: []string{`asset`}},
+}
+
+// findCommand returns command of group and name
+func findCommand(group, name string) (command, bool) {
+	for _, c := range commands {
+		if c.group == group && c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// groups returns command groups in order of commands
+func groups() []string {
+	var result []string
+	seen := map[string]bool{}
+	for _, c := range commands {
+		if !seen[c.group] {
+			seen[c.group] = true
+			result = append(result, c.group)
+		}
+	}
+	return result
+}
@@ -0,0 +1,647 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/catalog"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// kinds of catalog items browsed by explorer, in order of tabs
+var kinds = []string{assetKind, exchangeKind, marketKind, metricKind}
+
+// Kinds of catalog items browsed by explorer
+const (
+	assetKind    = `asset`
+	exchangeKind = `exchange`
+	marketKind   = `market`
+	metricKind   = `metric`
+)
+
+// frequencies are offered for generated request when selected metrics do not list any
+var frequencies = []string{`1b`, `1m`, `1h`, `1d`}
+
+const exploreKeys = `tab/←→ kind  ↑↓ pgup/pgdn move  enter select  type to filter  ^U clear filter  ^F frequency  ^G go  ^E cli  ^X clear selection  esc close  ^C quit`
+
+type item struct {
+	kind string
+	id   string
+}
+
+// entry is listed item with short description
+type entry struct {
+	item
+	description string
+}
+
+// explorer is state of interactive catalog browser, keys change it and render draws it, see terminal.go
+type explorer struct {
+	snapshot  *catalog.Snapshot
+	kind      int
+	filters   map[string]string
+	entries   []entry
+	cursor    int
+	offset    int
+	selected  []item
+	frequency string
+	// output is generated snippet shown instead of details until it is closed
+	output  []string
+	message string
+}
+
+func newExplorer(snapshot *catalog.Snapshot, frequency string) *explorer {
+	e := explorer{snapshot: snapshot, filters: map[string]string{}, frequency: frequency}
+	e.refresh()
+	return &e
+}
+
+// explore loads catalog snapshot and runs explorer in terminal, generated snippet shown at exit is printed to stdout
+func explore(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet(`cmcli explore`, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	newClient := clientFlags(fs)
+	snapshotPath := fs.String(`snapshot`, defaultSnapshotPath(), `catalog snapshot file, empty disables it`)
+	maxAge := fs.Duration(`max-age`, 24*time.Hour, `reload catalog when snapshot is older`)
+	frequency := fs.String(`frequency`, constants.DefaultFrequency, `frequency of generated request`)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return fmt.Errorf(`%s: explore needs interactive terminal`, constants.NotTerminal)
+	}
+
+	snapshot, err := catalog.ReadSnapshot(*snapshotPath)
+	if err != nil || snapshot.Age() > *maxAge {
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stderr, `loading catalog...`)
+		if snapshot, err = catalog.Load(ctx, client); err != nil {
+			return err
+		}
+		if *snapshotPath != `` {
+			if err := os.MkdirAll(filepath.Dir(*snapshotPath), 0o755); err == nil {
+				_ = snapshot.Save(*snapshotPath)
+			}
+		}
+	}
+	e := newExplorer(snapshot, *frequency)
+	if err := runTerminal(os.Stdin, os.Stdout, e); err != nil {
+		return err
+	}
+	for _, line := range e.output {
+		fmt.Fprintln(stdout, line)
+	}
+	return nil
+}
+
+func defaultSnapshotPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ``
+	}
+	return filepath.Join(dir, `cmcli`, `catalog.json`)
+}
+
+// handle applies key to state, it returns true when explorer should quit
+func (e *explorer) handle(k key) bool {
+	e.message = ``
+	filter := e.filters[kinds[e.kind]]
+	switch k.code {
+	case keyCtrlC, keyCtrlQ:
+		return true
+	case keyTab, keyRight:
+		e.switchKind(1)
+	case keyBackTab, keyLeft:
+		e.switchKind(len(kinds) - 1)
+	case keyUp:
+		e.move(-1)
+	case keyDown:
+		e.move(1)
+	case keyPgUp:
+		e.move(-pageMove)
+	case keyPgDn:
+		e.move(pageMove)
+	case keyHome:
+		e.move(-len(e.entries))
+	case keyEnd:
+		e.move(len(e.entries))
+	case keyEnter:
+		e.toggle()
+	case keyEsc:
+		e.output = nil
+	case keyBackspace:
+		if runes := []rune(filter); len(runes) > 0 {
+			e.setFilter(string(runes[:len(runes)-1]))
+		}
+	case keyCtrlU:
+		e.setFilter(``)
+	case keyCtrlX:
+		e.selected = nil
+	case keyCtrlF:
+		e.nextFrequency()
+	case keyCtrlG, keyCtrlE:
+		name, values, err := e.request()
+		if err != nil {
+			e.message = err.Error()
+			break
+		}
+		if k.code == keyCtrlG {
+			e.output = strings.Split(goSnippet(name, values), "\n")
+		} else {
+			e.output = []string{cliCommand(name, values)}
+		}
+	case keyRune:
+		e.setFilter(filter + string(k.r))
+	}
+	return false
+}
+
+// pageMove is number of rows moved by page keys
+const pageMove = 10
+
+func (e *explorer) switchKind(step int) {
+	e.kind = (e.kind + step) % len(kinds)
+	e.cursor, e.offset = 0, 0
+	e.refresh()
+}
+
+func (e *explorer) setFilter(filter string) {
+	e.filters[kinds[e.kind]] = filter
+	e.cursor, e.offset = 0, 0
+	e.refresh()
+}
+
+func (e *explorer) move(step int) {
+	e.cursor += step
+	if e.cursor >= len(e.entries) {
+		e.cursor = len(e.entries) - 1
+	}
+	if e.cursor < 0 {
+		e.cursor = 0
+	}
+}
+
+// current returns item under cursor
+func (e *explorer) current() (item, bool) {
+	if e.cursor >= len(e.entries) {
+		return item{}, false
+	}
+	return e.entries[e.cursor].item, true
+}
+
+// toggle selects or unselects item under cursor
+func (e *explorer) toggle() {
+	it, ok := e.current()
+	if !ok {
+		return
+	}
+	if e.isSelected(it) {
+		e.unselect(it)
+	} else {
+		e.selected = append(e.selected, it)
+	}
+}
+
+func (e *explorer) isSelected(it item) bool {
+	for _, s := range e.selected {
+		if s == it {
+			return true
+		}
+	}
+	return false
+}
+
+// nextFrequency cycles frequency of generated request through frequencies of selected metrics
+func (e *explorer) nextFrequency() {
+	var available []string
+	for _, info := range e.snapshot.Metrics {
+		if !e.isSelected(item{kind: metricKind, id: string(info.Metric)}) {
+			continue
+		}
+		for _, frequency := range info.Frequencies {
+			if !contains(available, frequency.Frequency) {
+				available = append(available, frequency.Frequency)
+			}
+		}
+	}
+	if len(available) == 0 {
+		available = frequencies
+	}
+	next := available[0]
+	for i, frequency := range available {
+		if frequency == e.frequency && i+1 < len(available) {
+			next = available[i+1]
+		}
+	}
+	e.frequency = next
+}
+
+// refresh lists items of current kind matching its filter
+func (e *explorer) refresh() {
+	kind := kinds[e.kind]
+	args := strings.Fields(e.filters[kind])
+	switch kind {
+	case assetKind:
+		e.entries = e.assets(args)
+	case exchangeKind:
+		e.entries = e.exchanges(args)
+	case marketKind:
+		e.entries = e.markets(args)
+	case metricKind:
+		e.entries = e.metrics(args)
+	}
+	e.move(0)
+}
+
+func (e *explorer) assets(args []string) []entry {
+	var entries []entry
+	for _, info := range catalog.QueryAssets(e.snapshot).Search(strings.Join(args, ` `)).List() {
+		entries = append(entries, entry{item{assetKind, string(info.Asset)}, string(info.FullName)})
+	}
+	return entries
+}
+
+func (e *explorer) exchanges(args []string) []entry {
+	text := strings.ToLower(strings.Join(args, ` `))
+	var entries []entry
+	for _, info := range e.snapshot.Exchanges {
+		if strings.Contains(string(info.Exchange), text) {
+			entries = append(entries, entry{item{exchangeKind, string(info.Exchange)}, fmt.Sprintf(`%d markets`, len(info.Markets))})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].id < entries[j].id
+	})
+	return entries
+}
+
+// markets are filtered by text and filters exchange=, base=, quote= and type=
+func (e *explorer) markets(args []string) []entry {
+	q := catalog.QueryMarkets(e.snapshot)
+	for _, arg := range args {
+		key, value, filter := keyValue(arg)
+		switch {
+		case !filter:
+			text := strings.ToLower(arg)
+			q.Where(func(info api.MarketInfo) bool { return strings.Contains(strings.ToLower(string(info.Market)), text) })
+		case key == `exchange`:
+			q.Exchange(value)
+		case key == `base`:
+			q.Base(value)
+		case key == `quote`:
+			q.Quote(value)
+		case key == `type`:
+			q.Type(api.MarketType(value))
+		}
+	}
+	var entries []entry
+	for _, info := range q.List() {
+		entries = append(entries, entry{item{marketKind, string(info.Market)}, string(info.Type)})
+	}
+	return entries
+}
+
+// metrics are filtered by text and filters category=, frequency= and asset=
+func (e *explorer) metrics(args []string) []entry {
+	q := catalog.QueryMetrics(e.snapshot)
+	var text []string
+	for _, arg := range args {
+		key, value, filter := keyValue(arg)
+		switch {
+		case !filter:
+			text = append(text, arg)
+		case key == `category`:
+			q.Category(value)
+		case key == `frequency`:
+			q.Frequency(value)
+		case key == `asset`:
+			q.Asset(value)
+		}
+	}
+	var entries []entry
+	for _, info := range q.Search(strings.Join(text, ` `)).List() {
+		entries = append(entries, entry{item{metricKind, string(info.Metric)}, string(info.FullName)})
+	}
+	return entries
+}
+
+func (e *explorer) unselect(it item) {
+	selected := e.selected[:0]
+	for _, s := range e.selected {
+		if s != it {
+			selected = append(selected, s)
+		}
+	}
+	e.selected = selected
+}
+
+// selection returns selected ids by kind, like `metrics: PriceUSD,CapMrktCurUSD`
+func (e *explorer) selection() []string {
+	var lines []string
+	for _, kind := range kinds {
+		if ids := e.selectedIds(kind); len(ids) > 0 {
+			lines = append(lines, fmt.Sprintf(`%ss: %s`, kind, strings.Join(ids, `,`)))
+		}
+	}
+	return lines
+}
+
+func (e *explorer) selectedIds(kind string) []string {
+	var ids []string
+	for _, it := range e.selected {
+		if it.kind == kind {
+			ids = append(ids, it.id)
+		}
+	}
+	return ids
+}
+
+// render returns rows of screen of width and height
+func (e *explorer) render(width, height int) []row {
+	rows := []row{{style: styleHeader, text: e.tabs()}}
+	rows = append(rows, row{text: fmt.Sprintf(`%s filter: %s_`, kinds[e.kind], e.filters[kinds[e.kind]])})
+
+	// list takes half of rows left by header, separators and footer
+	listHeight := (height - 5) / 2
+	if listHeight < 1 {
+		listHeight = 1
+	}
+	if e.cursor < e.offset {
+		e.offset = e.cursor
+	}
+	if e.cursor >= e.offset+listHeight {
+		e.offset = e.cursor - listHeight + 1
+	}
+	for i := e.offset; i < e.offset+listHeight; i++ {
+		if i >= len(e.entries) {
+			rows = append(rows, row{})
+			continue
+		}
+		mark := ` `
+		if e.isSelected(e.entries[i].item) {
+			mark = `*`
+		}
+		r := row{text: fmt.Sprintf(`%s %-40s %s`, mark, e.entries[i].id, e.entries[i].description)}
+		if i == e.cursor {
+			r.style = styleCursor
+		}
+		rows = append(rows, r)
+	}
+
+	title := fmt.Sprintf(`── %d/%d %ss `, min(e.cursor+1, len(e.entries)), len(e.entries), kinds[e.kind])
+	details := e.output
+	if details != nil {
+		title = `── generated, esc closes `
+	} else if it, ok := e.current(); ok {
+		var b bytes.Buffer
+		e.show(&b, it)
+		details = strings.Split(strings.TrimRight(b.String(), "\n"), "\n")
+	}
+	rows = append(rows, row{style: styleHeader, text: title + strings.Repeat(`─`, max(width-runeCount(title), 0))})
+	detailsHeight := height - len(rows) - 2
+	for i := 0; i < detailsHeight; i++ {
+		text := ``
+		if i < len(details) {
+			text = details[i]
+		}
+		rows = append(rows, row{text: text})
+	}
+
+	status := fmt.Sprintf(`frequency %s`, e.frequency)
+	if selection := e.selection(); len(selection) > 0 {
+		status += `  ` + strings.Join(selection, `  `)
+	}
+	if e.message != `` {
+		status = e.message
+	}
+	rows = append(rows, row{style: styleStatus, text: status}, row{text: exploreKeys})
+	for i := range rows {
+		rows[i].text = truncate(rows[i].text, width)
+	}
+	return rows
+}
+
+// tabs renders kinds with current one in brackets
+func (e *explorer) tabs() string {
+	var tabs []string
+	for i, kind := range kinds {
+		label := fmt.Sprintf(` %ss (%d) `, kind, e.count(kind))
+		if i == e.kind {
+			label = `[` + strings.TrimSpace(label) + `]`
+		}
+		tabs = append(tabs, label)
+	}
+	return strings.Join(tabs, ` `)
+}
+
+func (e *explorer) count(kind string) int {
+	switch kind {
+	case assetKind:
+		return len(e.snapshot.Assets)
+	case exchangeKind:
+		return len(e.snapshot.Exchanges)
+	case marketKind:
+		return len(e.snapshot.Markets)
+	}
+	return len(e.snapshot.Metrics)
+}
+
+func (e *explorer) show(w io.Writer, it item) {
+	switch it.kind {
+	case metricKind:
+		for _, info := range e.snapshot.Metrics {
+			if string(info.Metric) == it.id {
+				showMetric(w, info)
+			}
+		}
+	case assetKind:
+		for _, info := range e.snapshot.Assets {
+			if string(info.Asset) == it.id {
+				showAsset(w, info)
+			}
+		}
+	case exchangeKind:
+		for _, info := range e.snapshot.Exchanges {
+			if string(info.Exchange) == it.id {
+				fmt.Fprintf(w, "%s\n  time: %s - %s\n  markets: %d\n", info.Exchange, info.MinTime, info.MaxTime, len(info.Markets))
+				if info.Metrics != nil {
+					for _, metric := range *info.Metrics {
+						var frequencies []string
+						for _, frequency := range metric.Frequencies {
+							frequencies = append(frequencies, frequency.Frequency)
+						}
+						fmt.Fprintf(w, "  %s: %s\n", metric.Metric, strings.Join(frequencies, `, `))
+					}
+				}
+			}
+		}
+	case marketKind:
+		for _, info := range e.snapshot.Markets {
+			if string(info.Market) == it.id {
+				raw, _ := json.MarshalIndent(info, ``, `  `)
+				fmt.Fprintln(w, string(raw))
+			}
+		}
+	}
+}
+
+func showMetric(w io.Writer, info api.MetricInfo) {
+	fmt.Fprintf(w, "%s - %s\n", info.Metric, info.FullName)
+	if info.DisplayName != nil {
+		fmt.Fprintf(w, "  display name: %s\n", *info.DisplayName)
+	}
+	fmt.Fprintf(w, "  category: %s / %s\n  type: %s, unit: %s, data type: %s\n", info.Category, info.Subcategory, info.Type, info.Unit, info.DataType)
+	if info.Reviewable != nil && bool(*info.Reviewable) {
+		fmt.Fprintln(w, `  reviewable`)
+	}
+	fmt.Fprintf(w, "  %s\n  frequencies:\n", info.Description)
+	for _, frequency := range info.Frequencies {
+		assets := 0
+		if frequency.Assets != nil {
+			assets = len(*frequency.Assets)
+		}
+		fmt.Fprintf(w, "    %s: %d assets\n", frequency.Frequency, assets)
+	}
+}
+
+func showAsset(w io.Writer, info api.AssetInfo) {
+	fmt.Fprintf(w, "%s - %s\n", info.Asset, info.FullName)
+	if info.Exchanges != nil {
+		fmt.Fprintf(w, "  exchanges: %d\n", len(*info.Exchanges))
+	}
+	if info.Markets != nil {
+		fmt.Fprintf(w, "  markets: %d\n", len(*info.Markets))
+	}
+	if info.Metrics != nil {
+		fmt.Fprintln(w, `  metrics:`)
+		for _, metric := range *info.Metrics {
+			for _, frequency := range metric.Frequencies {
+				fmt.Fprintf(w, "    %-30s %-12s %s - %s\n", metric.Metric, frequency.Frequency, frequency.MinTime, frequency.MaxTime)
+			}
+		}
+	}
+}
+
+// request returns timeseries command and param values matching selection
+func (e *explorer) request() (string, map[string]string, error) {
+	assets, exchanges, markets, metrics := e.selectedIds(assetKind), e.selectedIds(exchangeKind), e.selectedIds(marketKind), e.selectedIds(metricKind)
+	values := map[string]string{`frequency`: e.frequency}
+	if len(metrics) > 0 {
+		values[`metrics`] = strings.Join(metrics, `,`)
+	}
+	switch {
+	case len(assets) > 0 && len(metrics) > 0:
+		values[`assets`] = strings.Join(assets, `,`)
+		return `asset-metrics`, values, nil
+	case len(exchanges) > 0 && len(metrics) > 0:
+		values[`exchanges`] = strings.Join(exchanges, `,`)
+		return `exchange-metrics`, values, nil
+	case len(markets) > 0 && len(metrics) > 0:
+		values[`markets`] = strings.Join(markets, `,`)
+		return `market-metrics`, values, nil
+	case len(markets) > 0:
+		values[`markets`] = strings.Join(markets, `,`)
+		return `market-candles`, values, nil
+	}
+	return ``, nil, fmt.Errorf(`select assets, exchanges or markets together with metrics, or markets for candles`)
+}
+
+// goSnippet renders params of timeseries command, field types are taken from generated params struct
+func goSnippet(name string, values map[string]string) string {
+	c, _ := findCommand(`timeseries`, name)
+	t := reflect.TypeOf(c.params)
+	var declarations, fields []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		param := strings.Split(f.Tag.Get(`json`), `,`)[0]
+		value, ok := values[param]
+		if !ok {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		var literal string
+		if ft.Kind() == reflect.Slice {
+			literal = fmt.Sprintf("api.%s{`%s`}", ft.Name(), strings.Join(strings.Split(value, `,`), "`, `"))
+		} else {
+			literal = fmt.Sprintf("api.%s(`%s`)", ft.Name(), value)
+		}
+		if f.Type.Kind() == reflect.Ptr {
+			variable := strings.ToLower(f.Name[:1]) + f.Name[1:]
+			declarations = append(declarations, fmt.Sprintf(`%s := %s`, variable, literal))
+			literal = `&` + variable
+		}
+		fields = append(fields, fmt.Sprintf("\t%s: %s,", f.Name, literal))
+	}
+	lines := append(declarations, fmt.Sprintf(`params := api.%s{`, t.Name()))
+	lines = append(lines, fields...)
+	lines = append(lines, `}`, fmt.Sprintf(`res, err := client.%sWithResponse(ctx, &params)`, c.operation))
+	snippet := strings.Join(lines, "\n")
+	if formatted, err := format.Source([]byte(snippet)); err == nil {
+		return string(formatted)
+	}
+	return snippet
+}
+
+// cliCommand renders cmcli command of timeseries command with params in name order
+func cliCommand(name string, values map[string]string) string {
+	params := make([]string, 0, len(values))
+	for param := range values {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+	parts := []string{`cmcli timeseries`, name}
+	for _, param := range params {
+		parts = append(parts, `-`+strings.ReplaceAll(param, `_`, `-`), values[param])
+	}
+	return strings.Join(parts, ` `)
+}
+
+// keyValue splits filter like `exchange=coinbase`
+func keyValue(arg string) (string, string, bool) {
+	parts := strings.SplitN(arg, `=`, 2)
+	if len(parts) != 2 {
+		return ``, ``, false
+	}
+	return parts[0], parts[1], true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/catalog"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSnapshot() *catalog.Snapshot {
+	assets := api.AssetsIds{`btc`, `eth`}
+	return &catalog.Snapshot{
+		Time: time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC),
+		Assets: []api.AssetInfo{
+			{Asset: `btc`, FullName: `Bitcoin`, Metrics: &api.MetricsIds{{Metric: `PriceUSD`, Frequencies: api.AssetMetricFrequencies{{Frequency: `1d`, MinTime: `2010-07-18T00:00:00.000000000Z`, MaxTime: `2022-05-01T00:00:00.000000000Z`}}}}},
+			{Asset: `eth`, FullName: `Ethereum`},
+		},
+		Metrics: []api.MetricInfo{
+			{Metric: `PriceUSD`, FullName: `Price, USD`, Category: `Market`, Subcategory: `Price`, Type: `Price`, Unit: `USD`, DataType: `decimal`,
+				Description: `The fixed closing price of the asset.`, Frequencies: api.MetricFrequencies{{Frequency: `1d`, Assets: &assets}}},
+			{Metric: `AdrActCnt`, FullName: `Addresses, active, count`, Category: `Addresses`, Subcategory: `Active`, Frequencies: api.MetricFrequencies{{Frequency: `1b`}}},
+		},
+		Markets: []api.MarketInfo{
+			{Market: `coinbase-btc-usd-spot`, Exchange: `coinbase`, Type: `spot`},
+			{Market: `binance-BTCUSDT-future`, Exchange: `binance`, Type: `future`},
+		},
+		Exchanges: []api.ExchangeInfo{{Exchange: `coinbase`, Markets: api.MarketsIds{`coinbase-btc-usd-spot`}}},
+	}
+}
+
+// typeKeys decodes input like terminal sends it and applies keys, it returns true when explorer quit
+func typeKeys(e *explorer, input string) bool {
+	for _, k := range decodeKeys([]byte(input)) {
+		if e.handle(k) {
+			return true
+		}
+	}
+	return false
+}
+
+func screen(e *explorer) string {
+	var lines []string
+	for _, r := range e.render(120, 30) {
+		lines = append(lines, r.text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestExplore(t *testing.T) {
+	e := newExplorer(testSnapshot(), `1d`)
+	text := screen(e)
+	assert.Contains(t, text, `[assets (2)]  exchanges (1)   markets (2)   metrics (2) `)
+	assert.Contains(t, text, "\nbtc - Bitcoin\n")
+
+	// metrics tab filtered while typing, details of metric under cursor are shown
+	assert.False(t, typeKeys(e, "\x1b[D"+`price`))
+	text = screen(e)
+	assert.Contains(t, text, "metric filter: price_\n")
+	assert.Contains(t, text, `  PriceUSD`)
+	assert.NotContains(t, text, `AdrActCnt`)
+	assert.Contains(t, text, "  category: Market / Price\n")
+	assert.Contains(t, text, "    1d: 2 assets\n")
+
+	// select metric and asset
+	typeKeys(e, "\r\t"+`bit`+"\r")
+	assert.Equal(t, []string{`assets: btc`, `metrics: PriceUSD`}, e.selection())
+	assert.Contains(t, screen(e), "\n* btc ")
+
+	assert.False(t, typeKeys(e, "\x07"))
+	assert.Equal(t, "frequency := api.AssetMetricsFrequency(`1d`)\n"+
+		"params := api.GetTimeseriesAssetMetricsParams{\n"+
+		"\tAssets:    api.AssetId(`btc`),\n"+
+		"\tMetrics:   api.AssetMetrics{`PriceUSD`},\n"+
+		"\tFrequency: &frequency,\n"+
+		"}\n"+
+		"res, err := client.GetTimeseriesAssetMetricsWithResponse(ctx, &params)", strings.Join(e.output, "\n"))
+	assert.Contains(t, screen(e), "generated, esc closes")
+
+	assert.False(t, typeKeys(e, "\x05"))
+	assert.Equal(t, []string{`cmcli timeseries asset-metrics -assets btc -frequency 1d -metrics PriceUSD`}, e.output)
+	typeKeys(e, "\x1b")
+	assert.Nil(t, e.output)
+	assert.True(t, typeKeys(e, "\x03"))
+}
+
+func TestExploreMarkets(t *testing.T) {
+	e := newExplorer(testSnapshot(), `1h`)
+	typeKeys(e, "\t\t"+`exchange=coinbase`)
+	text := screen(e)
+	assert.Contains(t, text, `coinbase-btc-usd-spot`)
+	assert.NotContains(t, text, `binance-BTCUSDT-future`)
+
+	// generating without selection reports error
+	typeKeys(e, "\x05")
+	assert.Contains(t, screen(e), "select assets, exchanges or markets together with metrics, or markets for candles\n")
+
+	typeKeys(e, "\r\x05")
+	assert.Equal(t, []string{`cmcli timeseries market-candles -frequency 1h -markets coinbase-btc-usd-spot`}, e.output)
+
+	// clearing filter lists all markets, cursor moves and enter unselects
+	typeKeys(e, "\x15\x1b[B")
+	if assert.Len(t, e.entries, 2) {
+		assert.Equal(t, `coinbase-btc-usd-spot`, e.entries[1].id)
+	}
+	typeKeys(e, "\x1b[A\x1b[F\r")
+	assert.Nil(t, e.selection())
+}
+
+func TestExploreFrequency(t *testing.T) {
+	e := newExplorer(testSnapshot(), `1d`)
+	typeKeys(e, "\x06")
+	assert.Equal(t, `1b`, e.frequency)
+	// frequencies of selected metrics are offered
+	typeKeys(e, "\x1b[Z"+`AdrActCnt`+"\r\x06")
+	assert.Equal(t, `1b`, e.frequency)
+	typeKeys(e, "\x15"+`PriceUSD`+"\r\x06")
+	assert.Equal(t, `1d`, e.frequency)
+	typeKeys(e, "\x06")
+	assert.Equal(t, `1b`, e.frequency)
+}
+
+func TestDecodeKeys(t *testing.T) {
+	assert.Equal(t, []key{{code: keyUp}, {code: keyPgDn}, {code: keyRune, r: 'é'}, {code: keyEsc}, {code: keyBackTab}, {code: keyBackspace}, {code: keyUnknown}},
+		decodeKeys([]byte("\x1b[A\x1b[6~é\x1b\x1b[Z\x7f\x1b[1;5A")))
+}
+
+func TestRenderFitsScreen(t *testing.T) {
+	e := newExplorer(testSnapshot(), `1d`)
+	rows := e.render(20, 8)
+	assert.Len(t, rows, 8)
+	for _, r := range rows {
+		assert.True(t, runeCount(r.text) <= 20, r.text)
+	}
+	assert.Equal(t, styleCursor, rows[2].style)
+}
@@ -0,0 +1,469 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"gopkg.in/yaml.v3"
+)
+
+// Partitions of exported files
+const (
+	Day   = `day`
+	Month = `month`
+	Year  = `year`
+	None  = `none`
+)
+
+var partitionLayouts = map[string]string{
+	Day:   `2006-01-02`,
+	Month: `2006-01`,
+	Year:  `2006`,
+	None:  ``,
+}
+
+// Job is export job spec. Every entity, like asset or market, of Endpoint is exported as separate series
+// into Output/<endpoint>/<entity>/<partition>.<format>, latest exported time of series is kept in State.
+type Job struct {
+	// Endpoint is timeseries endpoint, like `timeseries/asset-metrics` or `market-candles`
+	Endpoint     string            `yaml:"endpoint"`
+	Assets       []string          `yaml:"assets"`
+	Markets      []string          `yaml:"markets"`
+	Exchanges    []string          `yaml:"exchanges"`
+	Pairs        []string          `yaml:"pairs"`
+	Indexes      []string          `yaml:"indexes"`
+	Institutions []string          `yaml:"institutions"`
+	Metrics      []string          `yaml:"metrics"`
+	Frequency    string            `yaml:"frequency"`
+	Start        string            `yaml:"start"`
+	End          string            `yaml:"end"`
+	Params       map[string]string `yaml:"params"`
+	Output       string            `yaml:"output"`
+	// Format is csv or ndjson, default csv
+	Format string `yaml:"format"`
+	// Partition is day, month, year or none, default month
+	Partition string `yaml:"partition"`
+	// State is file of latest exported times, default Output/.cmcli-state.json
+	State string `yaml:"state"`
+}
+
+// LoadJob reads job spec from yaml file and validates it
+func LoadJob(path string) (Job, error) {
+	var job Job
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return job, err
+	}
+	if err := yaml.Unmarshal(raw, &job); err != nil {
+		return job, err
+	}
+	if job.Format == `` {
+		job.Format = CSV
+	}
+	if job.Partition == `` {
+		job.Partition = Month
+	}
+	if job.Output == `` {
+		job.Output = `.`
+	}
+	if job.State == `` {
+		job.State = filepath.Join(job.Output, `.cmcli-state.json`)
+	}
+	if _, _, err := job.command(); err != nil {
+		return job, err
+	}
+	if job.Format != CSV && job.Format != NDJSON {
+		return job, fmt.Errorf(`%s: %s %s`, constants.InvalidJob, constants.UnsupportedOutput, job.Format)
+	}
+	if _, ok := partitionLayouts[job.Partition]; !ok {
+		return job, fmt.Errorf(`%s: unknown partition %s`, constants.InvalidJob, job.Partition)
+	}
+	return job, nil
+}
+
+// command returns timeseries command of endpoint and param of exported entities
+func (job Job) command() (command, string, error) {
+	name := strings.TrimPrefix(strings.TrimPrefix(job.Endpoint, `/`), `timeseries/`)
+	c, ok := findCommand(`timeseries`, name)
+	if !ok {
+		return c, ``, fmt.Errorf(`%s: unknown endpoint %s`, constants.InvalidJob, job.Endpoint)
+	}
+	param, _ := job.entities()
+	if param == `` {
+		return c, ``, fmt.Errorf(`%s: no assets, markets, exchanges, pairs, indexes or institutions`, constants.InvalidJob)
+	}
+	return c, param, nil
+}
+
+// entities returns query param and values of exported entities
+func (job Job) entities() (string, []string) {
+	for _, e := range []struct {
+		param  string
+		values []string
+	}{
+		{`assets`, job.Assets},
+		{`markets`, job.Markets},
+		{`exchanges`, job.Exchanges},
+		{`pairs`, job.Pairs},
+		{`indexes`, job.Indexes},
+		{`institutions`, job.Institutions},
+	} {
+		if len(e.values) > 0 {
+			return e.param, e.values
+		}
+	}
+	return ``, nil
+}
+
+// ExportState is cursor of latest exported records by series
+type ExportState map[string]Cursor
+
+// Cursor is time of latest exported records of series. Series with several records at the same time, like trades or
+// liquidations, keep ids of records exported at that time too, so rerun which starts at Time skips only those.
+// Cursor without ids stands for all records at Time, it is stored as plain time.
+type Cursor struct {
+	Time string   `json:"time"`
+	IDs  []string `json:"ids,omitempty"`
+}
+
+// MarshalJSON stores cursor without ids as plain time
+func (c Cursor) MarshalJSON() ([]byte, error) {
+	if len(c.IDs) == 0 {
+		return json.Marshal(c.Time)
+	}
+	type plain Cursor
+	return json.Marshal(plain(c))
+}
+
+// UnmarshalJSON reads cursor stored as plain time or as object with ids
+func (c *Cursor) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &c.Time); err == nil {
+		c.IDs = nil
+		return nil
+	}
+	type plain Cursor
+	return json.Unmarshal(b, (*plain)(c))
+}
+
+// exported tells whether record at t with id was exported before, records without id are identified by time
+func (c Cursor) exported(t time.Time, id string) bool {
+	last, err := time.Parse(time.RFC3339Nano, c.Time)
+	if c.Time == `` || err != nil || t.After(last) {
+		return false
+	}
+	if t.Before(last) || id == `` {
+		return true
+	}
+	for _, exported := range c.IDs {
+		if exported == id {
+			return true
+		}
+	}
+	return false
+}
+
+// advance moves cursor to record at t with id, records are expected in time order
+func (c *Cursor) advance(t string, id string) {
+	if t != c.Time {
+		*c = Cursor{Time: t}
+	}
+	if id != `` {
+		c.IDs = append(c.IDs, id)
+	}
+}
+
+func loadState(path string) (ExportState, error) {
+	state := ExportState{}
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return state, json.Unmarshal(raw, &state)
+}
+
+// save writes state to temporary file first so that it is never left half written
+func (s ExportState) save(path string) error {
+	raw, err := json.MarshalIndent(s, ``, `  `)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + `.tmp`
+	if err := ioutil.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// export runs job spec, rerun fetches only records newer than those already exported
+func export(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet(`cmcli export`, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	newClient := clientFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: %s [flags] <job.yml>\n", fs.Name())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	job, err := LoadJob(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	state, err := loadState(job.State)
+	if err != nil {
+		return err
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	c, param, _ := job.command()
+	_, entities := job.entities()
+	for _, entity := range entities {
+		key := fmt.Sprintf(`%s/%s/%s`, c.name, entity, job.Frequency)
+		params, editors, err := job.params(c, param, entity, state[key].Time)
+		if err != nil {
+			return err
+		}
+		w := &partitionWriter{
+			dir:       filepath.Join(job.Output, c.name, entity),
+			format:    job.Format,
+			partition: job.Partition,
+			exported:  state[key],
+			cursor:    state[key],
+			onFlush: func(cursor Cursor) error {
+				state[key] = cursor
+				return state.save(job.State)
+			},
+		}
+		err = fetch(ctx, client, c, nil, params, editors, 0, w)
+		// records fetched before error are kept, rerun continues after them
+		if flushErr := w.Flush(); err == nil {
+			err = flushErr
+		}
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, key, err)
+		}
+		fmt.Fprintf(stdout, "%s: %d records, latest %s\n", key, w.count, state[key].Time)
+	}
+	return nil
+}
+
+// params builds params of series of entity, starting at last exported time when there is one. Records at that time
+// are fetched again, those exported before are skipped by partitionWriter.
+func (job Job) params(c command, param, entity, last string) (reflect.Value, []api.RequestEditorFn, error) {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	params := reflect.New(reflect.TypeOf(c.params))
+	bound := bindParams(fs, params)
+	values := map[string]string{}
+	for name, value := range job.Params {
+		values[name] = value
+	}
+	values[param] = entity
+	values[`metrics`] = strings.Join(job.Metrics, `,`)
+	values[`frequency`] = job.Frequency
+	values[`start_time`] = job.Start
+	values[`end_time`] = job.End
+	values[`paging_from`] = `start`
+	if last != `` {
+		values[`start_time`] = last
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		flagName := strings.ReplaceAll(name, `_`, `-`)
+		if values[name] == `` || fs.Lookup(flagName) == nil {
+			continue
+		}
+		if err := fs.Set(flagName, values[name]); err != nil {
+			return params, nil, fmt.Errorf(`%s: %s: %w`, constants.InvalidJob, name, err)
+		}
+	}
+	editors, err := checkParams(bound)
+	return params, editors, err
+}
+
+// partitionWriter appends records of one series to partition files. Records are expected in time order, those covered
+// by cursor of previous run are skipped. Partition is written once next one starts, then onFlush is called with
+// cursor of latest written records.
+type partitionWriter struct {
+	dir       string
+	format    string
+	partition string
+	exported  Cursor
+	cursor    Cursor
+	onFlush   func(cursor Cursor) error
+
+	current string
+	buffer  []record
+	count   int
+}
+
+func (p *partitionWriter) Write(r record) error {
+	var t, id string
+	for _, f := range r {
+		switch f.key {
+		case `time`:
+			t = cell(f.value)
+		case `coin_metrics_id`:
+			id = cell(f.value)
+		}
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, t)
+	if err != nil {
+		return fmt.Errorf(`%s: record without time`, constants.InvalidJob)
+	}
+	if p.exported.exported(parsed, id) {
+		return nil
+	}
+	partition := parsed.UTC().Format(partitionLayouts[p.partition])
+	if partition != p.current && len(p.buffer) > 0 {
+		if err := p.Flush(); err != nil {
+			return err
+		}
+	}
+	p.current = partition
+	p.buffer = append(p.buffer, r)
+	p.cursor.advance(t, id)
+	return nil
+}
+
+// Flush appends buffered records to file of current partition
+func (p *partitionWriter) Flush() error {
+	if len(p.buffer) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(p.dir, 0o755); err != nil {
+		return err
+	}
+	name := p.current
+	if name == `` {
+		name = filepath.Base(p.dir)
+	}
+	path := filepath.Join(p.dir, name+`.`+p.format)
+	var err error
+	if p.format == NDJSON {
+		err = appendNDJSON(path, p.buffer)
+	} else {
+		err = appendCSV(path, p.buffer)
+	}
+	if err != nil {
+		return err
+	}
+	p.count += len(p.buffer)
+	p.buffer = nil
+	return p.onFlush(p.cursor)
+}
+
+func appendNDJSON(path string, records []record) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	out := &ndjsonWriter{w: w}
+	for _, r := range records {
+		if err := out.Write(r); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// appendCSV appends records to csv file, file is rewritten with extended header when records have new fields
+func appendCSV(path string, records []record) error {
+	var rows [][]string
+	if f, err := os.Open(path); err == nil {
+		rows, err = csv.NewReader(f).ReadAll()
+		f.Close()
+		if err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	var header []string
+	if len(rows) > 0 {
+		header = rows[0]
+	}
+	table := tableWriter{columns: header, seen: map[string]bool{}}
+	for _, column := range header {
+		table.seen[column] = true
+	}
+	for _, r := range records {
+		if err := table.Write(r); err != nil {
+			return err
+		}
+	}
+
+	if len(rows) > 0 && len(table.columns) == len(header) {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		w := csv.NewWriter(f)
+		if err := w.WriteAll(table.rows()[1:]); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	tmp := path + `.tmp`
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	// existing rows go before new ones and are padded to extended header
+	all := table.rows()
+	out := [][]string{all[0]}
+	if len(rows) > 0 {
+		for _, row := range rows[1:] {
+			out = append(out, append(row, make([]string, len(table.columns)-len(row))...))
+		}
+	}
+	out = append(out, all[1:]...)
+	if err := w.WriteAll(out); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
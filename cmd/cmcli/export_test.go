@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func readFile(t *testing.T, path string) string {
+	raw, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	return string(raw)
+}
+
+func TestExportIncremental(t *testing.T) {
+	defer httpmock.Reset()
+	dir := t.TempDir()
+	job := filepath.Join(dir, `job.yml`)
+	assert.Nil(t, ioutil.WriteFile(job, []byte(`
+endpoint: timeseries/asset-metrics
+assets: [btc]
+metrics: [PriceUSD, CapMrktCurUSD]
+frequency: 1d
+start: 2022-04-30
+output: `+dir+`
+`), 0o644))
+
+	var queries []string
+	httpmock.RegisterResponder(http.MethodGet, constants.TestEndpoint+constants.ApiVersion+`/timeseries/asset-metrics`, func(req *http.Request) (*http.Response, error) {
+		queries = append(queries, req.URL.RawQuery)
+		body := `{"data":[
+			{"asset":"btc","time":"2022-04-30T00:00:00.000000000Z","PriceUSD":"90"},
+			{"asset":"btc","time":"2022-05-01T00:00:00.000000000Z","PriceUSD":"100"}
+		],"next_page_token":"page2"}`
+		if req.URL.Query().Get(`next_page_token`) == `page2` {
+			body = `{"data":[{"asset":"btc","time":"2022-05-02T00:00:00.000000000Z","PriceUSD":"110"}]}`
+		}
+		if req.URL.Query().Get(`start_time`) != `2022-04-30` {
+			body = `{"data":[
+				{"asset":"btc","time":"2022-05-02T00:00:00.000000000Z","PriceUSD":"110"},
+				{"asset":"btc","time":"2022-05-03T00:00:00.000000000Z","PriceUSD":"120","CapMrktCurUSD":"2000"}
+			]}`
+		}
+		resp := httpmock.NewStringResponse(http.StatusOK, body)
+		resp.Header.Set(`Content-Type`, `application/json`)
+		return resp, nil
+	})
+
+	var stdout bytes.Buffer
+	args := []string{`export`, `-endpoint`, constants.TestEndpoint, `-api-key`, constants.TestKey, job}
+	assert.Nil(t, run(context.Background(), args, &stdout, ioutil.Discard))
+	assert.Equal(t, "asset-metrics/btc/1d: 3 records, latest 2022-05-02T00:00:00.000000000Z\n", stdout.String())
+	assert.Contains(t, queries[0], `paging_from=start`)
+	assert.Contains(t, queries[0], `start_time=2022-04-30`)
+	assert.Equal(t, "asset,time,PriceUSD\nbtc,2022-04-30T00:00:00.000000000Z,90\n", readFile(t, filepath.Join(dir, `asset-metrics`, `btc`, `2022-04.csv`)))
+	assert.Equal(t, "asset,time,PriceUSD\nbtc,2022-05-01T00:00:00.000000000Z,100\nbtc,2022-05-02T00:00:00.000000000Z,110\n",
+		readFile(t, filepath.Join(dir, `asset-metrics`, `btc`, `2022-05.csv`)))
+
+	// rerun starts at latest exported time, record exported before is skipped and new metric extends header
+	stdout.Reset()
+	queries = nil
+	assert.Nil(t, run(context.Background(), args, &stdout, ioutil.Discard))
+	assert.Equal(t, "asset-metrics/btc/1d: 1 records, latest 2022-05-03T00:00:00.000000000Z\n", stdout.String())
+	assert.Contains(t, queries[0], `start_time=2022-05-02T00%3A00%3A00.000000000Z`)
+	assert.NotContains(t, queries[0], `start_inclusive`)
+	assert.Equal(t, "asset,time,PriceUSD,CapMrktCurUSD\nbtc,2022-05-01T00:00:00.000000000Z,100,\nbtc,2022-05-02T00:00:00.000000000Z,110,\nbtc,2022-05-03T00:00:00.000000000Z,120,2000\n",
+		readFile(t, filepath.Join(dir, `asset-metrics`, `btc`, `2022-05.csv`)))
+	assert.Equal(t, "{\n  \"asset-metrics/btc/1d\": \"2022-05-03T00:00:00.000000000Z\"\n}", readFile(t, filepath.Join(dir, `.cmcli-state.json`)))
+}
+
+func TestLoadJobErrors(t *testing.T) {
+	dir := t.TempDir()
+	for spec, message := range map[string]string{
+		"endpoint: timeseries/unknown\nassets: [btc]":             `unknown endpoint timeseries/unknown`,
+		"endpoint: asset-metrics":                                 `no assets, markets, exchanges, pairs, indexes or institutions`,
+		"endpoint: asset-metrics\nassets: [btc]\npartition: week": `unknown partition week`,
+		"endpoint: asset-metrics\nassets: [btc]\nformat: xml":     constants.UnsupportedOutput + ` xml`,
+	} {
+		path := filepath.Join(dir, `job.yml`)
+		assert.Nil(t, ioutil.WriteFile(path, []byte(spec), 0o644))
+		_, err := LoadJob(path)
+		assert.EqualError(t, err, constants.InvalidJob+`: `+message)
+	}
+}
+
+func TestExportTradesAtSameTime(t *testing.T) {
+	defer httpmock.Reset()
+	dir := t.TempDir()
+	job := filepath.Join(dir, `job.yml`)
+	assert.Nil(t, ioutil.WriteFile(job, []byte(`
+endpoint: market-trades
+markets: [coinbase-btc-usd-spot]
+start: 2022-05-01
+partition: none
+format: ndjson
+output: `+dir+`
+`), 0o644))
+
+	trade := func(id, t string) string {
+		return `{"market":"coinbase-btc-usd-spot","time":"` + t + `","coin_metrics_id":"` + id + `","amount":"1","price":"100","side":"buy"}`
+	}
+	first, second := `2022-05-01T00:00:00.000000000Z`, `2022-05-01T00:00:01.000000000Z`
+	pages := map[string]string{
+		`2022-05-01`: `{"data":[` + trade(`1`, first) + `,` + trade(`2`, first) + `,` + trade(`3`, second) + `,` + trade(`4`, second) + `]}`,
+		// trade 5 at latest exported time was published after first run
+		second: `{"data":[` + trade(`3`, second) + `,` + trade(`4`, second) + `,` + trade(`5`, second) + `]}`,
+	}
+	httpmock.RegisterResponder(http.MethodGet, constants.TestEndpoint+constants.ApiVersion+`/timeseries/market-trades`, func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(http.StatusOK, pages[req.URL.Query().Get(`start_time`)])
+		resp.Header.Set(`Content-Type`, `application/json`)
+		return resp, nil
+	})
+
+	var stdout bytes.Buffer
+	args := []string{`export`, `-endpoint`, constants.TestEndpoint, `-api-key`, constants.TestKey, job}
+	assert.Nil(t, run(context.Background(), args, &stdout, ioutil.Discard))
+	assert.Equal(t, "market-trades/coinbase-btc-usd-spot/: 4 records, latest "+second+"\n", stdout.String())
+	assert.Equal(t, "{\n  \"market-trades/coinbase-btc-usd-spot/\": {\n    \"time\": \""+second+"\",\n    \"ids\": [\n      \"3\",\n      \"4\"\n    ]\n  }\n}",
+		readFile(t, filepath.Join(dir, `.cmcli-state.json`)))
+
+	stdout.Reset()
+	assert.Nil(t, run(context.Background(), args, &stdout, ioutil.Discard))
+	assert.Equal(t, "market-trades/coinbase-btc-usd-spot/: 1 records, latest "+second+"\n", stdout.String())
+	path := filepath.Join(dir, `market-trades`, `coinbase-btc-usd-spot`, `coinbase-btc-usd-spot.ndjson`)
+	assert.Equal(t, strings.Join([]string{
+		trade(`1`, first), trade(`2`, first), trade(`3`, second), trade(`4`, second), trade(`5`, second),
+	}, "\n")+"\n", readFile(t, path))
+}
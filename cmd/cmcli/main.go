@@ -0,0 +1,206 @@
+// Command cmcli pulls data from Coin Metrics api without writing go program.
+//
+// Usage:
+//
+//	cmcli <group> <command> [flags] [args]
+//
+// Subcommands mirror api groups, like `catalog assets` or `timeseries asset-metrics`, and flags are query params
+// of operation. All pages are fetched, output is table, json, ndjson or csv.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// APIKeyEnv is environment variable of default api key
+const APIKeyEnv = `CM_API_KEY`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run executes command of args, records are written to stdout and usage to stderr
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) > 0 && args[0] == `export` {
+		return export(ctx, args[1:], stdout, stderr)
+	}
+	if len(args) > 0 && args[0] == `explore` {
+		return explore(ctx, args[1:], stdout, stderr)
+	}
+	if len(args) < 2 {
+		usage(stderr)
+		return flag.ErrHelp
+	}
+	c, ok := findCommand(args[0], args[1])
+	if !ok {
+		usage(stderr)
+		return fmt.Errorf(`%s: %s %s`, constants.UnknownCommand, args[0], args[1])
+	}
+
+	fs := flag.NewFlagSet(fmt.Sprintf(`cmcli %s %s`, c.group, c.name), flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	newClient := clientFlags(fs)
+	output := fs.String(`output`, Table, `output format: table, json, ndjson or csv`)
+	limit := fs.Int(`limit`, 0, `maximum number of records, 0 fetches all pages`)
+	params := reflect.New(reflect.TypeOf(c.params))
+	bound := bindParams(fs, params)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: %s [flags]", fs.Name())
+		for _, arg := range c.args {
+			fmt.Fprintf(stderr, ` <%s>`, arg)
+		}
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	// flags may follow positional args
+	var positional []string
+	rest := args[2:]
+	for {
+		if err := fs.Parse(rest); err != nil {
+			return err
+		}
+		rest = fs.Args()
+		if len(rest) == 0 {
+			break
+		}
+		positional = append(positional, rest[0])
+		rest = rest[1:]
+	}
+	if len(positional) != len(c.args) {
+		fs.Usage()
+		return fmt.Errorf(`%s expects args: %s`, fs.Name(), strings.Join(c.args, ` `))
+	}
+	editors, err := checkParams(bound)
+	if err != nil {
+		return err
+	}
+	w, err := newWriter(*output, stdout)
+	if err != nil {
+		return err
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	if err := fetch(ctx, client, c, positional, params, editors, *limit, w); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// clientFlags defines flags of api key and endpoint, returned function creates client once flags are parsed
+func clientFlags(fs *flag.FlagSet) func() (coinmetrics.CoinMetrics, error) {
+	apiKey := fs.String(`api-key`, os.Getenv(APIKeyEnv), `api key, community api is used without it`)
+	endpoint := fs.String(`endpoint`, ``, `api endpoint`)
+	return func() (coinmetrics.CoinMetrics, error) {
+		if *endpoint == `` {
+			*endpoint = constants.CommunityEndpoint
+			if *apiKey != `` {
+				*endpoint = constants.Endpoint
+			}
+		}
+		return coinmetrics.InitClient(*endpoint, *apiKey)
+	}
+}
+
+// fetch calls operation of command page by page and writes records until limit is reached
+func fetch(ctx context.Context, client api.ClientWithResponsesInterface, c command, args []string, params reflect.Value, editors []api.RequestEditorFn, limit int, w writer) error {
+	method := reflect.ValueOf(client).MethodByName(c.operation + `WithResponse`)
+	if !method.IsValid() {
+		return fmt.Errorf(`%s: %s`, constants.UnknownCommand, c.operation)
+	}
+	in := []reflect.Value{reflect.ValueOf(ctx)}
+	for i, arg := range args {
+		in = append(in, reflect.ValueOf(arg).Convert(method.Type().In(i+1)))
+	}
+	in = append(in, params)
+	for _, editor := range editors {
+		in = append(in, reflect.ValueOf(editor))
+	}
+
+	written := 0
+	for {
+		out := method.Call(in)
+		if err, _ := out[1].Interface().(error); err != nil {
+			return err
+		}
+		res := out[0].Elem()
+		body := res.FieldByName(`Body`).Bytes()
+		if res.FieldByName(`JSON200`).IsNil() {
+			return coinmetrics.NewApiError(out[0].Interface().(interface{ StatusCode() int }).StatusCode(), body)
+		}
+		records, err := parseRecords(body)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			if limit > 0 && written == limit {
+				return nil
+			}
+			if err := w.Write(r); err != nil {
+				return err
+			}
+			written++
+		}
+		if !nextPage(res.FieldByName(`JSON200`).Elem(), params.Elem()) || (limit > 0 && written == limit) {
+			return nil
+		}
+	}
+}
+
+// nextPage copies next page token of response to params, it returns false on last page
+func nextPage(response, params reflect.Value) bool {
+	token := response.FieldByName(`NextPageToken`)
+	next := params.FieldByName(`NextPageToken`)
+	if !token.IsValid() || !next.IsValid() {
+		return false
+	}
+	if token.Kind() == reflect.Ptr {
+		if token.IsNil() {
+			return false
+		}
+		token = token.Elem()
+	}
+	if token.String() == `` {
+		return false
+	}
+	value := reflect.New(next.Type().Elem())
+	value.Elem().SetString(token.String())
+	next.Set(value)
+	return true
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, `Usage: cmcli <group> <command> [flags] [args]`)
+	fmt.Fprintln(w, `       cmcli export [flags] <job.yml>`)
+	fmt.Fprintln(w, `       cmcli explore [flags]`)
+	fmt.Fprintln(w, `Run cmcli <group> <command> -h to list flags of command.`)
+	for _, group := range groups() {
+		var names []string
+		for _, c := range commands {
+			if c.group == group {
+				names = append(names, c.name)
+			}
+		}
+		fmt.Fprintf(w, "\n%s:\n  %s\n", group, strings.Join(names, "\n  "))
+	}
+}
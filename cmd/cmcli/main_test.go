@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	os.Exit(m.Run())
+}
+
+func registerJson(path string, status int, pages ...string) {
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf(`%s%s%s`, constants.TestEndpoint, constants.ApiVersion, path), func(req *http.Request) (*http.Response, error) {
+		body := pages[0]
+		if req.URL.Query().Get(`next_page_token`) == `page2` {
+			body = pages[1]
+		}
+		resp := httpmock.NewStringResponse(status, body)
+		resp.Header.Set(`Content-Type`, `application/json`)
+		return resp, nil
+	})
+}
+
+func runTest(args ...string) (string, error) {
+	var stdout bytes.Buffer
+	args = append(args, `-endpoint`, constants.TestEndpoint, `-api-key`, constants.TestKey)
+	err := run(context.Background(), args, &stdout, ioutil.Discard)
+	return stdout.String(), err
+}
+
+func TestAssetMetricsPages(t *testing.T) {
+	defer httpmock.Reset()
+	registerJson(`/timeseries/asset-metrics`, http.StatusOK,
+		`{"data":[{"asset":"btc","time":"2022-05-01T00:00:00.000000000Z","PriceUSD":"100"}],"next_page_token":"page2"}`,
+		`{"data":[{"asset":"btc","time":"2022-05-02T00:00:00.000000000Z","PriceUSD":"110","CapMrktCurUSD":"2000"}]}`)
+
+	out, err := runTest(`timeseries`, `asset-metrics`, `-assets`, `btc`, `-metrics`, `PriceUSD,CapMrktCurUSD`, `-output`, `csv`)
+	assert.Nil(t, err)
+	assert.Equal(t, "asset,time,PriceUSD,CapMrktCurUSD\n"+
+		"btc,2022-05-01T00:00:00.000000000Z,100,\n"+
+		"btc,2022-05-02T00:00:00.000000000Z,110,2000\n", out)
+
+	out, err = runTest(`timeseries`, `asset-metrics`, `-assets`, `btc`, `-metrics`, `PriceUSD`, `-output`, `ndjson`, `-limit`, `1`)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"asset\":\"btc\",\"time\":\"2022-05-01T00:00:00.000000000Z\",\"PriceUSD\":\"100\"}\n", out)
+	assert.Equal(t, 3, httpmock.GetTotalCallCount())
+}
+
+func TestBlockchainBlock(t *testing.T) {
+	defer httpmock.Reset()
+	registerJson(`/blockchain-v2/btc/blocks/abc`, http.StatusOK, `{"block_hash":"abc","height":"1","transactions":[{"txid":"t1"}]}`)
+
+	out, err := runTest(`blockchain`, `block`, `btc`, `abc`, `-output`, `json`)
+	assert.Nil(t, err)
+	assert.Equal(t, "[\n{\"block_hash\":\"abc\",\"height\":\"1\",\"transactions\":[{\"txid\":\"t1\"}]}\n]\n", out)
+
+	out, err = runTest(`blockchain`, `block`, `btc`, `abc`)
+	assert.Nil(t, err)
+	assert.Equal(t, "BLOCK_HASH  HEIGHT  TRANSACTIONS\nabc         1       [{\"txid\":\"t1\"}]\n", out)
+}
+
+func TestErrors(t *testing.T) {
+	defer httpmock.Reset()
+	registerJson(`/catalog/assets`, http.StatusUnauthorized, `{"error":{"type":"unauthorized","message":"Requested resource requires authorization."}}`)
+
+	_, err := runTest(`catalog`, `assets`)
+	assert.IsType(t, coinmetrics.ApiError{}, err)
+
+	_, err = runTest(`catalog`, `unknown`)
+	assert.EqualError(t, err, constants.UnknownCommand+`: catalog unknown`)
+
+	_, err = runTest(`timeseries`, `asset-metrics`, `-assets`, `btc`)
+	assert.EqualError(t, err, constants.MissingFlag+`: -metrics`)
+
+	_, err = runTest(`catalog`, `metrics`, `-output`, `xml`)
+	assert.EqualError(t, err, constants.UnsupportedOutput+`: xml`)
+
+	_, err = runTest(`blockchain`, `block`, `btc`)
+	assert.NotNil(t, err)
+}
+
+func TestCommandsMatchClient(t *testing.T) {
+	client, err := coinmetrics.InitClient(constants.TestEndpoint, constants.TestKey)
+	assert.Nil(t, err)
+	for _, c := range commands {
+		method := reflect.ValueOf(client).MethodByName(c.operation + `WithResponse`)
+		if assert.True(t, method.IsValid(), c.operation) {
+			// context, path args, params and variadic request editors
+			assert.Equal(t, len(c.args)+3, method.Type().NumIn(), c.operation)
+			assert.Equal(t, reflect.PtrTo(reflect.TypeOf(c.params)), method.Type().In(len(c.args)+1), c.operation)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// Output formats
+const (
+	Table  = `table`
+	JSON   = `json`
+	NDJSON = `ndjson`
+	CSV    = `csv`
+)
+
+type field struct {
+	key   string
+	value json.RawMessage
+}
+
+// record is json object which keeps order of its fields
+type record []field
+
+func (r *record) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		// scalars and arrays become single value record
+		*r = record{{key: `value`, value: append(json.RawMessage(nil), b...)}}
+		return nil
+	}
+	*r = (*r)[:0]
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		*r = append(*r, field{key: tok.(string), value: value})
+	}
+	return nil
+}
+
+func (r record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		if err := json.Compact(&buf, f.value); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// cell renders value of field, strings are unquoted and nested values are compact json
+func cell(value json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s
+	}
+	if string(value) == `null` {
+		return ``
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, value); err != nil {
+		return string(value)
+	}
+	return buf.String()
+}
+
+// page is response of api, records are in data or it is record itself
+type page struct {
+	Data *[]record `json:"data"`
+}
+
+// parseRecords returns records of response body
+func parseRecords(body []byte) ([]record, error) {
+	var p page
+	if err := json.Unmarshal(body, &p); err == nil && p.Data != nil {
+		return *p.Data, nil
+	}
+	var r record
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, err
+	}
+	return []record{r}, nil
+}
+
+// writer writes records in output format, Flush must be called after last record
+type writer interface {
+	Write(r record) error
+	Flush() error
+}
+
+func newWriter(format string, w io.Writer) (writer, error) {
+	switch format {
+	case Table:
+		return &tableWriter{w: w}, nil
+	case JSON:
+		return &jsonWriter{w: w}, nil
+	case NDJSON:
+		return &ndjsonWriter{w: w}, nil
+	case CSV:
+		return &tableWriter{w: w, csv: true}, nil
+	}
+	return nil, fmt.Errorf(`%s: %s`, constants.UnsupportedOutput, format)
+}
+
+// jsonWriter streams records as json array
+type jsonWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (j *jsonWriter) Write(r record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	prefix := ",\n"
+	if !j.started {
+		prefix = "[\n"
+		j.started = true
+	}
+	_, err = fmt.Fprintf(j.w, `%s%s`, prefix, b)
+	return err
+}
+
+func (j *jsonWriter) Flush() error {
+	if !j.started {
+		_, err := io.WriteString(j.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(j.w, "\n]\n")
+	return err
+}
+
+// ndjsonWriter streams records as json lines
+type ndjsonWriter struct {
+	w io.Writer
+}
+
+func (n *ndjsonWriter) Write(r record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(n.w, "%s\n", b)
+	return err
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return nil
+}
+
+// tableWriter buffers records, columns are union of fields of all records in order of appearance
+type tableWriter struct {
+	w       io.Writer
+	csv     bool
+	columns []string
+	seen    map[string]bool
+	records []record
+}
+
+func (t *tableWriter) Write(r record) error {
+	if t.seen == nil {
+		t.seen = map[string]bool{}
+	}
+	for _, f := range r {
+		if !t.seen[f.key] {
+			t.seen[f.key] = true
+			t.columns = append(t.columns, f.key)
+		}
+	}
+	t.records = append(t.records, r)
+	return nil
+}
+
+func (t *tableWriter) rows() [][]string {
+	rows := [][]string{append([]string(nil), t.columns...)}
+	for _, r := range t.records {
+		values := map[string]string{}
+		for _, f := range r {
+			values[f.key] = cell(f.value)
+		}
+		row := make([]string, len(t.columns))
+		for i, column := range t.columns {
+			row[i] = values[column]
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (t *tableWriter) Flush() error {
+	if len(t.columns) == 0 {
+		return nil
+	}
+	if t.csv {
+		w := csv.NewWriter(t.w)
+		if err := w.WriteAll(t.rows()); err != nil {
+			return err
+		}
+		return w.Error()
+	}
+	w := tabwriter.NewWriter(t.w, 0, 0, 2, ' ', 0)
+	for i, row := range t.rows() {
+		if i == 0 {
+			for j := range row {
+				row[j] = strings.ToUpper(row[j])
+			}
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
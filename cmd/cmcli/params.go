@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// skipped params are managed by cmcli itself, responses must stay json to be paginated and rendered
+var skipped = map[string]bool{
+	`next_page_token`: true,
+	`format`:          true,
+}
+
+// param is flag bound to field of params struct
+type param struct {
+	name     string
+	field    reflect.Value
+	required bool
+	// raw is query value of fields of types which can not be set from flag, like list of structs
+	raw string
+}
+
+func (p *param) String() string {
+	if p == nil || !p.field.IsValid() {
+		return ``
+	}
+	if p.raw != `` {
+		return p.raw
+	}
+	v := p.field
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ``
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice {
+		var values []string
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, fmt.Sprint(v.Index(i).Interface()))
+		}
+		return strings.Join(values, `,`)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func (p *param) Set(s string) error {
+	t := p.field.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, t.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.String {
+			p.raw = s
+			return nil
+		}
+		for _, item := range strings.Split(s, `,`) {
+			v = reflect.Append(v, reflect.ValueOf(item).Convert(t.Elem()))
+		}
+	default:
+		p.raw = s
+		return nil
+	}
+	if p.field.Kind() == reflect.Ptr {
+		ptr := reflect.New(t)
+		ptr.Elem().Set(v)
+		p.field.Set(ptr)
+		return nil
+	}
+	p.field.Set(v)
+	return nil
+}
+
+func (p *param) IsBoolFlag() bool {
+	t := p.field.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool
+}
+
+// set tells whether value was given
+func (p *param) set() bool {
+	if p.raw != `` {
+		return true
+	}
+	return !p.field.IsZero()
+}
+
+// editor sets raw query value, generated client does not serialize such fields properly
+func (p *param) editor() api.RequestEditorFn {
+	return func(ctx context.Context, req *http.Request) error {
+		q := req.URL.Query()
+		q.Set(p.name, p.raw)
+		req.URL.RawQuery = q.Encode()
+		return nil
+	}
+}
+
+// bindParams defines flag of every field of params, which must be pointer to params struct.
+// Flags are named after query params with hyphens instead of underscores.
+func bindParams(fs *flag.FlagSet, params reflect.Value) []*param {
+	var bound []*param
+	t := params.Elem().Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get(`json`), `,`)
+		if tag[0] == `` || tag[0] == `-` || skipped[tag[0]] {
+			continue
+		}
+		p := param{
+			name:     tag[0],
+			field:    params.Elem().Field(i),
+			required: len(tag) == 1 && t.Field(i).Type.Kind() != reflect.Ptr,
+		}
+		usage := fmt.Sprintf(`%s query param`, p.name)
+		if p.required {
+			usage += ` (required)`
+		}
+		fs.Var(&p, strings.ReplaceAll(p.name, `_`, `-`), usage)
+		bound = append(bound, &p)
+	}
+	return bound
+}
+
+// checkParams returns error for missing required params and request editors of raw params
+func checkParams(bound []*param) ([]api.RequestEditorFn, error) {
+	var editors []api.RequestEditorFn
+	for _, p := range bound {
+		if p.required && !p.set() {
+			return nil, fmt.Errorf(`%s: -%s`, constants.MissingFlag, strings.ReplaceAll(p.name, `_`, `-`))
+		}
+		if p.raw != `` {
+			editors = append(editors, p.editor())
+		}
+	}
+	return editors, nil
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// Codes of keys decoded from terminal input
+const (
+	keyRune = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyPgUp
+	keyPgDn
+	keyHome
+	keyEnd
+	keyTab
+	keyBackTab
+	keyEnter
+	keyBackspace
+	keyEsc
+	keyCtrlC
+	keyCtrlE
+	keyCtrlF
+	keyCtrlG
+	keyCtrlQ
+	keyCtrlU
+	keyCtrlX
+	keyUnknown
+)
+
+// key is key pressed in terminal, r is set for keyRune
+type key struct {
+	code int
+	r    rune
+}
+
+// Styles of rows
+const (
+	styleNone = iota
+	styleHeader
+	styleCursor
+	styleStatus
+)
+
+// row is line of screen
+type row struct {
+	style int
+	text  string
+}
+
+// escape sequences of styles
+var styles = map[int]string{
+	styleHeader: "\x1b[1m",
+	styleCursor: "\x1b[7m",
+	styleStatus: "\x1b[36m",
+}
+
+// controls are keys sent as single control byte
+var controls = map[byte]int{
+	'\t': keyTab,
+	'\r': keyEnter,
+	'\n': keyEnter,
+	0x7f: keyBackspace,
+	0x08: keyBackspace,
+	0x03: keyCtrlC,
+	0x04: keyCtrlC,
+	0x05: keyCtrlE,
+	0x06: keyCtrlF,
+	0x07: keyCtrlG,
+	0x11: keyCtrlQ,
+	0x15: keyCtrlU,
+	0x18: keyCtrlX,
+}
+
+// sequences are keys sent as escape sequences by xterm compatible terminals
+var sequences = map[string]int{
+	"[A": keyUp, "OA": keyUp,
+	"[B": keyDown, "OB": keyDown,
+	"[C": keyRight, "OC": keyRight,
+	"[D": keyLeft, "OD": keyLeft,
+	"[H": keyHome, "OH": keyHome, "[1~": keyHome, "[7~": keyHome,
+	"[F": keyEnd, "OF": keyEnd, "[4~": keyEnd, "[8~": keyEnd,
+	"[5~": keyPgUp,
+	"[6~": keyPgDn,
+	"[Z":  keyBackTab,
+}
+
+// decodeKeys decodes keys of one read from terminal, escape alone is esc key
+func decodeKeys(input []byte) []key {
+	var keys []key
+	for len(input) > 0 {
+		b := input[0]
+		switch {
+		case b == 0x1b:
+			n, code := escapeSequence(input[1:])
+			keys = append(keys, key{code: code})
+			input = input[1+n:]
+		case b < 0x20 || b == 0x7f:
+			code, ok := controls[b]
+			if !ok {
+				code = keyUnknown
+			}
+			keys = append(keys, key{code: code})
+			input = input[1:]
+		default:
+			r, size := utf8.DecodeRune(input)
+			if unicode.IsPrint(r) {
+				keys = append(keys, key{code: keyRune, r: r})
+			}
+			input = input[size:]
+		}
+	}
+	return keys
+}
+
+// escapeSequence returns length and key of sequence following escape
+func escapeSequence(input []byte) (int, int) {
+	if len(input) == 0 || (input[0] != '[' && input[0] != 'O') {
+		return 0, keyEsc
+	}
+	// sequence ends with byte in range @ to ~, parameters before it are digits and ;
+	for i := 1; i < len(input); i++ {
+		if input[i] >= '@' && input[i] <= '~' {
+			if code, ok := sequences[string(input[:i+1])]; ok {
+				return i + 1, code
+			}
+			return i + 1, keyUnknown
+		}
+	}
+	return len(input), keyUnknown
+}
+
+// truncate cuts text to width runes
+func truncate(text string, width int) string {
+	if width <= 0 {
+		return ``
+	}
+	if runeCount(text) <= width {
+		return text
+	}
+	return string([]rune(text)[:width])
+}
+
+func runeCount(text string) int {
+	return utf8.RuneCountInString(text)
+}
+
+// draw writes rows over whole screen, styled rows are padded so background covers width
+func draw(w io.Writer, rows []row, width int) error {
+	b := bufio.NewWriter(w)
+	b.WriteString("\x1b[H")
+	for i, r := range rows {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		text := r.text
+		if r.style == styleCursor {
+			text += fmt.Sprintf(`%*s`, width-runeCount(text), ``)
+		}
+		if style, ok := styles[r.style]; ok {
+			text = style + text + "\x1b[0m"
+		}
+		b.WriteString(text)
+		b.WriteString("\x1b[K")
+	}
+	b.WriteString("\x1b[J")
+	return b.Flush()
+}
+
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// runTerminal runs explorer full screen in raw mode until it quits, terminal is restored afterwards
+func runTerminal(in, out *os.File, e *explorer) error {
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return err
+	}
+	defer term.Restore(int(in.Fd()), state)
+	// alternate screen without cursor
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	buf := make([]byte, 256)
+	for {
+		width, height, err := term.GetSize(int(out.Fd()))
+		if err != nil {
+			return err
+		}
+		if err := draw(out, e.render(width, height), width); err != nil {
+			return err
+		}
+		n, err := in.Read(buf)
+		if err != nil {
+			return err
+		}
+		for _, k := range decodeKeys(buf[:n]) {
+			if e.handle(k) {
+				return nil
+			}
+		}
+	}
+}
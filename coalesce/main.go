@@ -0,0 +1,190 @@
+// Package coalesce deduplicates concurrent identical requests of the client, callers asking for the same operation and
+// params while request is in flight share one http call and its response.
+//
+//	doer := coalesce.New(http.DefaultClient, coalesce.Only(`getTimeseriesAssetMetrics`, `getCatalogAssets`))
+//	client, err := coinmetrics.InitClient(constants.Endpoint, key, api.WithHTTPClient(doer))
+package coalesce
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/rulesng/coinmetrics-go-sdk/openapi"
+)
+
+// Stats are counters of Doer
+type Stats struct {
+	// Requests are http calls sent to api
+	Requests int64
+	// Shared are requests served by call of another caller
+	Shared int64
+	// Bypasses are requests of operations which are not coalesced
+	Bypasses int64
+}
+
+// call is request in flight, result is set before done is closed
+type call struct {
+	done chan struct{}
+	res  *http.Response
+	body []byte
+	err  error
+}
+
+// Doer is api.HttpRequestDoer coalescing identical GET requests
+type Doer struct {
+	client api.HttpRequestDoer
+	routes []openapi.Route
+	only   map[string]bool
+	except map[string]bool
+
+	mu    sync.Mutex
+	calls map[string]*call
+
+	requests, shared, bypasses int64
+}
+
+// Option allows to customize Doer
+type Option func(*Doer)
+
+// Only coalesces requests of operations with given ids, like `getTimeseriesAssetMetrics`
+func Only(operationIDs ...string) Option {
+	return func(d *Doer) {
+		d.only = set(d.only, operationIDs)
+	}
+}
+
+// Except does not coalesce requests of operations with given ids
+func Except(operationIDs ...string) Option {
+	return func(d *Doer) {
+		d.except = set(d.except, operationIDs)
+	}
+}
+
+// New creates doer sending requests with client, http.DefaultClient is used when client is nil.
+// All GET operations are coalesced unless Only or Except is set.
+func New(client api.HttpRequestDoer, opts ...Option) *Doer {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	d := Doer{client: client, calls: map[string]*call{}}
+	if spec, err := openapi.V4(); err == nil {
+		d.routes = spec.Routes()
+	}
+	for _, opt := range opts {
+		opt(&d)
+	}
+	return &d
+}
+
+// Stats returns counters
+func (d *Doer) Stats() Stats {
+	return Stats{
+		Requests: atomic.LoadInt64(&d.requests),
+		Shared:   atomic.LoadInt64(&d.shared),
+		Bypasses: atomic.LoadInt64(&d.bypasses),
+	}
+}
+
+// Do sends request or waits for identical request in flight. Every caller gets response of its own.
+func (d *Doer) Do(req *http.Request) (*http.Response, error) {
+	if !d.coalesced(req) {
+		atomic.AddInt64(&d.bypasses, 1)
+		return d.client.Do(req)
+	}
+	key := Key(req)
+	d.mu.Lock()
+	if c, ok := d.calls[key]; ok {
+		d.mu.Unlock()
+		select {
+		case <-c.done:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		// call canceled by context of its caller is sent again for callers still waiting
+		if c.err != nil && isContextError(c.err) && req.Context().Err() == nil {
+			return d.Do(req)
+		}
+		atomic.AddInt64(&d.shared, 1)
+		return c.response(req)
+	}
+	c := &call{done: make(chan struct{})}
+	d.calls[key] = c
+	d.mu.Unlock()
+
+	atomic.AddInt64(&d.requests, 1)
+	c.res, c.err = d.client.Do(req)
+	if c.err == nil {
+		c.body, c.err = ioutil.ReadAll(c.res.Body)
+		c.res.Body.Close()
+	}
+	d.mu.Lock()
+	delete(d.calls, key)
+	d.mu.Unlock()
+	close(c.done)
+	return c.response(req)
+}
+
+// coalesced tells whether request is coalesced by options
+func (d *Doer) coalesced(req *http.Request) bool {
+	if req.Method != http.MethodGet {
+		return false
+	}
+	if d.only == nil && d.except == nil {
+		return true
+	}
+	path := req.URL.Path
+	if i := strings.Index(path, `/`+constants.ApiVersion+`/`); i >= 0 {
+		path = path[i+len(constants.ApiVersion)+1:]
+	}
+	route, _, ok := openapi.Match(d.routes, path)
+	if !ok {
+		return d.only == nil
+	}
+	id := route.Operation.OperationID
+	if d.except[id] {
+		return false
+	}
+	return d.only == nil || d.only[id]
+}
+
+// Key returns key of identical requests, query params are sorted. Api key stays part of key, so responses are shared
+// only by callers with the same entitlements.
+func Key(req *http.Request) string {
+	u := url.URL{Scheme: req.URL.Scheme, Host: req.URL.Host, Path: req.URL.Path, RawQuery: req.URL.Query().Encode()}
+	return req.Method + ` ` + u.String()
+}
+
+// response copies result of call for caller of req
+func (c *call) response(req *http.Request) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	res := *c.res
+	res.Header = c.res.Header.Clone()
+	res.Body = ioutil.NopCloser(bytes.NewReader(c.body))
+	res.Request = req
+	return &res, nil
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func set(s map[string]bool, values []string) map[string]bool {
+	if s == nil {
+		s = map[string]bool{}
+	}
+	for _, value := range values {
+		s[value] = true
+	}
+	return s
+}
@@ -0,0 +1,193 @@
+package coalesce_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coalesce"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/rulesng/coinmetrics-go-sdk/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+const callers = 20
+
+// gate holds requests until it is opened, so concurrent callers pile up behind the first one
+type gate struct {
+	open  chan struct{}
+	calls int64
+}
+
+func newGate() *gate {
+	return &gate{open: make(chan struct{})}
+}
+
+func (g *gate) Do(req *http.Request) (*http.Response, error) {
+	atomic.AddInt64(&g.calls, 1)
+	select {
+	case <-g.open:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	return http.DefaultClient.Do(req)
+}
+
+func newClient(t *testing.T, doer *coalesce.Doer) (*mock.Server, coinmetrics.CoinMetrics) {
+	server, err := mock.NewServer()
+	assert.Nil(t, err)
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	client, err := coinmetrics.InitClient(ts.URL+`/`, constants.TestKey, api.WithHTTPClient(doer))
+	assert.Nil(t, err)
+	return server, client
+}
+
+// concurrently calls f by callers goroutines and opens gate once they are waiting
+func concurrently(g *gate, f func(i int)) {
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f(i)
+		}(i)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(g.open)
+	wg.Wait()
+}
+
+func referenceRate(ctx context.Context, client coinmetrics.CoinMetrics, asset string) (*api.GetTimeseriesAssetMetricsResponse, error) {
+	return client.GetTimeseriesAssetMetricsWithResponse(ctx, &api.GetTimeseriesAssetMetricsParams{Assets: api.AssetId(asset), Metrics: api.AssetMetrics{`ReferenceRateUSD`}})
+}
+
+func TestIdenticalRequestsShareCall(t *testing.T) {
+	g := newGate()
+	doer := coalesce.New(g)
+	server, client := newClient(t, doer)
+	responses := make([]*api.GetTimeseriesAssetMetricsResponse, callers)
+	concurrently(g, func(i int) {
+		res, err := referenceRate(context.Background(), client, `btc`)
+		assert.Nil(t, err)
+		responses[i] = res
+	})
+	assert.Equal(t, int64(1), atomic.LoadInt64(&g.calls))
+	assert.Equal(t, 1, server.Calls(`/timeseries/asset-metrics`))
+	assert.Equal(t, coalesce.Stats{Requests: 1, Shared: callers - 1}, doer.Stats())
+	for _, res := range responses {
+		assert.NotNil(t, res.JSON200)
+		assert.Equal(t, responses[0].Body, res.Body)
+		assert.Contains(t, string(res.Body), `"asset":"btc"`)
+	}
+	// headers are copies, callers can not change response of each other
+	responses[0].HTTPResponse.Header.Set(`X-Test`, `1`)
+	assert.Empty(t, responses[1].HTTPResponse.Header.Get(`X-Test`))
+
+	// call is forgotten once finished
+	_, err := referenceRate(context.Background(), client, `btc`)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, server.Calls(`/timeseries/asset-metrics`))
+}
+
+func TestDifferentRequestsAreNotShared(t *testing.T) {
+	g := newGate()
+	doer := coalesce.New(g)
+	_, client := newClient(t, doer)
+	concurrently(g, func(i int) {
+		asset := `btc`
+		if i%2 == 1 {
+			asset = `eth`
+		}
+		res, err := referenceRate(context.Background(), client, asset)
+		assert.Nil(t, err)
+		assert.Contains(t, string(res.Body), `"asset":"`+asset+`"`)
+	})
+	assert.Equal(t, int64(2), atomic.LoadInt64(&g.calls))
+	assert.Equal(t, int64(callers-2), doer.Stats().Shared)
+}
+
+func TestKey(t *testing.T) {
+	a, _ := http.NewRequest(http.MethodGet, `https://api.coinmetrics.io/v4/catalog/assets?assets=btc&api_key=a`, nil)
+	b, _ := http.NewRequest(http.MethodGet, `https://api.coinmetrics.io/v4/catalog/assets?api_key=a&assets=btc`, nil)
+	c, _ := http.NewRequest(http.MethodGet, `https://api.coinmetrics.io/v4/catalog/assets?api_key=b&assets=btc`, nil)
+	assert.Equal(t, coalesce.Key(a), coalesce.Key(b))
+	// callers with different keys never share responses
+	assert.NotEqual(t, coalesce.Key(a), coalesce.Key(c))
+}
+
+func TestPerOperation(t *testing.T) {
+	g := newGate()
+	doer := coalesce.New(g, coalesce.Only(`getCatalogAssets`))
+	server, client := newClient(t, doer)
+	concurrently(g, func(i int) {
+		var err error
+		if i%2 == 0 {
+			_, err = client.GetCatalogAssetsWithResponse(context.Background(), &api.GetCatalogAssetsParams{})
+		} else {
+			_, err = referenceRate(context.Background(), client, `btc`)
+		}
+		assert.Nil(t, err)
+	})
+	assert.Equal(t, 1, server.Calls(`/catalog/assets`))
+	assert.Equal(t, callers/2, server.Calls(`/timeseries/asset-metrics`))
+	assert.Equal(t, coalesce.Stats{Requests: 1, Shared: callers/2 - 1, Bypasses: callers / 2}, doer.Stats())
+
+	g = newGate()
+	doer = coalesce.New(g, coalesce.Except(`getTimeseriesAssetMetrics`))
+	server, client = newClient(t, doer)
+	concurrently(g, func(i int) {
+		_, err := referenceRate(context.Background(), client, `btc`)
+		assert.Nil(t, err)
+	})
+	assert.Equal(t, callers, server.Calls(`/timeseries/asset-metrics`))
+}
+
+func TestCanceledCaller(t *testing.T) {
+	g := newGate()
+	doer := coalesce.New(g)
+	server, client := newClient(t, doer)
+	leader, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := referenceRate(leader, client, `btc`)
+		errs <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// follower outlives canceled leader and sends request again
+	results := make(chan *api.GetTimeseriesAssetMetricsResponse, 1)
+	go func() {
+		res, err := referenceRate(context.Background(), client, `btc`)
+		assert.Nil(t, err)
+		results <- res
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+	close(g.open)
+	res := <-results
+	if assert.NotNil(t, res) {
+		assert.NotNil(t, res.JSON200)
+	}
+	assert.Equal(t, 1, server.Calls(`/timeseries/asset-metrics`))
+	assert.Equal(t, int64(2), doer.Stats().Requests)
+
+	// canceled follower does not wait for leader
+	g = newGate()
+	doer = coalesce.New(g)
+	_, client = newClient(t, doer)
+	go referenceRate(context.Background(), client, `btc`)
+	time.Sleep(50 * time.Millisecond)
+	follower, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := referenceRate(follower, client, `btc`)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	close(g.open)
+}
@@ -0,0 +1,24 @@
+package coinmetrics_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiErrorBadParameter(t *testing.T) {
+	for body, expected := range map[string]string{
+		`{"error":{"type":"bad_parameter","message":"Bad parameter 'asset'. Value 'doge' is not supported."}}`: `asset`,
+		`{"error":{"type":"bad_parameter","message":"Value of parameter 'start_height' must be a number."}}`:   `start_height`,
+		`{"error":{"type":"bad_parameter","message":"Invalid request."}}`:                                      ``,
+		`{"error":{"type":"forbidden","message":"Bad parameter 'asset'."}}`:                                    ``,
+	} {
+		var apiError coinmetrics.ApiError
+		assert.ErrorAs(t, coinmetrics.NewApiError(http.StatusBadRequest, []byte(body)), &apiError)
+		param, ok := apiError.BadParameter()
+		assert.Equal(t, expected != ``, ok, body)
+		assert.Equal(t, expected, param, body)
+	}
+}
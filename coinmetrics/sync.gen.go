@@ -0,0 +1,1042 @@
+// Code generated by apigen sync. DO NOT EDIT.
+
+package coinmetrics
+
+import (
+	"context"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+)
+
+// GetBlockchainV2ListOfAccountsWithResponseSync To get all pages of list of accounts
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getBlockchainV2ListOfAccounts
+// Returning: api.GetBlockchainV2ListOfAccountsResponse, error
+func (c CoinMetrics) GetBlockchainV2ListOfAccountsWithResponseSync(ctx context.Context, asset api.BlockchainAsset, params *api.GetBlockchainV2ListOfAccountsParams, reqEditors ...api.RequestEditorFn) (api.GetBlockchainV2ListOfAccountsResponse, error) {
+	page := *params
+	data := []interface{}{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetBlockchainV2ListOfAccountsWithResponse(ctx, asset, &page, reqEditors...)
+		if err != nil {
+			return api.GetBlockchainV2ListOfAccountsResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records, err := pageRecords(res.JSON200.Data)
+		if err != nil {
+			return api.GetBlockchainV2ListOfAccountsResponse{}, err
+		}
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetBlockchainV2ListOfBalanceUpdatesWithResponseSync To get all pages of list of balance updates
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getBlockchainV2ListOfBalanceUpdates
+// Returning: api.GetBlockchainV2ListOfBalanceUpdatesResponse, error
+func (c CoinMetrics) GetBlockchainV2ListOfBalanceUpdatesWithResponseSync(ctx context.Context, asset api.BlockchainAsset, params *api.GetBlockchainV2ListOfBalanceUpdatesParams, reqEditors ...api.RequestEditorFn) (api.GetBlockchainV2ListOfBalanceUpdatesResponse, error) {
+	page := *params
+	data := []api.BlockchainBalanceUpdateV2{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetBlockchainV2ListOfBalanceUpdatesWithResponse(ctx, asset, &page, reqEditors...)
+		if err != nil {
+			return api.GetBlockchainV2ListOfBalanceUpdatesResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetBlockchainV2ListOfBlocksWithResponseSync To get all pages of list of blocks
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getBlockchainV2ListOfBlocks
+// Returning: api.GetBlockchainV2ListOfBlocksResponse, error
+func (c CoinMetrics) GetBlockchainV2ListOfBlocksWithResponseSync(ctx context.Context, asset api.BlockchainAsset, params *api.GetBlockchainV2ListOfBlocksParams, reqEditors ...api.RequestEditorFn) (api.GetBlockchainV2ListOfBlocksResponse, error) {
+	page := *params
+	data := []interface{}{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetBlockchainV2ListOfBlocksWithResponse(ctx, asset, &page, reqEditors...)
+		if err != nil {
+			return api.GetBlockchainV2ListOfBlocksResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records, err := pageRecords(res.JSON200.Data)
+		if err != nil {
+			return api.GetBlockchainV2ListOfBlocksResponse{}, err
+		}
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetBlockchainV2ListOfSubAccountsWithResponseSync To get all pages of list of sub-accounts
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getBlockchainV2ListOfSubAccounts
+// Returning: api.GetBlockchainV2ListOfSubAccountsResponse, error
+func (c CoinMetrics) GetBlockchainV2ListOfSubAccountsWithResponseSync(ctx context.Context, asset api.BlockchainAsset, params *api.GetBlockchainV2ListOfSubAccountsParams, reqEditors ...api.RequestEditorFn) (api.GetBlockchainV2ListOfSubAccountsResponse, error) {
+	page := *params
+	data := []interface{}{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetBlockchainV2ListOfSubAccountsWithResponse(ctx, asset, &page, reqEditors...)
+		if err != nil {
+			return api.GetBlockchainV2ListOfSubAccountsResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records, err := pageRecords(res.JSON200.Data)
+		if err != nil {
+			return api.GetBlockchainV2ListOfSubAccountsResponse{}, err
+		}
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetBlockchainV2ListOfTransactionsWithResponseSync To get all pages of list of transactions
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getBlockchainV2ListOfTransactions
+// Returning: api.GetBlockchainV2ListOfTransactionsResponse, error
+func (c CoinMetrics) GetBlockchainV2ListOfTransactionsWithResponseSync(ctx context.Context, asset api.BlockchainAsset, params *api.GetBlockchainV2ListOfTransactionsParams, reqEditors ...api.RequestEditorFn) (api.GetBlockchainV2ListOfTransactionsResponse, error) {
+	page := *params
+	data := []interface{}{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetBlockchainV2ListOfTransactionsWithResponse(ctx, asset, &page, reqEditors...)
+		if err != nil {
+			return api.GetBlockchainV2ListOfTransactionsResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records, err := pageRecords(res.JSON200.Data)
+		if err != nil {
+			return api.GetBlockchainV2ListOfTransactionsResponse{}, err
+		}
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetBlockchainListOfAccountsWithResponseSync To get all pages of list of accounts
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getBlockchainListOfAccounts
+// Returning: api.GetBlockchainListOfAccountsResponse, error
+func (c CoinMetrics) GetBlockchainListOfAccountsWithResponseSync(ctx context.Context, asset api.BlockchainAsset, params *api.GetBlockchainListOfAccountsParams, reqEditors ...api.RequestEditorFn) (api.GetBlockchainListOfAccountsResponse, error) {
+	page := *params
+	data := []interface{}{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetBlockchainListOfAccountsWithResponse(ctx, asset, &page, reqEditors...)
+		if err != nil {
+			return api.GetBlockchainListOfAccountsResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records, err := pageRecords(res.JSON200.Data)
+		if err != nil {
+			return api.GetBlockchainListOfAccountsResponse{}, err
+		}
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetBlockchainListOfBalanceUpdatesWithResponseSync To get all pages of list of balance updates
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getBlockchainListOfBalanceUpdates
+// Returning: api.GetBlockchainListOfBalanceUpdatesResponse, error
+func (c CoinMetrics) GetBlockchainListOfBalanceUpdatesWithResponseSync(ctx context.Context, asset api.BlockchainAsset, params *api.GetBlockchainListOfBalanceUpdatesParams, reqEditors ...api.RequestEditorFn) (api.GetBlockchainListOfBalanceUpdatesResponse, error) {
+	page := *params
+	data := []api.BlockchainBalanceUpdate{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetBlockchainListOfBalanceUpdatesWithResponse(ctx, asset, &page, reqEditors...)
+		if err != nil {
+			return api.GetBlockchainListOfBalanceUpdatesResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetBlockchainListOfBlocksWithResponseSync To get all pages of list of blocks
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getBlockchainListOfBlocks
+// Returning: api.GetBlockchainListOfBlocksResponse, error
+func (c CoinMetrics) GetBlockchainListOfBlocksWithResponseSync(ctx context.Context, asset api.BlockchainAsset, params *api.GetBlockchainListOfBlocksParams, reqEditors ...api.RequestEditorFn) (api.GetBlockchainListOfBlocksResponse, error) {
+	page := *params
+	data := []interface{}{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetBlockchainListOfBlocksWithResponse(ctx, asset, &page, reqEditors...)
+		if err != nil {
+			return api.GetBlockchainListOfBlocksResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records, err := pageRecords(res.JSON200.Data)
+		if err != nil {
+			return api.GetBlockchainListOfBlocksResponse{}, err
+		}
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTransactionTrackerWithResponseSync To get all pages of transaction tracker
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTransactionTracker
+// Returning: api.GetTransactionTrackerResponse, error
+func (c CoinMetrics) GetTransactionTrackerWithResponseSync(ctx context.Context, asset api.BlockchainAsset, params *api.GetTransactionTrackerParams, reqEditors ...api.RequestEditorFn) (api.GetTransactionTrackerResponse, error) {
+	page := *params
+	data := []api.TxTrackerTransaction{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTransactionTrackerWithResponse(ctx, asset, &page, reqEditors...)
+		if err != nil {
+			return api.GetTransactionTrackerResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetBlockchainListOfTransactionsWithResponseSync To get all pages of list of transactions
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getBlockchainListOfTransactions
+// Returning: api.GetBlockchainListOfTransactionsResponse, error
+func (c CoinMetrics) GetBlockchainListOfTransactionsWithResponseSync(ctx context.Context, asset api.BlockchainAsset, params *api.GetBlockchainListOfTransactionsParams, reqEditors ...api.RequestEditorFn) (api.GetBlockchainListOfTransactionsResponse, error) {
+	page := *params
+	data := []interface{}{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetBlockchainListOfTransactionsWithResponse(ctx, asset, &page, reqEditors...)
+		if err != nil {
+			return api.GetBlockchainListOfTransactionsResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records, err := pageRecords(res.JSON200.Data)
+		if err != nil {
+			return api.GetBlockchainListOfTransactionsResponse{}, err
+		}
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetAssetAlertsWithResponseSync To get all pages of asset alerts (unstable)
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getAssetAlerts
+// Returning: api.GetAssetAlertsResponse, error
+func (c CoinMetrics) GetAssetAlertsWithResponseSync(ctx context.Context, params *api.GetAssetAlertsParams, reqEditors ...api.RequestEditorFn) (api.GetAssetAlertsResponse, error) {
+	page := *params
+	data := []api.AssetAlert{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetAssetAlertsWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetAssetAlertsResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetAssetChainsWithResponseSync To get all pages of asset chains
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getAssetChains
+// Returning: api.GetAssetChainsResponse, error
+func (c CoinMetrics) GetAssetChainsWithResponseSync(ctx context.Context, params *api.GetAssetChainsParams, reqEditors ...api.RequestEditorFn) (api.GetAssetChainsResponse, error) {
+	page := *params
+	data := []api.AssetChains{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetAssetChainsWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetAssetChainsResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesAssetMetricsWithResponseSync To get all pages of asset metrics
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesAssetMetrics
+// Returning: api.GetTimeseriesAssetMetricsResponse, error
+func (c CoinMetrics) GetTimeseriesAssetMetricsWithResponseSync(ctx context.Context, params *api.GetTimeseriesAssetMetricsParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesAssetMetricsResponse, error) {
+	page := *params
+	data := []interface{}{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesAssetMetricsWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesAssetMetricsResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records, err := pageRecords(res.JSON200.Data)
+		if err != nil {
+			return api.GetTimeseriesAssetMetricsResponse{}, err
+		}
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesExchangeAssetMetricsWithResponseSync To get all pages of exchange-asset metrics
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesExchangeAssetMetrics
+// Returning: api.GetTimeseriesExchangeAssetMetricsResponse, error
+func (c CoinMetrics) GetTimeseriesExchangeAssetMetricsWithResponseSync(ctx context.Context, params *api.GetTimeseriesExchangeAssetMetricsParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesExchangeAssetMetricsResponse, error) {
+	page := *params
+	data := []interface{}{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesExchangeAssetMetricsWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesExchangeAssetMetricsResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records, err := pageRecords(res.JSON200.Data)
+		if err != nil {
+			return api.GetTimeseriesExchangeAssetMetricsResponse{}, err
+		}
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesExchangeMetricsWithResponseSync To get all pages of exchange metrics
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesExchangeMetrics
+// Returning: api.GetTimeseriesExchangeMetricsResponse, error
+func (c CoinMetrics) GetTimeseriesExchangeMetricsWithResponseSync(ctx context.Context, params *api.GetTimeseriesExchangeMetricsParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesExchangeMetricsResponse, error) {
+	page := *params
+	data := []interface{}{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesExchangeMetricsWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesExchangeMetricsResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records, err := pageRecords(res.JSON200.Data)
+		if err != nil {
+			return api.GetTimeseriesExchangeMetricsResponse{}, err
+		}
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesIndexConstituentsWithResponseSync To get all pages of index constituents
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesIndexConstituents
+// Returning: api.GetTimeseriesIndexConstituentsResponse, error
+func (c CoinMetrics) GetTimeseriesIndexConstituentsWithResponseSync(ctx context.Context, params *api.GetTimeseriesIndexConstituentsParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesIndexConstituentsResponse, error) {
+	page := *params
+	data := []api.IndexConstituents{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesIndexConstituentsWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesIndexConstituentsResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesIndexLevelsWithResponseSync To get all pages of index levels
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesIndexLevels
+// Returning: api.GetTimeseriesIndexLevelsResponse, error
+func (c CoinMetrics) GetTimeseriesIndexLevelsWithResponseSync(ctx context.Context, params *api.GetTimeseriesIndexLevelsParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesIndexLevelsResponse, error) {
+	page := *params
+	data := []api.IndexLevel{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesIndexLevelsWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesIndexLevelsResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesInstitutionMetricsWithResponseSync To get all pages of institution metrics
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesInstitutionMetrics
+// Returning: api.GetTimeseriesInstitutionMetricsResponse, error
+//
+// Breaking change: it returned ([]interface{}, error) with data of every page before, records of all pages are in JSON200.Data now.
+func (c CoinMetrics) GetTimeseriesInstitutionMetricsWithResponseSync(ctx context.Context, params *api.GetTimeseriesInstitutionMetricsParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesInstitutionMetricsResponse, error) {
+	page := *params
+	data := []interface{}{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesInstitutionMetricsWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesInstitutionMetricsResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records, err := pageRecords(res.JSON200.Data)
+		if err != nil {
+			return api.GetTimeseriesInstitutionMetricsResponse{}, err
+		}
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesMarketCandlesWithResponseSync To get all pages of market candles
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesMarketCandles
+// Returning: api.GetTimeseriesMarketCandlesResponse, error
+func (c CoinMetrics) GetTimeseriesMarketCandlesWithResponseSync(ctx context.Context, params *api.GetTimeseriesMarketCandlesParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesMarketCandlesResponse, error) {
+	page := *params
+	data := []api.MarketCandle{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesMarketCandlesWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesMarketCandlesResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesMarketContractPricesWithResponseSync To get all pages of market contract prices
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesMarketContractPrices
+// Returning: api.GetTimeseriesMarketContractPricesResponse, error
+func (c CoinMetrics) GetTimeseriesMarketContractPricesWithResponseSync(ctx context.Context, params *api.GetTimeseriesMarketContractPricesParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesMarketContractPricesResponse, error) {
+	page := *params
+	data := []api.MarketContractPrices{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesMarketContractPricesWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesMarketContractPricesResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesMarketFundingRatesWithResponseSync To get all pages of market funding rates
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesMarketFundingRates
+// Returning: api.GetTimeseriesMarketFundingRatesResponse, error
+func (c CoinMetrics) GetTimeseriesMarketFundingRatesWithResponseSync(ctx context.Context, params *api.GetTimeseriesMarketFundingRatesParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesMarketFundingRatesResponse, error) {
+	page := *params
+	data := []api.MarketFundingRate{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesMarketFundingRatesWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesMarketFundingRatesResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesMarketGreeksWithResponseSync To get all pages of market greeks
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesMarketGreeks
+// Returning: api.GetTimeseriesMarketGreeksResponse, error
+func (c CoinMetrics) GetTimeseriesMarketGreeksWithResponseSync(ctx context.Context, params *api.GetTimeseriesMarketGreeksParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesMarketGreeksResponse, error) {
+	page := *params
+	data := []api.MarketGreeks{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesMarketGreeksWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesMarketGreeksResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesMarketImpliedVolatilityWithResponseSync To get all pages of market implied volatility
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesMarketImpliedVolatility
+// Returning: api.GetTimeseriesMarketImpliedVolatilityResponse, error
+func (c CoinMetrics) GetTimeseriesMarketImpliedVolatilityWithResponseSync(ctx context.Context, params *api.GetTimeseriesMarketImpliedVolatilityParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesMarketImpliedVolatilityResponse, error) {
+	page := *params
+	data := []api.MarketImpliedVolatility{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesMarketImpliedVolatilityWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesMarketImpliedVolatilityResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesMarketLiquidationsWithResponseSync To get all pages of market liquidations
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesMarketLiquidations
+// Returning: api.GetTimeseriesMarketLiquidationsResponse, error
+func (c CoinMetrics) GetTimeseriesMarketLiquidationsWithResponseSync(ctx context.Context, params *api.GetTimeseriesMarketLiquidationsParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesMarketLiquidationsResponse, error) {
+	page := *params
+	data := []api.MarketLiquidation{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesMarketLiquidationsWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesMarketLiquidationsResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesMarketMetricsWithResponseSync To get all pages of market metrics
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesMarketMetrics
+// Returning: api.GetTimeseriesMarketMetricsResponse, error
+func (c CoinMetrics) GetTimeseriesMarketMetricsWithResponseSync(ctx context.Context, params *api.GetTimeseriesMarketMetricsParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesMarketMetricsResponse, error) {
+	page := *params
+	data := []interface{}{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesMarketMetricsWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesMarketMetricsResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records, err := pageRecords(res.JSON200.Data)
+		if err != nil {
+			return api.GetTimeseriesMarketMetricsResponse{}, err
+		}
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesMarketOpenInteresetWithResponseSync To get all pages of market open interest
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesMarketOpenIntereset
+// Returning: api.GetTimeseriesMarketOpenInteresetResponse, error
+func (c CoinMetrics) GetTimeseriesMarketOpenInteresetWithResponseSync(ctx context.Context, params *api.GetTimeseriesMarketOpenInteresetParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesMarketOpenInteresetResponse, error) {
+	page := *params
+	data := []api.MarketOpenInterest{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesMarketOpenInteresetWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesMarketOpenInteresetResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesMarketOrderbooksWithResponseSync To get all pages of market orderbooks
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesMarketOrderbooks
+// Returning: api.GetTimeseriesMarketOrderbooksResponse, error
+func (c CoinMetrics) GetTimeseriesMarketOrderbooksWithResponseSync(ctx context.Context, params *api.GetTimeseriesMarketOrderbooksParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesMarketOrderbooksResponse, error) {
+	page := *params
+	data := []api.MarketOrderBook{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesMarketOrderbooksWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesMarketOrderbooksResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesMarketQuotesWithResponseSync To get all pages of market quotes
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesMarketQuotes
+// Returning: api.GetTimeseriesMarketQuotesResponse, error
+func (c CoinMetrics) GetTimeseriesMarketQuotesWithResponseSync(ctx context.Context, params *api.GetTimeseriesMarketQuotesParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesMarketQuotesResponse, error) {
+	page := *params
+	data := []api.MarketQuote{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesMarketQuotesWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesMarketQuotesResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesMarketTradesWithResponseSync To get all pages of market trades
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesMarketTrades
+// Returning: api.GetTimeseriesMarketTradesResponse, error
+func (c CoinMetrics) GetTimeseriesMarketTradesWithResponseSync(ctx context.Context, params *api.GetTimeseriesMarketTradesParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesMarketTradesResponse, error) {
+	page := *params
+	data := []api.MarketTrade{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesMarketTradesWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesMarketTradesResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetMempoolFeeratesWithResponseSync To get all pages of mempool feerates
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getMempoolFeerates
+// Returning: api.GetMempoolFeeratesResponse, error
+func (c CoinMetrics) GetMempoolFeeratesWithResponseSync(ctx context.Context, params *api.GetMempoolFeeratesParams, reqEditors ...api.RequestEditorFn) (api.GetMempoolFeeratesResponse, error) {
+	page := *params
+	data := []api.MempoolFeerate{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.MempoolFeeratesPageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetMempoolFeeratesWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetMempoolFeeratesResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesMiningPoolTipsSummaryWithResponseSync To get all pages of mining pool tips summary
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesMiningPoolTipsSummary
+// Returning: api.GetTimeseriesMiningPoolTipsSummaryResponse, error
+func (c CoinMetrics) GetTimeseriesMiningPoolTipsSummaryWithResponseSync(ctx context.Context, params *api.GetTimeseriesMiningPoolTipsSummaryParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesMiningPoolTipsSummaryResponse, error) {
+	page := *params
+	data := []api.MiningPoolTipsSummary{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesMiningPoolTipsSummaryWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesMiningPoolTipsSummaryResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records := res.JSON200.Data
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
+
+// GetTimeseriesPairMetricsWithResponseSync To get all pages of pair metrics
+// ApiEndpoint: https://docs.coinmetrics.io/api/v4#operation/getTimeseriesPairMetrics
+// Returning: api.GetTimeseriesPairMetricsResponse, error
+func (c CoinMetrics) GetTimeseriesPairMetricsWithResponseSync(ctx context.Context, params *api.GetTimeseriesPairMetricsParams, reqEditors ...api.RequestEditorFn) (api.GetTimeseriesPairMetricsResponse, error) {
+	page := *params
+	data := []interface{}{}
+	for {
+		if size, ok := nextPageSize(len(data)); ok {
+			pageSize := api.PageSize(size)
+			page.PageSize = &pageSize
+		}
+		res, err := c.GetTimeseriesPairMetricsWithResponse(ctx, &page, reqEditors...)
+		if err != nil {
+			return api.GetTimeseriesPairMetricsResponse{}, err
+		}
+		if res.JSON200 == nil {
+			return *res, nil
+		}
+		records, err := pageRecords(res.JSON200.Data)
+		if err != nil {
+			return api.GetTimeseriesPairMetricsResponse{}, err
+		}
+		data = append(data, records...)
+		// empty page ends paging even with next page token, so paging can not loop forever
+		if len(records) == 0 || res.JSON200.NextPageToken == nil || limitReached(len(data)) {
+			res.JSON200.Data = data[:limited(len(data))]
+			res.JSON200.NextPageToken = nil
+			res.JSON200.NextPageUrl = nil
+			return *res, nil
+		}
+		page.NextPageToken = res.JSON200.NextPageToken
+	}
+}
@@ -0,0 +1,50 @@
+package coinmetrics
+
+import (
+	"fmt"
+
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// WithResponseSync wrappers of paginated operations are generated from spec, they fetch pages until there is no next
+// page token or global Limit is reached. Params of caller are not modified. Response of failed page is returned as is,
+// otherwise JSON200 of the last page holds records of all pages. Empty page ends paging.
+
+//go:generate go run ../cmd/apigen sync -o sync.gen.go
+
+// nextPageSize returns page size fetching at most remaining records of global Limit, ok is false without limit
+func nextPageSize(collected int) (int32, bool) {
+	if limit <= 0 {
+		return 0, false
+	}
+	remaining := limit - int32(collected)
+	if remaining > constants.DefaultPageSize {
+		remaining = constants.DefaultPageSize
+	}
+	return remaining, true
+}
+
+// limitReached tells whether global Limit of records is collected
+func limitReached(collected int) bool {
+	return limit > 0 && int32(collected) >= limit
+}
+
+// limited returns number of collected records kept by global Limit
+func limited(collected int) int {
+	if limitReached(collected) {
+		return int(limit)
+	}
+	return collected
+}
+
+// pageRecords returns records of page decoded as interface, data which is not list is error
+func pageRecords(data interface{}) ([]interface{}, error) {
+	if data == nil {
+		return nil, nil
+	}
+	records, ok := data.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf(`%s: %T`, constants.UnexpectedData, data)
+	}
+	return records, nil
+}
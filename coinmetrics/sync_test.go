@@ -0,0 +1,167 @@
+package coinmetrics_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/rulesng/coinmetrics-go-sdk/mock"
+	"github.com/rulesng/coinmetrics-go-sdk/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+const seriesLength = 120
+
+func newClient(t *testing.T) (*mock.Server, coinmetrics.CoinMetrics) {
+	server, err := mock.NewServer(mock.WithSeriesLength(seriesLength))
+	assert.Nil(t, err)
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	client, err := coinmetrics.InitClient(ts.URL+`/`, constants.TestKey)
+	assert.Nil(t, err)
+	return server, client
+}
+
+// requiredParams fills required query params of spec left empty, so params of every operation can be zero
+func requiredParams(ctx context.Context, req *http.Request) error {
+	spec, err := openapi.V4()
+	if err != nil {
+		return err
+	}
+	route, _, ok := openapi.Match(spec.Routes(), strings.TrimPrefix(req.URL.Path, `/`+constants.ApiVersion))
+	if !ok {
+		return nil
+	}
+	q := req.URL.Query()
+	for _, p := range route.Parameters {
+		if p.In == `query` && p.Required && q.Get(p.Name) == `` {
+			q.Set(p.Name, `btc`)
+		}
+	}
+	req.URL.RawQuery = q.Encode()
+	return nil
+}
+
+func TestEverySyncWrapper(t *testing.T) {
+	_, client := newClient(t)
+	value := reflect.ValueOf(client)
+	wrappers := 0
+	for i := 0; i < value.NumMethod(); i++ {
+		method := value.Type().Method(i)
+		if !strings.HasSuffix(method.Name, `WithResponseSync`) || strings.HasPrefix(method.Name, `GetCatalogAll`) {
+			continue
+		}
+		wrappers++
+		methodType := value.Method(i).Type()
+		args := []reflect.Value{reflect.ValueOf(context.Background())}
+		for j := 1; j < methodType.NumIn()-1; j++ {
+			in := methodType.In(j)
+			if in.Kind() == reflect.Ptr {
+				args = append(args, reflect.New(in.Elem()))
+			} else {
+				args = append(args, reflect.ValueOf(`btc`).Convert(in))
+			}
+		}
+		args = append(args, reflect.ValueOf(api.RequestEditorFn(requiredParams)))
+		out := value.Method(i).Call(args)
+		if err, _ := out[1].Interface().(error); !assert.Nil(t, err, method.Name) {
+			continue
+		}
+		json200 := out[0].FieldByName(`JSON200`)
+		if !assert.False(t, json200.IsNil(), method.Name) {
+			continue
+		}
+		data := json200.Elem().FieldByName(`Data`)
+		if data.Kind() == reflect.Interface {
+			data = data.Elem()
+		}
+		// all pages of default size are fetched
+		assert.True(t, data.Len() > int(constants.DefaultPageSize) && data.Len()%seriesLength == 0, `%s: %d records`, method.Name, data.Len())
+		assert.True(t, json200.Elem().FieldByName(`NextPageToken`).IsNil(), method.Name)
+	}
+	assert.True(t, wrappers > 20)
+}
+
+func TestSyncLimit(t *testing.T) {
+	server, client := newClient(t)
+	client.Limit(150)
+	defer client.Limit(-1)
+	params := api.GetTimeseriesAssetMetricsParams{Assets: `btc,eth`, Metrics: api.AssetMetrics{`PriceUSD`}}
+	res, err := client.GetTimeseriesAssetMetricsWithResponseSync(context.Background(), &params)
+	assert.Nil(t, err)
+	if assert.NotNil(t, res.JSON200) {
+		assert.Len(t, res.JSON200.Data, 150)
+	}
+	assert.Equal(t, 2, server.Calls(`/timeseries/asset-metrics`))
+	assert.Nil(t, params.NextPageToken)
+	assert.Nil(t, params.PageSize)
+
+	client.Limit(30)
+	candles, err := client.GetTimeseriesMarketCandlesSync(context.Background(), &api.GetTimeseriesMarketCandlesParams{Markets: `coinbase-btc-usd-spot`})
+	assert.Nil(t, err)
+	if assert.NotNil(t, candles.JSON200) {
+		assert.Len(t, candles.JSON200.Data, 30)
+		assert.Equal(t, api.MarketId(`coinbase-btc-usd-spot`), candles.JSON200.Data[0].Market)
+	}
+}
+
+func TestSyncError(t *testing.T) {
+	server, client := newClient(t)
+	server.InjectError(`/timeseries/market-greeks`, http.StatusForbidden, 1)
+	res, err := client.GetTimeseriesMarketGreeksWithResponseSync(context.Background(), &api.GetTimeseriesMarketGreeksParams{Markets: `deribit-BTC-25MAR22-40000-C-option`})
+	assert.Nil(t, err)
+	assert.Nil(t, res.JSON200)
+	assert.NotNil(t, res.JSON403)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode())
+
+	res, err = client.GetTimeseriesMarketGreeksWithResponseSync(context.Background(), &api.GetTimeseriesMarketGreeksParams{Markets: `deribit-BTC-25MAR22-40000-C-option`})
+	assert.Nil(t, err)
+	if assert.NotNil(t, res.JSON200) {
+		assert.Len(t, res.JSON200.Data, seriesLength)
+	}
+}
+
+// newPagesClient serves body for every request and counts calls
+func newPagesClient(t *testing.T, body string) (*int, coinmetrics.CoinMetrics) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.Header().Set(`Content-Type`, `application/json`)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(ts.Close)
+	client, err := coinmetrics.InitClient(ts.URL+`/`, constants.TestKey)
+	assert.Nil(t, err)
+	return &calls, client
+}
+
+func TestSyncEmptyPage(t *testing.T) {
+	calls, client := newPagesClient(t, `{"data":[],"next_page_token":"next"}`)
+	res, err := client.GetBlockchainV2ListOfBlocksWithResponseSync(context.Background(), `btc`, &api.GetBlockchainV2ListOfBlocksParams{})
+	assert.Nil(t, err)
+	if assert.NotNil(t, res.JSON200) {
+		assert.Empty(t, res.JSON200.Data)
+		assert.Nil(t, res.JSON200.NextPageToken)
+	}
+	assert.Equal(t, 1, *calls)
+
+	calls, client = newPagesClient(t, `{"data":[],"next_page_token":"next"}`)
+	candles, err := client.GetTimeseriesMarketCandlesWithResponseSync(context.Background(), &api.GetTimeseriesMarketCandlesParams{Markets: `coinbase-btc-usd-spot`})
+	assert.Nil(t, err)
+	assert.Empty(t, candles.JSON200.Data)
+	assert.Equal(t, 1, *calls)
+}
+
+func TestSyncUnexpectedData(t *testing.T) {
+	_, client := newPagesClient(t, `{"data":{"block_hash":"abc"},"next_page_token":"next"}`)
+	_, err := client.GetBlockchainV2ListOfBlocksWithResponseSync(context.Background(), `btc`, &api.GetBlockchainV2ListOfBlocksParams{})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, constants.UnexpectedData+`: map[string]interface {}`, err.Error())
+	}
+}
@@ -9,6 +9,77 @@ const (
 	// NoDataFound Error message
 	NoDataFound = `no data found`
 
+	// InvalidMarketId Error message
+	InvalidMarketId = `invalid market id`
+
+	// UnsupportedParams Error message
+	UnsupportedParams = `unsupported params`
+
+	// StopWalk Error message
+	StopWalk = `stop walk`
+
+	// ReorgTooDeep Error message
+	ReorgTooDeep = `reorg deeper than reorg window`
+
+	// InvalidAmount Error message
+	InvalidAmount = `invalid amount`
+
+	// UnsupportedAlertRule Error message
+	UnsupportedAlertRule = `unsupported alert rule`
+
+	// WebhookFailed Error message
+	WebhookFailed = `webhook failed`
+
+	// UnknownCommand Error message
+	UnknownCommand = `unknown command`
+
+	// MissingFlag Error message
+	MissingFlag = `missing required flag`
+
+	// UnsupportedOutput Error message
+	UnsupportedOutput = `unsupported output format`
+
+	// InvalidJob Error message
+	InvalidJob = `invalid export job`
+
+	// UnsupportedCassetteMode Error message
+	UnsupportedCassetteMode = `unsupported cassette mode`
+
+	// CassetteMiss Error message
+	CassetteMiss = `no recorded interaction`
+
+	// SchemaViolation Error message
+	SchemaViolation = `response does not match schema`
+
+	// InvalidPatch Error message
+	InvalidPatch = `invalid overlay patch`
+
+	// NotTerminal Error message
+	NotTerminal = `not a terminal`
+
+	// UnexpectedData Error message
+	UnexpectedData = `unexpected data of page`
+
+	// NoEffectiveWeights Error message
+	NoEffectiveWeights = `no constituents effective at start of period`
+
+	// AlreadyStarted Error message
+	AlreadyStarted = `already started`
+
+	// ErrorTypeBadParameter Type of api error response rejecting request param
+	ErrorTypeBadParameter = `bad_parameter`
+	// ErrorTypeForbidden Type of api error response for resource not available to key
+	ErrorTypeForbidden = `forbidden`
+	// BadParameterPattern Matches name of rejected param in message of bad_parameter error, like `Bad parameter 'asset'.`
+	BadParameterPattern = `(?i)parameter\s+'([^']+)'`
+
+	// DefaultFrequency Frequency used by api when frequency param is omitted
+	DefaultFrequency = `1d`
+
+	// Endpoint of api, CommunityEndpoint is used without api key
+	Endpoint          = `https://api.coinmetrics.io/`
+	CommunityEndpoint = `https://community-api.coinmetrics.io/`
+
 	// TestEndpoint Test
 	TestEndpoint = `https://fake-endpoint.com/`
 	TestKey      = `abc`
@@ -0,0 +1,442 @@
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// Defaults of Reconstruct
+const (
+	DefaultPriceMetric = `ReferenceRateUSD`
+	DefaultFrequency   = `1d`
+)
+
+// Level is published level of index
+type Level struct {
+	Time  time.Time
+	Level float64
+}
+
+// Weights are constituent weights of index by asset, effective from Time
+type Weights struct {
+	Time    time.Time
+	Weights map[string]float64
+}
+
+// Prices are asset prices by asset and time
+type Prices map[string]map[time.Time]float64
+
+// Point compares reconstructed index with published one at Time. Returns are relative to previous point,
+// Contributions are weight times return of every constituent and Residual is published return not explained by them.
+type Point struct {
+	Time                time.Time
+	Published           float64
+	Reconstructed       float64
+	PublishedReturn     float64
+	ReconstructedReturn float64
+	Contributions       map[string]float64
+	Residual            float64
+	// Missing are constituents without prices, they do not contribute to reconstructed return
+	Missing []string
+}
+
+// Reconstruction is index recomputed from constituent weights and prices. TrackingError is standard deviation
+// of differences between published and reconstructed returns, MaxDeviation is largest relative difference of levels.
+// Attribution sums contributions of every constituent over whole interval.
+type Reconstruction struct {
+	Index         string
+	Points        []Point
+	TrackingError float64
+	MaxDeviation  float64
+	Attribution   map[string]float64
+	Residual      float64
+}
+
+type options struct {
+	priceMetric string
+	frequency   string
+	reqEditors  []api.RequestEditorFn
+}
+
+// Option allows to customize Reconstruct and loading of its inputs
+type Option func(*options)
+
+func newOptions(opts []Option) options {
+	o := options{priceMetric: DefaultPriceMetric, frequency: DefaultFrequency}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithPriceMetric sets asset metric used as price of constituents
+func WithPriceMetric(metric string) Option {
+	return func(o *options) {
+		o.priceMetric = metric
+	}
+}
+
+// WithFrequency sets frequency of levels, constituents and prices
+func WithFrequency(frequency string) Option {
+	return func(o *options) {
+		o.frequency = frequency
+	}
+}
+
+// WithRequestEditors are passed to every call made by Reconstruct
+func WithRequestEditors(fns ...api.RequestEditorFn) Option {
+	return func(o *options) {
+		o.reqEditors = append(o.reqEditors, fns...)
+	}
+}
+
+// Reconstruct loads levels, constituents and constituent prices of index between start and end and recomputes index
+func Reconstruct(ctx context.Context, client api.ClientWithResponsesInterface, index string, start, end time.Time, opts ...Option) (*Reconstruction, error) {
+	levels, err := LoadLevels(ctx, client, index, start, end, opts...)
+	if err != nil {
+		return nil, err
+	}
+	weights, err := LoadWeights(ctx, client, index, start, end, opts...)
+	if err != nil {
+		return nil, err
+	}
+	assets := map[string]bool{}
+	for _, w := range weights {
+		for asset := range w.Weights {
+			assets[asset] = true
+		}
+	}
+	prices, err := LoadPrices(ctx, client, keys(assets), start, end, opts...)
+	if err != nil {
+		return nil, err
+	}
+	reconstruction, err := Compute(levels, weights, prices)
+	if err != nil {
+		return nil, err
+	}
+	reconstruction.Index = index
+	return reconstruction, nil
+}
+
+// Compute recomputes index from levels ordered by time. Return of every period is sum of constituent returns weighted by
+// weights effective at start of period, reconstructed levels are chained from first published level.
+func Compute(levels []Level, weights []Weights, prices Prices) (*Reconstruction, error) {
+	if len(levels) == 0 || len(weights) == 0 {
+		return nil, errors.New(constants.NoDataFound)
+	}
+	weights = append([]Weights(nil), weights...)
+	sort.SliceStable(weights, func(i, j int) bool {
+		return weights[i].Time.Before(weights[j].Time)
+	})
+
+	reconstruction := Reconstruction{Attribution: map[string]float64{}}
+	first := levels[0]
+	reconstruction.Points = append(reconstruction.Points, Point{Time: first.Time, Published: first.Level, Reconstructed: first.Level})
+	var differences []float64
+	for i := 1; i < len(levels); i++ {
+		previous, current := levels[i-1], levels[i]
+		point := Point{
+			Time:            current.Time,
+			Published:       current.Level,
+			PublishedReturn: current.Level/previous.Level - 1,
+			Contributions:   map[string]float64{},
+		}
+		effectiveWeights, err := effective(weights, previous.Time)
+		if err != nil {
+			return nil, err
+		}
+		for asset, weight := range effectiveWeights {
+			from, okFrom := prices[asset][previous.Time]
+			to, okTo := prices[asset][current.Time]
+			if !okFrom || !okTo || from == 0 {
+				point.Missing = append(point.Missing, asset)
+				continue
+			}
+			contribution := weight * (to/from - 1)
+			point.Contributions[asset] = contribution
+			point.ReconstructedReturn += contribution
+			reconstruction.Attribution[asset] += contribution
+		}
+		sort.Strings(point.Missing)
+		point.Residual = point.PublishedReturn - point.ReconstructedReturn
+		point.Reconstructed = reconstruction.Points[i-1].Reconstructed * (1 + point.ReconstructedReturn)
+		reconstruction.Residual += point.Residual
+		reconstruction.MaxDeviation = math.Max(reconstruction.MaxDeviation, math.Abs(point.Reconstructed/point.Published-1))
+		differences = append(differences, point.Residual)
+		reconstruction.Points = append(reconstruction.Points, point)
+	}
+	reconstruction.TrackingError = deviation(differences)
+	return &reconstruction, nil
+}
+
+// effective returns weights effective at t, that is latest weights not after t. Weights published later are never used
+// for earlier periods.
+func effective(weights []Weights, t time.Time) (map[string]float64, error) {
+	i := sort.Search(len(weights), func(i int) bool {
+		return weights[i].Time.After(t)
+	})
+	if i == 0 {
+		return nil, fmt.Errorf(`%s: %s`, constants.NoEffectiveWeights, t.Format(time.RFC3339))
+	}
+	return weights[i-1].Weights, nil
+}
+
+// deviation returns sample standard deviation of values
+func deviation(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	var mean float64
+	for _, value := range values {
+		mean += value
+	}
+	mean /= float64(len(values))
+	var variance float64
+	for _, value := range values {
+		variance += (value - mean) * (value - mean)
+	}
+	return math.Sqrt(variance / float64(len(values)-1))
+}
+
+// LoadLevels fetches published levels of index ordered by time
+func LoadLevels(ctx context.Context, client api.ClientWithResponsesInterface, index string, start, end time.Time, opts ...Option) ([]Level, error) {
+	o := newOptions(opts)
+	pageSize := api.PageSize(constants.DefaultPageSize)
+	frequency := api.IndexFrequency(o.frequency)
+	startTime, endTime := interval(start, end)
+	params := api.GetTimeseriesIndexLevelsParams{Indexes: api.IndexId(index), Frequency: &frequency, StartTime: startTime, EndTime: endTime, PageSize: &pageSize}
+	var levels []Level
+	for {
+		res, err := client.GetTimeseriesIndexLevelsWithResponse(ctx, &params, o.reqEditors...)
+		if err != nil {
+			return nil, err
+		}
+		if res.JSON200 == nil {
+			return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+		}
+		for _, level := range res.JSON200.Data {
+			t, err := time.Parse(time.RFC3339Nano, level.Time)
+			if err != nil {
+				return nil, err
+			}
+			value, err := strconv.ParseFloat(string(level.Level), 64)
+			if err != nil {
+				return nil, err
+			}
+			levels = append(levels, Level{Time: t, Level: value})
+		}
+		if res.JSON200.NextPageToken == nil {
+			break
+		}
+		params.NextPageToken = res.JSON200.NextPageToken
+	}
+	sort.SliceStable(levels, func(i, j int) bool {
+		return levels[i].Time.Before(levels[j].Time)
+	})
+	return levels, nil
+}
+
+// LoadWeights fetches constituent weights of index ordered by time. Weights effective at start, that is latest
+// constituents published at or before start, are included too.
+func LoadWeights(ctx context.Context, client api.ClientWithResponsesInterface, index string, start, end time.Time, opts ...Option) ([]Weights, error) {
+	o := newOptions(opts)
+	pageSize := api.PageSize(constants.DefaultPageSize)
+	frequency := api.IndexConstituentsFrequency(o.frequency)
+	startTime, endTime := interval(start, end)
+	var weights []Weights
+	if startTime != nil {
+		initial, err := initialWeights(ctx, client, index, start, o)
+		if err != nil {
+			return nil, err
+		}
+		weights = append(weights, initial...)
+	}
+	params := api.GetTimeseriesIndexConstituentsParams{Indexes: api.IndexId(index), Frequency: &frequency, StartTime: startTime, EndTime: endTime, PageSize: &pageSize}
+	for {
+		res, err := client.GetTimeseriesIndexConstituentsWithResponse(ctx, &params, o.reqEditors...)
+		if err != nil {
+			return nil, err
+		}
+		if res.JSON200 == nil {
+			return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+		}
+		page, err := parseWeights(res.JSON200.Data)
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range page {
+			// weights at start are already loaded as initial
+			if len(weights) == 0 || w.Time.After(weights[0].Time) {
+				weights = append(weights, w)
+			}
+		}
+		if res.JSON200.NextPageToken == nil {
+			break
+		}
+		params.NextPageToken = res.JSON200.NextPageToken
+	}
+	sort.SliceStable(weights, func(i, j int) bool {
+		return weights[i].Time.Before(weights[j].Time)
+	})
+	return weights, nil
+}
+
+// initialWeights fetches latest constituents published at or before start
+func initialWeights(ctx context.Context, client api.ClientWithResponsesInterface, index string, start time.Time, o options) ([]Weights, error) {
+	pageSize := api.PageSize(1)
+	frequency := api.IndexConstituentsFrequency(o.frequency)
+	pagingFrom := api.GetTimeseriesIndexConstituentsParamsPagingFrom(`end`)
+	_, endTime := interval(time.Time{}, start)
+	params := api.GetTimeseriesIndexConstituentsParams{Indexes: api.IndexId(index), Frequency: &frequency, EndTime: endTime, PageSize: &pageSize, PagingFrom: &pagingFrom}
+	res, err := client.GetTimeseriesIndexConstituentsWithResponse(ctx, &params, o.reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	if res.JSON200 == nil {
+		return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+	}
+	weights, err := parseWeights(res.JSON200.Data)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(weights) - 1; i >= 0; i-- {
+		if !weights[i].Time.After(start) {
+			return weights[i : i+1], nil
+		}
+	}
+	return nil, nil
+}
+
+func parseWeights(data []api.IndexConstituents) ([]Weights, error) {
+	weights := make([]Weights, 0, len(data))
+	for _, constituents := range data {
+		t, err := time.Parse(time.RFC3339Nano, constituents.Time)
+		if err != nil {
+			return nil, err
+		}
+		w := Weights{Time: t, Weights: map[string]float64{}}
+		for _, constituent := range constituents.Constituents {
+			if constituent.Asset == nil || constituent.Weight == nil {
+				continue
+			}
+			weight, err := strconv.ParseFloat(string(*constituent.Weight), 64)
+			if err != nil {
+				return nil, err
+			}
+			w.Weights[string(*constituent.Asset)] = weight
+		}
+		weights = append(weights, w)
+	}
+	return weights, nil
+}
+
+// LoadPrices fetches price metric of assets, null prices are left out and reported as missing by Compute
+func LoadPrices(ctx context.Context, client api.ClientWithResponsesInterface, assets []string, start, end time.Time, opts ...Option) (Prices, error) {
+	o := newOptions(opts)
+	pageSize := api.PageSize(constants.DefaultPageSize)
+	frequency := api.AssetMetricsFrequency(o.frequency)
+	startTime, endTime := interval(start, end)
+	params := api.GetTimeseriesAssetMetricsParams{
+		Assets:    api.AssetId(strings.Join(assets, `,`)),
+		Metrics:   api.AssetMetrics{o.priceMetric},
+		Frequency: &frequency,
+		StartTime: startTime,
+		EndTime:   endTime,
+		PageSize:  &pageSize,
+	}
+	prices := Prices{}
+	for {
+		res, err := client.GetTimeseriesAssetMetricsWithResponse(ctx, &params, o.reqEditors...)
+		if err != nil {
+			return nil, err
+		}
+		if res.JSON200 == nil {
+			return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+		}
+		raw, err := json.Marshal(res.JSON200.Data)
+		if err != nil {
+			return nil, err
+		}
+		var rows []map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &rows); err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			// null price is missing, Compute reports it
+			price, ok, err := parsePrice(row[o.priceMetric])
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			var asset, timestamp string
+			if err := json.Unmarshal(row[`asset`], &asset); err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(row[`time`], &timestamp); err != nil {
+				return nil, err
+			}
+			t, err := time.Parse(time.RFC3339Nano, timestamp)
+			if err != nil {
+				return nil, err
+			}
+			if prices[asset] == nil {
+				prices[asset] = map[time.Time]float64{}
+			}
+			prices[asset][t] = price
+		}
+		if res.JSON200.NextPageToken == nil {
+			break
+		}
+		params.NextPageToken = res.JSON200.NextPageToken
+	}
+	return prices, nil
+}
+
+// parsePrice parses metric value sent as string or number, ok is false when value is absent or null
+func parsePrice(raw json.RawMessage) (price float64, ok bool, err error) {
+	if len(raw) == 0 || string(raw) == `null` {
+		return 0, false, nil
+	}
+	var value json.Number
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return 0, false, err
+	}
+	price, err = value.Float64()
+	return price, err == nil, err
+}
+
+func interval(start, end time.Time) (*api.StartTime, *api.EndTime) {
+	var startTime *api.StartTime
+	var endTime *api.EndTime
+	if !start.IsZero() {
+		value := api.StartTime(start.UTC().Format(time.RFC3339Nano))
+		startTime = &value
+	}
+	if !end.IsZero() {
+		value := api.EndTime(end.UTC().Format(time.RFC3339Nano))
+		endTime = &value
+	}
+	return startTime, endTime
+}
+
+func keys(set map[string]bool) []string {
+	result := make([]string, 0, len(set))
+	for key := range set {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
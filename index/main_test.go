@@ -0,0 +1,166 @@
+package index_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/rulesng/coinmetrics-go-sdk/index"
+	"github.com/stretchr/testify/assert"
+)
+
+var _coinmetrics coinmetrics.CoinMetrics
+
+func TestMain(m *testing.M) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	var _err error
+	_coinmetrics, _err = coinmetrics.InitClient(constants.TestEndpoint, constants.TestKey)
+	if _err != nil {
+		fmt.Println(_err)
+	}
+	os.Exit(m.Run())
+}
+
+func registerJson(t *testing.T, path string, pages ...string) {
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf(`%s%s%s`, constants.TestEndpoint, constants.ApiVersion, path), func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, `1d`, req.URL.Query().Get(`frequency`))
+		body := pages[0]
+		if req.URL.Query().Get(`next_page_token`) == `page2` {
+			body = pages[1]
+		}
+		resp := httpmock.NewStringResponse(http.StatusOK, body)
+		resp.Header.Set(`Content-Type`, `application/json`)
+		return resp, nil
+	})
+}
+
+func TestReconstruct(t *testing.T) {
+	registerJson(t, `/timeseries/index-levels`, `{"data":[
+		{"index":"CMBI2","time":"2022-05-01T00:00:00.000000000Z","level":"1000"},
+		{"index":"CMBI2","time":"2022-05-02T00:00:00.000000000Z","level":"1100"}
+	],"next_page_token":"page2"}`, `{"data":[
+		{"index":"CMBI2","time":"2022-05-03T00:00:00.000000000Z","level":"1045"}
+	]}`)
+	registerJson(t, `/timeseries/index-constituents`, `{"data":[
+		{"index":"CMBI2","time":"2022-05-01T00:00:00.000000000Z","constituents":[{"asset":"btc","weight":"0.6"},{"asset":"eth","weight":"0.4"}]},
+		{"index":"CMBI2","time":"2022-05-02T00:00:00.000000000Z","constituents":[{"asset":"btc","weight":"0.5"},{"asset":"eth","weight":"0.5"}]}
+	]}`)
+	registerJson(t, `/timeseries/asset-metrics`, `{"data":[
+		{"asset":"btc","time":"2022-05-01T00:00:00.000000000Z","ReferenceRateUSD":"100"},
+		{"asset":"btc","time":"2022-05-02T00:00:00.000000000Z","ReferenceRateUSD":"110"},
+		{"asset":"btc","time":"2022-05-03T00:00:00.000000000Z","ReferenceRateUSD":"99"},
+		{"asset":"eth","time":"2022-05-01T00:00:00.000000000Z","ReferenceRateUSD":"10"},
+		{"asset":"eth","time":"2022-05-02T00:00:00.000000000Z","ReferenceRateUSD":"11.5"},
+		{"asset":"eth","time":"2022-05-03T00:00:00.000000000Z","ReferenceRateUSD":"11"}
+	]}`)
+
+	start := time.Date(2022, time.May, 1, 0, 0, 0, 0, time.UTC)
+	reconstruction, err := index.Reconstruct(context.Background(), _coinmetrics, `CMBI2`, start, start.Add(48*time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, `CMBI2`, reconstruction.Index)
+	assert.Equal(t, 3, len(reconstruction.Points))
+
+	second := reconstruction.Points[1]
+	assert.InDelta(t, 0.1, second.PublishedReturn, 1e-9)
+	assert.InDelta(t, 0.12, second.ReconstructedReturn, 1e-9)
+	assert.InDelta(t, 1120, second.Reconstructed, 1e-9)
+	assert.InDelta(t, 0.06, second.Contributions[`eth`], 1e-9)
+	assert.InDelta(t, -0.02, second.Residual, 1e-9)
+
+	// weights of second day apply to third day returns
+	third := reconstruction.Points[2]
+	ethReturn := 11/11.5 - 1
+	assert.InDelta(t, -0.05, third.Contributions[`btc`], 1e-9)
+	assert.InDelta(t, 0.5*ethReturn, third.Contributions[`eth`], 1e-9)
+	assert.InDelta(t, 1120*(1-0.05+0.5*ethReturn), third.Reconstructed, 1e-9)
+
+	assert.InDelta(t, 0.01, reconstruction.Attribution[`btc`], 1e-9)
+	assert.InDelta(t, 0.06+0.5*ethReturn, reconstruction.Attribution[`eth`], 1e-9)
+	residuals := []float64{second.Residual, third.Residual}
+	mean := (residuals[0] + residuals[1]) / 2
+	assert.InDelta(t, math.Sqrt(math.Pow(residuals[0]-mean, 2)+math.Pow(residuals[1]-mean, 2)), reconstruction.TrackingError, 1e-9)
+	assert.InDelta(t, 1120.0/1100-1, reconstruction.MaxDeviation, 1e-9)
+}
+
+func TestComputeMissingPrices(t *testing.T) {
+	start := time.Date(2022, time.May, 1, 0, 0, 0, 0, time.UTC)
+	next := start.Add(24 * time.Hour)
+	reconstruction, err := index.Compute(
+		[]index.Level{{Time: start, Level: 100}, {Time: next, Level: 102}},
+		[]index.Weights{{Time: start, Weights: map[string]float64{`btc`: 0.5, `sol`: 0.5}}},
+		index.Prices{`btc`: {start: 10, next: 10.4}},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`sol`}, reconstruction.Points[1].Missing)
+	assert.InDelta(t, 0.02, reconstruction.Points[1].ReconstructedReturn, 1e-9)
+	assert.InDelta(t, 0, reconstruction.Points[1].Residual, 1e-9)
+
+	_, err = index.Compute(nil, nil, nil)
+	assert.EqualError(t, err, constants.NoDataFound)
+}
+
+func TestLoadPricesNullValues(t *testing.T) {
+	registerJson(t, `/timeseries/asset-metrics`, `{"data":[
+		{"asset":"btc","time":"2022-05-01T00:00:00.000000000Z","ReferenceRateUSD":"100"},
+		{"asset":"sol","time":"2022-05-01T00:00:00.000000000Z","ReferenceRateUSD":null},
+		{"asset":"eth","time":"2022-05-01T00:00:00.000000000Z","ReferenceRateUSD":10.5}
+	]}`)
+	start := time.Date(2022, time.May, 1, 0, 0, 0, 0, time.UTC)
+	prices, err := index.LoadPrices(context.Background(), _coinmetrics, []string{`btc`, `eth`, `sol`}, start, start)
+	assert.Nil(t, err)
+	assert.Equal(t, index.Prices{`btc`: {start: 100}, `eth`: {start: 10.5}}, prices)
+}
+
+func TestReconstructUsesWeightsBeforeStart(t *testing.T) {
+	registerJson(t, `/timeseries/index-levels`, `{"data":[
+		{"index":"CMBI2","time":"2022-05-01T00:00:00.000000000Z","level":"1000"},
+		{"index":"CMBI2","time":"2022-05-02T00:00:00.000000000Z","level":"1100"},
+		{"index":"CMBI2","time":"2022-05-03T00:00:00.000000000Z","level":"1045"}
+	]}`)
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf(`%s%s/timeseries/index-constituents`, constants.TestEndpoint, constants.ApiVersion), func(req *http.Request) (*http.Response, error) {
+		body := `{"data":[{"index":"CMBI2","time":"2022-05-02T00:00:00.000000000Z","constituents":[{"asset":"btc","weight":"0.5"},{"asset":"eth","weight":"0.5"}]}]}`
+		if req.URL.Query().Get(`paging_from`) == `end` {
+			assert.Equal(t, `2022-05-01T00:00:00Z`, req.URL.Query().Get(`end_time`))
+			assert.Equal(t, `1`, req.URL.Query().Get(`page_size`))
+			body = `{"data":[{"index":"CMBI2","time":"2022-04-15T00:00:00.000000000Z","constituents":[{"asset":"btc","weight":"0.6"},{"asset":"eth","weight":"0.4"}]}]}`
+		}
+		resp := httpmock.NewStringResponse(http.StatusOK, body)
+		resp.Header.Set(`Content-Type`, `application/json`)
+		return resp, nil
+	})
+	registerJson(t, `/timeseries/asset-metrics`, `{"data":[
+		{"asset":"btc","time":"2022-05-01T00:00:00.000000000Z","ReferenceRateUSD":"100"},
+		{"asset":"btc","time":"2022-05-02T00:00:00.000000000Z","ReferenceRateUSD":"110"},
+		{"asset":"btc","time":"2022-05-03T00:00:00.000000000Z","ReferenceRateUSD":"99"},
+		{"asset":"eth","time":"2022-05-01T00:00:00.000000000Z","ReferenceRateUSD":"10"},
+		{"asset":"eth","time":"2022-05-02T00:00:00.000000000Z","ReferenceRateUSD":"11.5"},
+		{"asset":"eth","time":"2022-05-03T00:00:00.000000000Z","ReferenceRateUSD":"11"}
+	]}`)
+
+	start := time.Date(2022, time.May, 1, 0, 0, 0, 0, time.UTC)
+	reconstruction, err := index.Reconstruct(context.Background(), _coinmetrics, `CMBI2`, start, start.Add(48*time.Hour))
+	assert.Nil(t, err)
+	// first period is priced with weights of rebalance before start, not with later ones
+	assert.InDelta(t, 0.06, reconstruction.Points[1].Contributions[`btc`], 1e-9)
+	assert.InDelta(t, 0.06, reconstruction.Points[1].Contributions[`eth`], 1e-9)
+	assert.InDelta(t, -0.05, reconstruction.Points[2].Contributions[`btc`], 1e-9)
+}
+
+func TestComputeWithoutEffectiveWeights(t *testing.T) {
+	start := time.Date(2022, time.May, 1, 0, 0, 0, 0, time.UTC)
+	next := start.Add(24 * time.Hour)
+	_, err := index.Compute(
+		[]index.Level{{Time: start, Level: 100}, {Time: next, Level: 102}},
+		[]index.Weights{{Time: next, Weights: map[string]float64{`btc`: 1}}},
+		index.Prices{`btc`: {start: 10, next: 10.2}},
+	)
+	assert.EqualError(t, err, constants.NoEffectiveWeights+`: 2022-05-01T00:00:00Z`)
+}
@@ -0,0 +1,256 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// Market types supported by the api
+const (
+	Spot   api.MarketType = "spot"
+	Future api.MarketType = "future"
+	Option api.MarketType = "option"
+)
+
+// Option contract types as reported by the catalog
+const (
+	Call api.OptionContractType = "call"
+	Put  api.OptionContractType = "put"
+)
+
+const separator = `-`
+
+// ErrInvalidMarketId is returned when a market id can not be parsed
+var ErrInvalidMarketId = errors.New(constants.InvalidMarketId)
+
+// expiryLayouts are the expiration formats used by exchanges inside option symbols
+var expiryLayouts = []string{`2Jan06`, `02Jan06`, `060102`, `20060102`}
+
+// Market contains all parts of a market id.
+// Spot markets are identified by exchange, base and quote (and pool config for DeFi markets),
+// derivatives are identified by exchange and symbol.
+// For options the symbol is further split into underlying, expiry, strike and put/call.
+type Market struct {
+	Exchange string
+	Type     api.MarketType
+
+	Base  string
+	Quote string
+
+	// PoolConfigId is only set for DeFi spot markets like `uniswap_v2_eth-1-weth-usdc-spot`
+	PoolConfigId string
+
+	// Symbol is the full instrument name of derivative markets
+	Symbol string
+
+	Expiry string
+	Strike string
+
+	// PutCall is the raw put/call flag of an option symbol, `P` or `C`
+	PutCall string
+}
+
+// Parse splits market id into its parts and validates it against known market types.
+func Parse(id api.MarketId) (Market, error) {
+	parts := strings.Split(string(id), separator)
+	if len(parts) < 3 {
+		return Market{}, invalid(id, `expected at least 3 parts`)
+	}
+	for _, part := range parts {
+		if part == `` {
+			return Market{}, invalid(id, `empty part`)
+		}
+	}
+
+	m := Market{
+		Exchange: parts[0],
+		Type:     api.MarketType(parts[len(parts)-1]),
+	}
+	middle := parts[1 : len(parts)-1]
+
+	switch m.Type {
+	case Spot:
+		switch len(middle) {
+		case 2:
+			m.Base, m.Quote = middle[0], middle[1]
+		case 3:
+			m.PoolConfigId, m.Base, m.Quote = middle[0], middle[1], middle[2]
+		default:
+			return Market{}, invalid(id, `spot market must have base and quote`)
+		}
+	case Future:
+		m.Symbol = strings.Join(middle, separator)
+	case Option:
+		if len(middle) < 4 {
+			return Market{}, invalid(id, `option market must have underlying, expiry, strike and put/call`)
+		}
+		m.Symbol = strings.Join(middle, separator)
+		n := len(middle)
+		m.PutCall = middle[n-1]
+		m.Strike = middle[n-2]
+		m.Expiry = middle[n-3]
+		m.Base = middle[0]
+		if n-3 > 1 {
+			m.Quote = strings.Join(middle[1:n-3], separator)
+		}
+		if m.PutCall != `P` && m.PutCall != `C` {
+			return Market{}, invalid(id, `unknown put/call flag `+m.PutCall)
+		}
+	default:
+		return Market{}, invalid(id, `unknown market type `+string(m.Type))
+	}
+	return m, nil
+}
+
+// MustParse is like Parse but panics if market id can not be parsed.
+func MustParse(id api.MarketId) Market {
+	m, err := Parse(id)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// NewSpot builds spot market
+func NewSpot(exchange, base, quote string) Market {
+	return Market{Exchange: exchange, Type: Spot, Base: base, Quote: quote}
+}
+
+// NewFuture builds future market from exchange symbol
+func NewFuture(exchange, symbol string) Market {
+	return Market{Exchange: exchange, Type: Future, Symbol: symbol}
+}
+
+// NewOption builds option market, putCall accepts `P`, `C`, `put` or `call`
+func NewOption(exchange, underlying, expiry, strike, putCall string) Market {
+	m := Market{Exchange: exchange, Type: Option, Expiry: expiry, Strike: strike, PutCall: putCallFlag(putCall)}
+	if i := strings.Index(underlying, separator); i != -1 {
+		m.Base, m.Quote = underlying[:i], underlying[i+1:]
+	} else {
+		m.Base = underlying
+	}
+	return m
+}
+
+// FromInfo builds market from catalog market info, it keeps base and quote of derivatives as reported by catalog.
+func FromInfo(info api.MarketInfo) (Market, error) {
+	m, err := Parse(info.Market)
+	if err != nil {
+		return Market{}, err
+	}
+	if m.Type != Spot {
+		if info.Base != nil {
+			m.Base = string(*info.Base)
+		}
+		if info.Quote != nil {
+			m.Quote = string(*info.Quote)
+		}
+	}
+	return m, nil
+}
+
+// Id builds market id, Parse(m.Id()) returns same market
+func (m Market) Id() api.MarketId {
+	parts := []string{m.Exchange}
+	switch m.Type {
+	case Spot:
+		if m.PoolConfigId != `` {
+			parts = append(parts, m.PoolConfigId)
+		}
+		parts = append(parts, m.Base, m.Quote)
+	case Option:
+		parts = append(parts, m.OptionSymbol())
+	default:
+		parts = append(parts, m.Symbol)
+	}
+	parts = append(parts, string(m.Type))
+	return api.MarketId(strings.Join(parts, separator))
+}
+
+// String returns market id
+func (m Market) String() string {
+	return string(m.Id())
+}
+
+// Validate checks that all parts required by market type are present
+func (m Market) Validate() error {
+	_, err := Parse(m.Id())
+	return err
+}
+
+// OptionSymbol returns symbol of option market, it is built from its parts when symbol is not set
+func (m Market) OptionSymbol() string {
+	if m.Symbol != `` {
+		return m.Symbol
+	}
+	parts := []string{m.Base}
+	if m.Quote != `` {
+		parts = append(parts, m.Quote)
+	}
+	parts = append(parts, m.Expiry, m.Strike, m.PutCall)
+	return strings.Join(parts, separator)
+}
+
+// ContractType returns `call` or `put` for option markets
+func (m Market) ContractType() api.OptionContractType {
+	switch m.PutCall {
+	case `C`:
+		return Call
+	case `P`:
+		return Put
+	}
+	return ``
+}
+
+// ExpiryTime parses expiry of option market
+func (m Market) ExpiryTime() (time.Time, error) {
+	for _, layout := range expiryLayouts {
+		// month names are matched case insensitive so `25MAR22` is accepted
+		if t, err := time.Parse(layout, m.Expiry); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, invalid(m.Id(), `unknown expiry format `+m.Expiry)
+}
+
+// Ids builds list of market ids
+func Ids(markets ...Market) api.MarketsIds {
+	ids := make(api.MarketsIds, 0, len(markets))
+	for _, m := range markets {
+		ids = append(ids, m.Id())
+	}
+	return ids
+}
+
+// Join builds comma separated markets param accepted by timeseries calls
+func Join(markets ...Market) api.MarketId {
+	return JoinIds(Ids(markets...)...)
+}
+
+// JoinIds builds comma separated markets param from market ids
+func JoinIds(ids ...api.MarketId) api.MarketId {
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, string(id))
+	}
+	return api.MarketId(strings.Join(values, `,`))
+}
+
+func putCallFlag(putCall string) string {
+	switch strings.ToLower(putCall) {
+	case `c`, string(Call):
+		return `C`
+	case `p`, string(Put):
+		return `P`
+	}
+	return putCall
+}
+
+func invalid(id api.MarketId, reason string) error {
+	return fmt.Errorf("%w %q: %s", ErrInvalidMarketId, id, reason)
+}
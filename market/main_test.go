@@ -0,0 +1,90 @@
+package market_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/market"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSpotMarket(t *testing.T) {
+	m, err := market.Parse(`coinbase-btc-usd-spot`)
+	assert.Nil(t, err)
+	assert.Equal(t, market.Market{Exchange: `coinbase`, Type: market.Spot, Base: `btc`, Quote: `usd`}, m)
+	assert.Equal(t, api.MarketId(`coinbase-btc-usd-spot`), m.Id())
+}
+
+func TestParseDefiSpotMarket(t *testing.T) {
+	m, err := market.Parse(`uniswap_v2_eth-1-weth-usdc-spot`)
+	assert.Nil(t, err)
+	assert.Equal(t, `1`, m.PoolConfigId)
+	assert.Equal(t, `weth`, m.Base)
+	assert.Equal(t, `usdc`, m.Quote)
+	assert.Equal(t, api.MarketId(`uniswap_v2_eth-1-weth-usdc-spot`), m.Id())
+}
+
+func TestParseFutureMarket(t *testing.T) {
+	for _, id := range []api.MarketId{`binance-BTCUSDT-future`, `ftx-BTC-PERP-future`, `gate.io-BTC_USD-future`} {
+		m, err := market.Parse(id)
+		assert.Nil(t, err)
+		assert.Equal(t, market.Future, m.Type)
+		assert.Equal(t, id, m.Id())
+	}
+	m := market.MustParse(`ftx-BTC-PERP-future`)
+	assert.Equal(t, `BTC-PERP`, m.Symbol)
+}
+
+func TestParseOptionMarket(t *testing.T) {
+	m, err := market.Parse(`deribit-BTC-25MAR22-40000-C-option`)
+	assert.Nil(t, err)
+	assert.Equal(t, `deribit`, m.Exchange)
+	assert.Equal(t, `BTC`, m.Base)
+	assert.Equal(t, ``, m.Quote)
+	assert.Equal(t, `25MAR22`, m.Expiry)
+	assert.Equal(t, `40000`, m.Strike)
+	assert.Equal(t, market.Call, m.ContractType())
+	assert.Equal(t, api.MarketId(`deribit-BTC-25MAR22-40000-C-option`), m.Id())
+
+	expiry, err := m.ExpiryTime()
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2022, time.March, 25, 0, 0, 0, 0, time.UTC), expiry)
+
+	m, err = market.Parse(`okex-BTC-USD-220325-40000-P-option`)
+	assert.Nil(t, err)
+	assert.Equal(t, `USD`, m.Quote)
+	assert.Equal(t, market.Put, m.ContractType())
+	expiry, err = m.ExpiryTime()
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2022, time.March, 25, 0, 0, 0, 0, time.UTC), expiry)
+}
+
+func TestParseInvalidMarket(t *testing.T) {
+	for _, id := range []api.MarketId{``, `coinbase-btc`, `coinbase-btc-usd-swap`, `coinbase--usd-spot`, `coinbase-btc-spot`, `deribit-BTC-25MAR22-option`, `deribit-BTC-25MAR22-40000-X-option`} {
+		_, err := market.Parse(id)
+		assert.True(t, errors.Is(err, market.ErrInvalidMarketId), id)
+	}
+}
+
+func TestBuildMarkets(t *testing.T) {
+	option := market.NewOption(`deribit`, `ETH`, `25MAR22`, `1200`, `put`)
+	assert.Equal(t, api.MarketId(`deribit-ETH-25MAR22-1200-P-option`), option.Id())
+	assert.Nil(t, option.Validate())
+
+	markets := market.Join(market.NewSpot(`coinbase`, `btc`, `usd`), market.NewFuture(`binance`, `BTCUSDT`), option)
+	assert.Equal(t, api.MarketId(`coinbase-btc-usd-spot,binance-BTCUSDT-future,deribit-ETH-25MAR22-1200-P-option`), markets)
+
+	assert.NotNil(t, market.NewSpot(`coinbase`, `btc`, ``).Validate())
+}
+
+func TestFromInfo(t *testing.T) {
+	base := api.AssetIdBase(`btc`)
+	quote := api.AssetIdQuote(`usdt`)
+	m, err := market.FromInfo(api.MarketInfo{Market: `binance-BTCUSDT-future`, Base: &base, Quote: &quote})
+	assert.Nil(t, err)
+	assert.Equal(t, `btc`, m.Base)
+	assert.Equal(t, `usdt`, m.Quote)
+	assert.Equal(t, api.MarketId(`binance-BTCUSDT-future`), m.Id())
+}
@@ -0,0 +1,340 @@
+package mempool
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// Defaults of FeeEstimator, block size and interval are the ones of bitcoin
+const (
+	DefaultBlockVSize    = 1000000
+	DefaultBlockInterval = 10 * time.Minute
+	DefaultFeeHistory    = time.Hour
+	DefaultPercentile    = 50
+	DefaultMinFeerate    = 1
+)
+
+// Band is parsed mempool feerate band, Feerate is in sat/vB and VSize is consensus size / 4
+type Band struct {
+	Feerate float64
+	Count   int64
+	VSize   float64
+	Fees    float64
+}
+
+// Snapshot is mempool feerate bands at given time
+type Snapshot struct {
+	Time  time.Time
+	Bands []Band
+}
+
+// ParseSnapshot converts mempool feerates returned by api into Snapshot with bands ordered by feerate, highest first
+func ParseSnapshot(feerate api.MempoolFeerate) (Snapshot, error) {
+	t, err := time.Parse(time.RFC3339Nano, feerate.Time)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	snapshot := Snapshot{Time: t, Bands: make([]Band, 0, len(feerate.Feerates))}
+	for _, band := range feerate.Feerates {
+		var parsed Band
+		if parsed.Feerate, err = strconv.ParseFloat(string(band.Feerate), 64); err != nil {
+			return Snapshot{}, err
+		}
+		if parsed.Count, err = strconv.ParseInt(string(band.Count), 10, 64); err != nil {
+			return Snapshot{}, err
+		}
+		consensusSize, err := strconv.ParseFloat(string(band.ConsensusSize), 64)
+		if err != nil {
+			return Snapshot{}, err
+		}
+		parsed.VSize = consensusSize / 4
+		if parsed.Fees, err = strconv.ParseFloat(string(band.Fees), 64); err != nil {
+			return Snapshot{}, err
+		}
+		snapshot.Bands = append(snapshot.Bands, parsed)
+	}
+	sort.SliceStable(snapshot.Bands, func(i, j int) bool {
+		return snapshot.Bands[i].Feerate > snapshot.Bands[j].Feerate
+	})
+	return snapshot, nil
+}
+
+// Estimate is feerate recommended for inclusion within Target blocks
+type Estimate struct {
+	Target  int
+	Feerate float64
+	Time    time.Time
+	// Snapshots is number of snapshots estimate is based on
+	Snapshots int
+}
+
+// BacktestResult tells how estimates for Target blocks would have performed over historical snapshots.
+// Estimate is a hit when, at some snapshot within Target block intervals after it, estimated feerate
+// was enough to be within first block of mempool. Overpay is estimated feerate above the cheapest such feerate.
+type BacktestResult struct {
+	Target      int
+	Samples     int
+	Hits        int
+	HitRate     float64
+	MeanFeerate float64
+	MeanOverpay float64
+}
+
+// FeeEstimator recommends feerates from mempool feerate bands of asset. Every snapshot gives feerate
+// at which cumulative vsize of higher feerate bands fills target number of blocks, recommendation is
+// percentile of those feerates over snapshots of recent history.
+type FeeEstimator struct {
+	client        api.ClientWithResponsesInterface
+	asset         string
+	blockVSize    float64
+	blockInterval time.Duration
+	history       time.Duration
+	percentile    float64
+	minFeerate    float64
+	reqEditors    []api.RequestEditorFn
+}
+
+// FeeOption allows to customize FeeEstimator
+type FeeOption func(*FeeEstimator)
+
+// WithBlockVSize sets capacity of block in vbytes
+func WithBlockVSize(vsize int64) FeeOption {
+	return func(e *FeeEstimator) {
+		e.blockVSize = float64(vsize)
+	}
+}
+
+// WithBlockInterval sets expected time between blocks, used to match snapshots against target in backtests
+func WithBlockInterval(interval time.Duration) FeeOption {
+	return func(e *FeeEstimator) {
+		e.blockInterval = interval
+	}
+}
+
+// WithFeeHistory sets how far back snapshots are taken into account, zero uses latest snapshot only
+func WithFeeHistory(history time.Duration) FeeOption {
+	return func(e *FeeEstimator) {
+		e.history = history
+	}
+}
+
+// WithPercentile sets percentile, between 0 and 100, of per snapshot feerates which is recommended.
+// Higher percentile gives more conservative estimates.
+func WithPercentile(percentile float64) FeeOption {
+	return func(e *FeeEstimator) {
+		e.percentile = math.Max(0, math.Min(100, percentile))
+	}
+}
+
+// WithMinFeerate sets feerate recommended when mempool is cleared within target
+func WithMinFeerate(feerate float64) FeeOption {
+	return func(e *FeeEstimator) {
+		e.minFeerate = feerate
+	}
+}
+
+// WithFeeRequestEditors are passed to every call made by estimator
+func WithFeeRequestEditors(fns ...api.RequestEditorFn) FeeOption {
+	return func(e *FeeEstimator) {
+		e.reqEditors = append(e.reqEditors, fns...)
+	}
+}
+
+// NewFeeEstimator creates fee estimator of asset
+func NewFeeEstimator(client api.ClientWithResponsesInterface, asset string, opts ...FeeOption) *FeeEstimator {
+	e := FeeEstimator{
+		client:        client,
+		asset:         asset,
+		blockVSize:    DefaultBlockVSize,
+		blockInterval: DefaultBlockInterval,
+		history:       DefaultFeeHistory,
+		percentile:    DefaultPercentile,
+		minFeerate:    DefaultMinFeerate,
+	}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	return &e
+}
+
+// Estimate recommends feerate for inclusion within target blocks from latest snapshot and snapshots within history
+// before it, so estimates do not depend on publication lag
+func (e *FeeEstimator) Estimate(ctx context.Context, target int) (Estimate, error) {
+	latest, err := e.Latest(ctx)
+	if err != nil {
+		return Estimate{}, err
+	}
+	snapshots := []Snapshot{latest}
+	if e.history > 0 {
+		if snapshots, err = e.Snapshots(ctx, latest.Time.Add(-e.history), latest.Time); err != nil {
+			return Estimate{}, err
+		}
+		if len(snapshots) == 0 || snapshots[len(snapshots)-1].Time.Before(latest.Time) {
+			snapshots = append(snapshots, latest)
+		}
+	}
+	return e.EstimateSnapshots(snapshots, target)
+}
+
+// Latest returns latest published mempool snapshot
+func (e *FeeEstimator) Latest(ctx context.Context) (Snapshot, error) {
+	pageSize := api.MempoolFeeratesPageSize(1)
+	pagingFrom := api.GetMempoolFeeratesParamsPagingFrom(`end`)
+	params := api.GetMempoolFeeratesParams{Assets: api.AssetId(e.asset), PageSize: &pageSize, PagingFrom: &pagingFrom}
+	res, err := e.client.GetMempoolFeeratesWithResponse(ctx, &params, e.reqEditors...)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if res.JSON200 == nil {
+		return Snapshot{}, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+	}
+	if len(res.JSON200.Data) == 0 {
+		return Snapshot{}, errors.New(constants.NoDataFound)
+	}
+	return ParseSnapshot(res.JSON200.Data[len(res.JSON200.Data)-1])
+}
+
+// Backtest replays estimates for target blocks over snapshots between start and end
+func (e *FeeEstimator) Backtest(ctx context.Context, target int, start, end time.Time) (BacktestResult, error) {
+	snapshots, err := e.Snapshots(ctx, start.Add(-e.history), end)
+	if err != nil {
+		return BacktestResult{}, err
+	}
+	return e.BacktestSnapshots(snapshots, target, start), nil
+}
+
+// Snapshots returns mempool snapshots between start and end ordered by time, zero times leave interval open
+func (e *FeeEstimator) Snapshots(ctx context.Context, start, end time.Time) ([]Snapshot, error) {
+	pageSize := api.MempoolFeeratesPageSize(constants.DefaultPageSize)
+	params := api.GetMempoolFeeratesParams{Assets: api.AssetId(e.asset), PageSize: &pageSize}
+	if !start.IsZero() {
+		startTime := api.StartTime(start.UTC().Format(time.RFC3339Nano))
+		params.StartTime = &startTime
+	}
+	if !end.IsZero() {
+		endTime := api.EndTime(end.UTC().Format(time.RFC3339Nano))
+		params.EndTime = &endTime
+	}
+	var snapshots []Snapshot
+	err := pages(func(token *api.NextPageToken) (*api.NextPageToken, error) {
+		params.NextPageToken = token
+		res, err := e.client.GetMempoolFeeratesWithResponse(ctx, &params, e.reqEditors...)
+		if err != nil {
+			return nil, err
+		}
+		if res.JSON200 == nil {
+			return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+		}
+		for _, feerate := range res.JSON200.Data {
+			snapshot, err := ParseSnapshot(feerate)
+			if err != nil {
+				return nil, err
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+		return res.JSON200.NextPageToken, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Time.Before(snapshots[j].Time)
+	})
+	return snapshots, nil
+}
+
+// EstimateSnapshots recommends feerate for inclusion within target blocks from snapshots ordered by time.
+// Snapshots within history of latest one are used.
+func (e *FeeEstimator) EstimateSnapshots(snapshots []Snapshot, target int) (Estimate, error) {
+	if len(snapshots) == 0 {
+		return Estimate{}, errors.New(constants.NoDataFound)
+	}
+	latest := snapshots[len(snapshots)-1]
+	var feerates []float64
+	for i := len(snapshots) - 1; i >= 0 && !snapshots[i].Time.Before(latest.Time.Add(-e.history)); i-- {
+		feerates = append(feerates, e.SnapshotFeerate(snapshots[i], target))
+	}
+	return Estimate{
+		Target:    target,
+		Feerate:   percentile(feerates, e.percentile),
+		Time:      latest.Time,
+		Snapshots: len(feerates),
+	}, nil
+}
+
+// SnapshotFeerate returns lowest feerate which gets into target blocks according to snapshot alone
+func (e *FeeEstimator) SnapshotFeerate(snapshot Snapshot, target int) float64 {
+	capacity := e.blockVSize * float64(target)
+	var vsize float64
+	for _, band := range snapshot.Bands {
+		vsize += band.VSize
+		if vsize >= capacity {
+			return math.Max(band.Feerate, e.minFeerate)
+		}
+	}
+	return e.minFeerate
+}
+
+// BacktestSnapshots replays estimates for target blocks at every snapshot from start on, which has
+// snapshots of whole target horizon after it
+func (e *FeeEstimator) BacktestSnapshots(snapshots []Snapshot, target int, start time.Time) BacktestResult {
+	result := BacktestResult{Target: target}
+	if len(snapshots) == 0 {
+		return result
+	}
+	horizon := e.blockInterval * time.Duration(target)
+	last := snapshots[len(snapshots)-1].Time
+	var feerates, overpays float64
+	for i, snapshot := range snapshots {
+		if snapshot.Time.Before(start) {
+			continue
+		}
+		if snapshot.Time.Add(horizon).After(last) {
+			break
+		}
+		estimate, _ := e.EstimateSnapshots(snapshots[:i+1], target)
+		cheapest := math.Inf(1)
+		for _, later := range snapshots[i+1:] {
+			if later.Time.After(snapshot.Time.Add(horizon)) {
+				break
+			}
+			cheapest = math.Min(cheapest, e.SnapshotFeerate(later, 1))
+		}
+		if math.IsInf(cheapest, 1) {
+			continue
+		}
+		result.Samples++
+		feerates += estimate.Feerate
+		if estimate.Feerate >= cheapest {
+			result.Hits++
+			overpays += estimate.Feerate - cheapest
+		}
+	}
+	if result.Samples > 0 {
+		result.HitRate = float64(result.Hits) / float64(result.Samples)
+		result.MeanFeerate = feerates / float64(result.Samples)
+	}
+	if result.Hits > 0 {
+		result.MeanOverpay = overpays / float64(result.Hits)
+	}
+	return result
+}
+
+// percentile returns nearest rank percentile of values
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
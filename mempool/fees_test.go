@@ -0,0 +1,127 @@
+package mempool_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/rulesng/coinmetrics-go-sdk/mempool"
+	"github.com/stretchr/testify/assert"
+)
+
+// Snapshots of 1000 vB blocks mempool, feerates getting into next block are 10, 20, 2 and 8 sat/vB
+const testFeeratesPage1 = `{
+  "data": [
+    {"asset":"btc","time":"2022-05-01T00:00:00.000000000Z","feerates":[
+      {"feerate":"10","count":"3","consensus_size":"2400","fees":"0.00006"},
+      {"feerate":"20","count":"2","consensus_size":"2400","fees":"0.00012"}
+    ]},
+    {"asset":"btc","time":"2022-05-01T00:10:00.000000000Z","feerates":[
+      {"feerate":"20","count":"4","consensus_size":"4800","fees":"0.00024"}
+    ]}
+  ],
+  "next_page_token": "page2"
+}`
+
+const testFeeratesPage2 = `{
+  "data": [
+    {"asset":"btc","time":"2022-05-01T00:20:00.000000000Z","feerates":[
+      {"feerate":"30","count":"1","consensus_size":"2000","fees":"0.00015"},
+      {"feerate":"2","count":"5","consensus_size":"2400","fees":"0.000012"}
+    ]},
+    {"asset":"btc","time":"2022-05-01T00:30:00.000000000Z","feerates":[
+      {"feerate":"8","count":"6","consensus_size":"4000","fees":"0.00008"}
+    ]}
+  ]
+}`
+
+func registerFeerates(t *testing.T) {
+	var snapshots []json.RawMessage
+	for _, page := range []string{testFeeratesPage1, testFeeratesPage2} {
+		var body struct{ Data []json.RawMessage }
+		assert.Nil(t, json.Unmarshal([]byte(page), &body))
+		snapshots = append(snapshots, body.Data...)
+	}
+	url := fmt.Sprintf(`%s%s/timeseries/mempool-feerates`, constants.TestEndpoint, constants.ApiVersion)
+	httpmock.RegisterResponder(http.MethodGet, url, func(req *http.Request) (*http.Response, error) {
+		query := req.URL.Query()
+		assert.Equal(t, `btc`, query.Get(`assets`))
+		body := testFeeratesPage1
+		if query.Get(`next_page_token`) == `page2` {
+			body = testFeeratesPage2
+		}
+		if query.Get(`start_time`) != `` || query.Get(`end_time`) != `` || query.Get(`paging_from`) != `` {
+			// filtered requests are served in one page
+			var data []json.RawMessage
+			for _, snapshot := range snapshots {
+				var parsed struct{ Time time.Time }
+				assert.Nil(t, json.Unmarshal(snapshot, &parsed))
+				if start, err := time.Parse(time.RFC3339Nano, query.Get(`start_time`)); err == nil && parsed.Time.Before(start) {
+					continue
+				}
+				if end, err := time.Parse(time.RFC3339Nano, query.Get(`end_time`)); err == nil && parsed.Time.After(end) {
+					continue
+				}
+				data = append(data, snapshot)
+			}
+			if query.Get(`paging_from`) == `end` && query.Get(`page_size`) == `1` && len(data) > 0 {
+				data = data[len(data)-1:]
+			}
+			raw, err := json.Marshal(map[string]interface{}{`data`: data})
+			assert.Nil(t, err)
+			body = string(raw)
+		}
+		resp := httpmock.NewStringResponse(http.StatusOK, body)
+		resp.Header.Set(`Content-Type`, `application/json`)
+		return resp, nil
+	})
+}
+
+func TestFeeEstimate(t *testing.T) {
+	registerFeerates(t)
+	ctx := context.Background()
+	estimator := mempool.NewFeeEstimator(_coinmetrics, `btc`, mempool.WithBlockVSize(1000))
+	estimate, err := estimator.Estimate(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, mempool.Estimate{Target: 1, Feerate: 8, Time: time.Date(2022, time.May, 1, 0, 30, 0, 0, time.UTC), Snapshots: 4}, estimate)
+
+	estimate, err = mempool.NewFeeEstimator(_coinmetrics, `btc`, mempool.WithBlockVSize(1000), mempool.WithPercentile(90)).Estimate(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(20), estimate.Feerate)
+
+	// history is relative to latest snapshot, which is years old here
+	estimate, err = mempool.NewFeeEstimator(_coinmetrics, `btc`, mempool.WithBlockVSize(1000), mempool.WithFeeHistory(15*time.Minute)).Estimate(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, mempool.Estimate{Target: 1, Feerate: 2, Time: time.Date(2022, time.May, 1, 0, 30, 0, 0, time.UTC), Snapshots: 2}, estimate)
+
+	// zero history uses latest snapshot only
+	estimate, err = mempool.NewFeeEstimator(_coinmetrics, `btc`, mempool.WithBlockVSize(1000), mempool.WithFeeHistory(0)).Estimate(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, mempool.Estimate{Target: 1, Feerate: 8, Time: time.Date(2022, time.May, 1, 0, 30, 0, 0, time.UTC), Snapshots: 1}, estimate)
+
+	snapshots, err := estimator.Snapshots(ctx, time.Time{}, time.Time{})
+	assert.Nil(t, err)
+	assert.Equal(t, mempool.Band{Feerate: 20, Count: 2, VSize: 600, Fees: 0.00012}, snapshots[0].Bands[0])
+	assert.Equal(t, float64(10), estimator.SnapshotFeerate(snapshots[0], 1))
+	assert.Equal(t, float64(1), estimator.SnapshotFeerate(snapshots[0], 2))
+	assert.Equal(t, float64(2), estimator.SnapshotFeerate(snapshots[2], 1))
+}
+
+func TestFeeBacktest(t *testing.T) {
+	registerFeerates(t)
+	estimator := mempool.NewFeeEstimator(_coinmetrics, `btc`, mempool.WithBlockVSize(1000), mempool.WithFeeHistory(0))
+	start := time.Date(2022, time.May, 1, 0, 0, 0, 0, time.UTC)
+	result, err := estimator.Backtest(context.Background(), 1, start, start.Add(time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.Target)
+	assert.Equal(t, 3, result.Samples)
+	assert.Equal(t, 1, result.Hits)
+	assert.InDelta(t, 1.0/3, result.HitRate, 1e-9)
+	assert.InDelta(t, 32.0/3, result.MeanFeerate, 1e-9)
+	assert.Equal(t, float64(18), result.MeanOverpay)
+}
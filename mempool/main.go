@@ -0,0 +1,45 @@
+package mempool
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+)
+
+// pages calls fetch with token of every next page until last page is reached
+func pages(fetch func(token *api.NextPageToken) (*api.NextPageToken, error)) error {
+	var token *api.NextPageToken
+	for {
+		next, err := fetch(token)
+		if err != nil || next == nil {
+			return err
+		}
+		token = next
+	}
+}
+
+// listParam sets comma separated list query param. It is used for params which are generated with wrong type,
+// like `txids` of transaction tracker which is generated as list of transactions instead of list of ids.
+func listParam(name string, values []string) api.RequestEditorFn {
+	return func(ctx context.Context, req *http.Request) error {
+		q := req.URL.Query()
+		q.Set(name, strings.Join(values, `,`))
+		req.URL.RawQuery = q.Encode()
+		return nil
+	}
+}
+
+// chunks splits values into slices of at most size items
+func chunks(values []string, size int) [][]string {
+	var result [][]string
+	for len(values) > size {
+		result = append(result, values[:size])
+		values = values[size:]
+	}
+	if len(values) > 0 {
+		result = append(result, values)
+	}
+	return result
+}
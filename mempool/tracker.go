@@ -0,0 +1,485 @@
+package mempool
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// Statuses of transaction reported by transaction tracker
+const (
+	StatusUnconfirmed api.TxTrackerTxStatus = "UNCONFIRMED"
+	StatusConfirmed   api.TxTrackerTxStatus = "CONFIRMED"
+	StatusRemoved     api.TxTrackerTxStatus = "REMOVED"
+)
+
+// ReasonReplaced is removal reason of transactions replaced by fee
+const ReasonReplaced api.TxTrackerRemovalReason = "REPLACED"
+
+// Defaults of Tracker
+const (
+	DefaultConfirmations   = 1
+	DefaultPollInterval    = 10 * time.Second
+	DefaultMaxPollInterval = 5 * time.Minute
+)
+
+// EventKind tells what happened to watched transaction
+type EventKind string
+
+// Kinds of events produced by Tracker
+const (
+	// Seen is emitted when transaction enters mempool, also when it returns to mempool after reorg
+	Seen EventKind = "seen"
+	// Replaced is emitted when transaction is replaced by fee, replacement is watched from then on
+	Replaced EventKind = "replaced"
+	// Confirmed is emitted once transaction has required number of confirmations
+	Confirmed EventKind = "confirmed"
+	// Dropped is emitted when transaction is removed from mempool for other reason than replacement
+	Dropped EventKind = "dropped"
+)
+
+// Event is emitted by Tracker on every state transition of watched transaction
+type Event struct {
+	Kind        EventKind
+	Txid        string
+	Transaction api.TxTrackerTransaction
+	// Confirmations of Confirmed transaction
+	Confirmations int64
+	// Replacement is txid of transaction which replaced Replaced transaction
+	Replacement string
+	// Reason of removal of Dropped transaction
+	Reason api.TxTrackerRemovalReason
+}
+
+// Watch is persisted state of watched transaction, Status is empty until transaction is reported by api
+type Watch struct {
+	Txid          string
+	Status        api.TxTrackerTxStatus
+	Confirmations int64
+}
+
+// Store persists watched transactions so tracking can be resumed after restart
+type Store interface {
+	Load(ctx context.Context) ([]Watch, error)
+	Save(ctx context.Context, watch Watch) error
+	Delete(ctx context.Context, txid string) error
+}
+
+// MemoryStore keeps watched transactions in memory, it is used by Tracker when no store is given
+type MemoryStore struct {
+	mu      sync.Mutex
+	watches map[string]Watch
+}
+
+// NewMemoryStore creates empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{watches: map[string]Watch{}}
+}
+
+// Load returns all stored watches
+func (s *MemoryStore) Load(ctx context.Context) ([]Watch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	watches := make([]Watch, 0, len(s.watches))
+	for _, watch := range s.watches {
+		watches = append(watches, watch)
+	}
+	return watches, nil
+}
+
+// Save stores watch replacing previous state of same txid
+func (s *MemoryStore) Save(ctx context.Context, watch Watch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watches[watch.Txid] = watch
+	return nil
+}
+
+// Delete removes watch of txid
+func (s *MemoryStore) Delete(ctx context.Context, txid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.watches, txid)
+	return nil
+}
+
+// Tracker watches transactions of asset through transaction tracker and emits events on their state transitions.
+// Transactions stop being watched once they are confirmed, replaced or dropped.
+type Tracker struct {
+	client          api.ClientWithResponsesInterface
+	asset           api.BlockchainAsset
+	store           Store
+	confirmations   int64
+	pollInterval    time.Duration
+	maxPollInterval time.Duration
+	reqEditors      []api.RequestEditorFn
+
+	mu      sync.Mutex
+	loaded  bool
+	watches map[string]Watch
+}
+
+// TrackerOption allows to customize Tracker
+type TrackerOption func(*Tracker)
+
+// WithStore persists watched transactions in store
+func WithStore(store Store) TrackerOption {
+	return func(t *Tracker) {
+		t.store = store
+	}
+}
+
+// WithConfirmations sets number of confirmations after which transaction is Confirmed
+func WithConfirmations(n int64) TrackerOption {
+	return func(t *Tracker) {
+		if n > 0 {
+			t.confirmations = n
+		}
+	}
+}
+
+// WithPollInterval sets interval between polls, it doubles after every poll without events up to max interval
+func WithPollInterval(interval, max time.Duration) TrackerOption {
+	return func(t *Tracker) {
+		t.pollInterval = interval
+		t.maxPollInterval = max
+	}
+}
+
+// WithTrackerRequestEditors are passed to every call made by tracker
+func WithTrackerRequestEditors(fns ...api.RequestEditorFn) TrackerOption {
+	return func(t *Tracker) {
+		t.reqEditors = append(t.reqEditors, fns...)
+	}
+}
+
+// NewTracker creates tracker of transactions of asset
+func NewTracker(client api.ClientWithResponsesInterface, asset string, opts ...TrackerOption) *Tracker {
+	t := Tracker{
+		client:          client,
+		asset:           api.BlockchainAsset(asset),
+		store:           NewMemoryStore(),
+		confirmations:   DefaultConfirmations,
+		pollInterval:    DefaultPollInterval,
+		maxPollInterval: DefaultMaxPollInterval,
+		watches:         map[string]Watch{},
+	}
+	for _, opt := range opts {
+		opt(&t)
+	}
+	return &t
+}
+
+// load reads watches from store once, t.mu must be held
+func (t *Tracker) load(ctx context.Context) error {
+	if t.loaded {
+		return nil
+	}
+	watches, err := t.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	for _, watch := range watches {
+		t.watches[watch.Txid] = watch
+	}
+	t.loaded = true
+	return nil
+}
+
+// Watch starts watching txids, txids already watched keep their state
+func (t *Tracker) Watch(ctx context.Context, txids ...string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.load(ctx); err != nil {
+		return err
+	}
+	for _, txid := range txids {
+		if _, ok := t.watches[txid]; ok {
+			continue
+		}
+		watch := Watch{Txid: txid}
+		if err := t.store.Save(ctx, watch); err != nil {
+			return err
+		}
+		t.watches[txid] = watch
+	}
+	return nil
+}
+
+// Unwatch stops watching txid
+func (t *Tracker) Unwatch(ctx context.Context, txid string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.load(ctx); err != nil {
+		return err
+	}
+	if err := t.store.Delete(ctx, txid); err != nil {
+		return err
+	}
+	delete(t.watches, txid)
+	return nil
+}
+
+// Watched returns watched transactions ordered by txid
+func (t *Tracker) Watched(ctx context.Context) ([]Watch, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.load(ctx); err != nil {
+		return nil, err
+	}
+	return t.sorted(), nil
+}
+
+// sorted returns watches ordered by txid, t.mu must be held
+func (t *Tracker) sorted() []Watch {
+	watches := make([]Watch, 0, len(t.watches))
+	for _, watch := range t.watches {
+		watches = append(watches, watch)
+	}
+	sort.Slice(watches, func(i, j int) bool {
+		return watches[i].Txid < watches[j].Txid
+	})
+	return watches
+}
+
+// Run polls until ctx is done. Interval between polls backs off while nothing changes or api is unavailable
+// and is reset once an event is emitted. Errors returned by fn stop tracking.
+func (t *Tracker) Run(ctx context.Context, fn func(Event) error) error {
+	interval := t.pollInterval
+	for {
+		n, err := t.poll(ctx, fn)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil && !transient(err) {
+			return err
+		}
+		if n > 0 && err == nil {
+			interval = t.pollInterval
+		} else if interval *= 2; interval > t.maxPollInterval {
+			interval = t.maxPollInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Poll fetches status of watched transactions once and calls fn for every state transition.
+// State of transaction is saved only after fn returns without error, so failed events are emitted again by next poll.
+func (t *Tracker) Poll(ctx context.Context, fn func(Event) error) error {
+	_, err := t.poll(ctx, fn)
+	return err
+}
+
+func (t *Tracker) poll(ctx context.Context, fn func(Event) error) (int, error) {
+	t.mu.Lock()
+	err := t.load(ctx)
+	watches := t.sorted()
+	t.mu.Unlock()
+	if err != nil || len(watches) == 0 {
+		return 0, err
+	}
+
+	txids := make([]string, 0, len(watches))
+	for _, watch := range watches {
+		txids = append(txids, watch.Txid)
+	}
+	transactions, err := t.fetch(ctx, txids)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, watch := range watches {
+		tx, ok := transactions[watch.Txid]
+		if !ok {
+			continue
+		}
+		event, next, done, err := t.transition(ctx, watch, tx)
+		if err != nil {
+			return n, err
+		}
+		if event != nil {
+			if err := fn(*event); err != nil {
+				return n, err
+			}
+			n++
+		}
+		if err := t.apply(ctx, next, done); err != nil {
+			return n, err
+		}
+		if event != nil && event.Kind == Replaced {
+			if err := t.Watch(ctx, event.Replacement); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// transition compares reported transaction with watched state and returns event to emit,
+// next state and whether transaction should stop being watched
+func (t *Tracker) transition(ctx context.Context, watch Watch, tx api.TxTrackerTransaction) (*Event, Watch, bool, error) {
+	next := Watch{Txid: watch.Txid, Status: tx.Status}
+	event := Event{Txid: watch.Txid, Transaction: tx}
+	switch tx.Status {
+	case StatusUnconfirmed:
+		if watch.Status == StatusUnconfirmed {
+			return nil, next, false, nil
+		}
+		event.Kind = Seen
+		return &event, next, false, nil
+	case StatusConfirmed:
+		next.Confirmations = 1
+		if tx.NConfirmations != nil {
+			confirmations, err := strconv.ParseInt(string(*tx.NConfirmations), 10, 64)
+			if err != nil {
+				return nil, watch, false, err
+			}
+			next.Confirmations = confirmations
+		}
+		if next.Confirmations < t.confirmations {
+			return nil, next, false, nil
+		}
+		event.Kind = Confirmed
+		event.Confirmations = next.Confirmations
+		return &event, next, true, nil
+	case StatusRemoved:
+		event.Kind = Dropped
+		event.Reason, event.Replacement = removal(tx)
+		if event.Reason != ReasonReplaced {
+			return &event, next, true, nil
+		}
+		event.Kind = Replaced
+		if event.Replacement == `` {
+			replacement, err := t.replacement(ctx, watch.Txid)
+			if err != nil {
+				return nil, watch, false, err
+			}
+			event.Replacement = replacement
+		}
+		if event.Replacement == `` {
+			// replacement is not known yet, check again on next poll
+			return nil, watch, false, nil
+		}
+		return &event, next, true, nil
+	}
+	return nil, next, false, nil
+}
+
+// apply saves next state of watched transaction, unless it was unwatched meanwhile
+func (t *Tracker) apply(ctx context.Context, next Watch, done bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.watches[next.Txid]; !ok {
+		return nil
+	}
+	if done {
+		if err := t.store.Delete(ctx, next.Txid); err != nil {
+			return err
+		}
+		delete(t.watches, next.Txid)
+		return nil
+	}
+	if next == t.watches[next.Txid] {
+		return nil
+	}
+	if err := t.store.Save(ctx, next); err != nil {
+		return err
+	}
+	t.watches[next.Txid] = next
+	return nil
+}
+
+// removal returns reason and replacement from latest removal status update
+func removal(tx api.TxTrackerTransaction) (api.TxTrackerRemovalReason, string) {
+	for i := len(tx.StatusUpdates) - 1; i >= 0; i-- {
+		update := tx.StatusUpdates[i]
+		if update.Status != StatusRemoved {
+			continue
+		}
+		var reason api.TxTrackerRemovalReason
+		var replacement string
+		if update.RemovalReason != nil {
+			reason = *update.RemovalReason
+		}
+		if update.ReplacementTxid != nil {
+			replacement = string(*update.ReplacementTxid)
+		}
+		return reason, replacement
+	}
+	return ``, ``
+}
+
+// fetch returns tracked transactions by txid
+func (t *Tracker) fetch(ctx context.Context, txids []string) (map[string]api.TxTrackerTransaction, error) {
+	transactions := map[string]api.TxTrackerTransaction{}
+	pageSize := api.PageSize(constants.DefaultPageSize)
+	for _, chunk := range chunks(txids, int(constants.DefaultPageSize)) {
+		reqEditors := append(append([]api.RequestEditorFn(nil), t.reqEditors...), listParam(`txids`, chunk))
+		params := api.GetTransactionTrackerParams{PageSize: &pageSize}
+		err := pages(func(token *api.NextPageToken) (*api.NextPageToken, error) {
+			params.NextPageToken = token
+			res, err := t.client.GetTransactionTrackerWithResponse(ctx, t.asset, &params, reqEditors...)
+			if err != nil {
+				return nil, err
+			}
+			if res.JSON200 == nil {
+				return nil, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+			}
+			for _, tx := range res.JSON200.Data {
+				transactions[string(tx.Txid)] = tx
+			}
+			return res.JSON200.NextPageToken, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return transactions, nil
+}
+
+// replacement looks up txid of transaction which replaced txid
+func (t *Tracker) replacement(ctx context.Context, txid string) (string, error) {
+	replacementsOnly := api.OnlyReplacementTransactions(true)
+	params := api.GetTransactionTrackerParams{
+		ReplacementsForTxids: &api.ReplacementsForTransactions{txid},
+		ReplacementsOnly:     &replacementsOnly,
+	}
+	res, err := t.client.GetTransactionTrackerWithResponse(ctx, t.asset, &params, t.reqEditors...)
+	if err != nil {
+		return ``, err
+	}
+	if res.JSON200 == nil {
+		return ``, coinmetrics.NewApiError(res.StatusCode(), res.Body)
+	}
+	for _, tx := range res.JSON200.Data {
+		if tx.ReplacementForTxid != nil && string(*tx.ReplacementForTxid) == txid {
+			return string(tx.Txid), nil
+		}
+	}
+	return ``, nil
+}
+
+// transient tells if poll should be retried after error, that is on network errors, rate limiting and server errors
+func transient(err error) bool {
+	var apiError coinmetrics.ApiError
+	if errors.As(err, &apiError) {
+		return apiError.StatusCode == http.StatusTooManyRequests || apiError.StatusCode >= http.StatusInternalServerError
+	}
+	var urlError *url.Error
+	return errors.As(err, &urlError)
+}
@@ -0,0 +1,128 @@
+package mempool_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/rulesng/coinmetrics-go-sdk/mempool"
+	"github.com/stretchr/testify/assert"
+)
+
+// testTracker serves `/blockchain/btc/transaction-tracker` from transactions kept in memory
+type testTracker struct {
+	mu           sync.Mutex
+	transactions map[string]string
+	replacements map[string]string
+}
+
+func newTestTracker() *testTracker {
+	return &testTracker{transactions: map[string]string{}, replacements: map[string]string{}}
+}
+
+func (tr *testTracker) set(txid, body string) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.transactions[txid] = body
+}
+
+func (tr *testTracker) register() {
+	url := fmt.Sprintf(`%s%s/blockchain/btc/transaction-tracker`, constants.TestEndpoint, constants.ApiVersion)
+	httpmock.RegisterResponder(http.MethodGet, url, func(req *http.Request) (*http.Response, error) {
+		tr.mu.Lock()
+		defer tr.mu.Unlock()
+		var data []string
+		if replaced := req.URL.Query().Get(`replacements_for_txids`); replaced != `` {
+			if txid, ok := tr.replacements[replaced]; ok {
+				data = append(data, fmt.Sprintf(`{"txid":%q,"replacement_for_txid":%q,"status":"UNCONFIRMED"}`, txid, replaced))
+			}
+		}
+		for _, txid := range strings.Split(req.URL.Query().Get(`txids`), `,`) {
+			if body, ok := tr.transactions[txid]; ok {
+				data = append(data, body)
+			}
+		}
+		resp := httpmock.NewStringResponse(http.StatusOK, fmt.Sprintf(`{"data":[%s]}`, strings.Join(data, `,`)))
+		resp.Header.Set(`Content-Type`, `application/json`)
+		return resp, nil
+	})
+}
+
+func collect(events *[]string) func(mempool.Event) error {
+	return func(event mempool.Event) error {
+		description := fmt.Sprintf(`%s %s`, event.Kind, event.Txid)
+		switch event.Kind {
+		case mempool.Confirmed:
+			description += fmt.Sprintf(` confirmations=%d`, event.Confirmations)
+		case mempool.Replaced:
+			description += ` by ` + event.Replacement
+		case mempool.Dropped:
+			description += ` reason=` + string(event.Reason)
+		}
+		*events = append(*events, description)
+		return nil
+	}
+}
+
+func TestTrackerTransitions(t *testing.T) {
+	tr := newTestTracker()
+	tr.register()
+	tracker := mempool.NewTracker(_coinmetrics, `btc`, mempool.WithConfirmations(2))
+	ctx := context.Background()
+	assert.Nil(t, tracker.Watch(ctx, `tx1`, `tx2`))
+
+	var events []string
+	tr.set(`tx1`, `{"txid":"tx1","status":"UNCONFIRMED"}`)
+	assert.Nil(t, tracker.Poll(ctx, collect(&events)))
+	assert.Nil(t, tracker.Poll(ctx, collect(&events)))
+
+	tr.set(`tx1`, `{"txid":"tx1","status":"REMOVED","status_updates":[{"status":"UNCONFIRMED"},{"status":"REMOVED","removal_reason":"REPLACED"}]}`)
+	tr.replacements[`tx1`] = `tx3`
+	tr.set(`tx2`, `{"txid":"tx2","status":"CONFIRMED","n_confirmations":"1"}`)
+	assert.Nil(t, tracker.Poll(ctx, collect(&events)))
+
+	tr.set(`tx2`, `{"txid":"tx2","status":"CONFIRMED","n_confirmations":"2"}`)
+	tr.set(`tx3`, `{"txid":"tx3","status":"UNCONFIRMED"}`)
+	assert.Nil(t, tracker.Poll(ctx, collect(&events)))
+
+	assert.Equal(t, []string{
+		`seen tx1`,
+		`replaced tx1 by tx3`,
+		`confirmed tx2 confirmations=2`,
+		`seen tx3`,
+	}, events)
+	watched, err := tracker.Watched(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []mempool.Watch{{Txid: `tx3`, Status: mempool.StatusUnconfirmed}}, watched)
+}
+
+func TestTrackerStore(t *testing.T) {
+	tr := newTestTracker()
+	tr.register()
+	ctx := context.Background()
+	store := mempool.NewMemoryStore()
+	assert.Nil(t, store.Save(ctx, mempool.Watch{Txid: `tx4`, Status: mempool.StatusUnconfirmed}))
+	assert.Nil(t, store.Save(ctx, mempool.Watch{Txid: `tx5`}))
+	tr.set(`tx4`, `{"txid":"tx4","status":"UNCONFIRMED"}`)
+	tr.set(`tx5`, `{"txid":"tx5","status":"REMOVED","status_updates":[{"status":"REMOVED","removal_reason":"EXPIRY"}]}`)
+
+	// failed event is emitted again by next poll
+	tracker := mempool.NewTracker(_coinmetrics, `btc`, mempool.WithStore(store))
+	err := tracker.Poll(ctx, func(event mempool.Event) error {
+		return errors.New(`downstream unavailable`)
+	})
+	assert.EqualError(t, err, `downstream unavailable`)
+
+	var events []string
+	assert.Nil(t, tracker.Poll(ctx, collect(&events)))
+	assert.Equal(t, []string{`dropped tx5 reason=EXPIRY`}, events)
+	watches, err := store.Load(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []mempool.Watch{{Txid: `tx4`, Status: mempool.StatusUnconfirmed}}, watches)
+}
@@ -0,0 +1,258 @@
+package mock
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rulesng/coinmetrics-go-sdk/openapi"
+)
+
+// maxDepth guards generation against deeply nested schemas
+const maxDepth = 8
+
+// timeLayout is time format of api
+const timeLayout = `2006-01-02T15:04:05.000000000Z`
+
+// entities maps schema and property names to query params listing entities
+var entities = map[string]string{
+	`AssetId`:        `assets`,
+	`Asset`:          `assets`,
+	`AssetIdBase`:    `assets`,
+	`asset`:          `assets`,
+	`MarketId`:       `markets`,
+	`Market`:         `markets`,
+	`market`:         `markets`,
+	`ExchangeId`:     `exchanges`,
+	`Exchange`:       `exchanges`,
+	`exchange`:       `exchanges`,
+	`MetricId`:       `metrics`,
+	`metric`:         `metrics`,
+	`IndexId`:        `indexes`,
+	`Index`:          `indexes`,
+	`index`:          `indexes`,
+	`PairId`:         `pairs`,
+	`Pair`:           `pairs`,
+	`pair`:           `pairs`,
+	`InstitutionId`:  `institutions`,
+	`Institution`:    `institutions`,
+	`institution`:    `institutions`,
+	`ExchangeAsset`:  `exchange_assets`,
+	`exchange_asset`: `exchange_assets`,
+}
+
+// defaultEntities are used when request does not list entities
+var defaultEntities = map[string][]string{
+	`assets`:          {`btc`, `eth`},
+	`markets`:         {`coinbase-btc-usd-spot`, `binance-BTCUSDT-future`},
+	`exchanges`:       {`coinbase`, `binance`},
+	`metrics`:         {`PriceUSD`, `AdrActCnt`},
+	`indexes`:         {`CMBI10`, `CMBIBTC`},
+	`pairs`:           {`btc-usd`, `eth-usd`},
+	`institutions`:    {`grayscale`},
+	`exchange_assets`: {`coinbase-btc`, `coinbase-eth`},
+}
+
+// path params naming single entity
+var pathEntities = map[string]string{
+	`assets`: `asset`,
+}
+
+var (
+	timeWords    = []string{`time`, `listing`, `expiration`}
+	hashWords    = []string{`hash`, `txid`, `transactionid`, `address`}
+	integerWords = []string{`height`, `count`, `number`, `confirmations`, `nonce`, `version`, `depth`, `sequence`, `position`}
+	decimalWords = []string{`price`, `amount`, `size`, `value`, `rate`, `fee`, `balance`, `weight`, `volume`, `vwap`, `greeks`,
+		`volatility`, `strike`, `threshold`, `received`, `sent`, `change`, `probability`, `difficulty`, `level`, `usd`}
+)
+
+// row is position of generated record, values derived from it are deterministic
+type row struct {
+	index  int
+	kind   string
+	entity string
+	time   time.Time
+}
+
+// generator produces values of schemas for request
+type generator struct {
+	spec  *openapi.Spec
+	query url.Values
+	path  map[string]string
+	base  time.Time
+	step  time.Duration
+}
+
+func newGenerator(spec *openapi.Spec, query url.Values, path map[string]string) *generator {
+	g := generator{spec: spec, query: query, path: path, base: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), step: 24 * time.Hour}
+	if start, ok := parseTime(query.Get(`start_time`)); ok {
+		g.base = start
+	}
+	if step, ok := parseFrequency(query.Get(`frequency`)); ok {
+		g.step = step
+	}
+	return &g
+}
+
+// parseTime parses formats of time params accepted by api
+func parseTime(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, `2006-01-02T15:04:05`, `2006-01-02`, `20060102`} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseFrequency parses frequencies like `1d`, `1h`, `5m`, `1s` or `1d-ny-close`, blocks are 10 minutes apart
+func parseFrequency(frequency string) (time.Duration, bool) {
+	i := 0
+	for i < len(frequency) && frequency[i] >= '0' && frequency[i] <= '9' {
+		i++
+	}
+	if i == 0 || i == len(frequency) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(frequency[:i])
+	if err != nil {
+		return 0, false
+	}
+	units := map[byte]time.Duration{'s': time.Second, 'm': time.Minute, 'h': time.Hour, 'd': 24 * time.Hour, 'b': 10 * time.Minute}
+	unit, ok := units[frequency[i]]
+	return time.Duration(n) * unit, ok
+}
+
+// values returns entities of kind requested by query or path, or defaults
+func (g *generator) values(kind string) []string {
+	if value := g.query.Get(kind); value != `` {
+		return strings.Split(value, `,`)
+	}
+	if value, ok := g.path[pathEntities[kind]]; ok {
+		return []string{value}
+	}
+	return defaultEntities[kind]
+}
+
+// rows returns rows of records, every entity of kind has length rows spaced by frequency
+func (g *generator) rows(kind string, length int) []row {
+	var rows []row
+	entities := []string{``}
+	if kind != `` {
+		entities = g.values(kind)
+	}
+	for _, entity := range entities {
+		for i := 0; i < length; i++ {
+			rows = append(rows, row{index: len(rows), kind: kind, entity: entity, time: g.base.Add(time.Duration(i) * g.step)})
+		}
+	}
+	return rows
+}
+
+// value generates value of schema, name is name of property holding it
+func (g *generator) value(schema *openapi.Schema, name string, r row, depth int) interface{} {
+	schema, component := g.spec.Schema(schema)
+	if schema == nil {
+		return nil
+	}
+	if component != `` {
+		name = component
+	}
+	if len(schema.OneOf) > 0 {
+		return g.value(schema.OneOf[0], name, r, depth)
+	}
+	if len(schema.AnyOf) > 0 {
+		return g.value(schema.AnyOf[0], name, r, depth)
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[r.index%len(schema.Enum)]
+	}
+	switch {
+	case len(schema.AllOf) > 0 || schema.Type == `object` || schema.Properties != nil:
+		return g.object(schema, r, depth)
+	case schema.Type == `array`:
+		items := []interface{}{}
+		if depth < maxDepth {
+			for i := 0; i < 2; i++ {
+				item := r
+				item.index = r.index*2 + i
+				items = append(items, g.value(schema.Items, name, item, depth+1))
+			}
+		}
+		return items
+	case schema.Type == `boolean`:
+		return r.index%2 == 0
+	case schema.Type == `integer`:
+		return r.index + 1
+	case schema.Type == `number`:
+		return 100 + float64(r.index)*1.5
+	}
+	return g.str(name, r)
+}
+
+func (g *generator) object(schema *openapi.Schema, r row, depth int) map[string]interface{} {
+	object := map[string]interface{}{}
+	if depth >= maxDepth {
+		return object
+	}
+	for _, part := range schema.AllOf {
+		resolved, _ := g.spec.Schema(part)
+		for key, value := range g.object(resolved, r, depth) {
+			object[key] = value
+		}
+	}
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == `next_page_token` || name == `next_page_url` {
+			continue
+		}
+		object[name] = g.value(schema.Properties[name], name, r, depth+1)
+	}
+	// rows of metrics endpoints hold requested metrics as additional properties
+	if schema.AdditionalProperties != nil && !schema.AdditionalProperties.Forbidden() {
+		for _, metric := range strings.Split(g.query.Get(`metrics`), `,`) {
+			if _, ok := object[metric]; metric != `` && !ok {
+				object[metric] = g.value(schema.AdditionalProperties, `value`, r, depth+1)
+			}
+		}
+	}
+	return object
+}
+
+// str generates string by meaning guessed from schema or property name
+func (g *generator) str(name string, r row) string {
+	lower := strings.ToLower(name)
+	if kind, ok := entities[name]; ok {
+		if kind == r.kind {
+			return r.entity
+		}
+		values := g.values(kind)
+		return values[r.index%len(values)]
+	}
+	switch {
+	case containsAny(lower, timeWords):
+		return r.time.Format(timeLayout)
+	case containsAny(lower, hashWords):
+		return fmt.Sprintf(`%064x`, r.index+1)
+	case containsAny(lower, integerWords):
+		return strconv.Itoa(r.index + 1)
+	case containsAny(lower, decimalWords):
+		return strconv.FormatFloat(100+float64(r.index)*1.5, 'f', -1, 64)
+	}
+	return fmt.Sprintf(`%s %d`, name, r.index+1)
+}
+
+func containsAny(s string, words []string) bool {
+	for _, word := range words {
+		if strings.Contains(s, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,332 @@
+// Package mock is in-process fake of Coin Metrics api built from OpenAPI spec of the client. It serves deterministic,
+// schema-valid synthetic data for every path, paginates with `next_page_token`, serves seeded fixtures and injected errors.
+//
+//	server, err := mock.NewServer()
+//	ts := httptest.NewServer(server)
+//	defer ts.Close()
+//	client, err := coinmetrics.InitClient(ts.URL+`/`, `key`)
+package mock
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/rulesng/coinmetrics-go-sdk/openapi"
+)
+
+// Defaults of Server
+const (
+	DefaultSeriesLength = 5
+	DefaultPageSize     = 100
+)
+
+// errorTypes are error types of api by status
+var errorTypes = map[int]string{
+	http.StatusBadRequest:          constants.ErrorTypeBadParameter,
+	http.StatusUnauthorized:        `unauthorized`,
+	http.StatusForbidden:           constants.ErrorTypeForbidden,
+	http.StatusNotFound:            `not_found`,
+	http.StatusTooManyRequests:     `too_many_requests`,
+	http.StatusInternalServerError: `internal_server_error`,
+}
+
+type injected struct {
+	status int
+	// remaining is number of requests which still fail, negative fails until errors are cleared
+	remaining int
+}
+
+// Server is http.Handler serving api, use it with httptest.NewServer
+type Server struct {
+	spec         *openapi.Spec
+	routes       []openapi.Route
+	seriesLength int
+	apiKey       string
+
+	mu       sync.Mutex
+	fixtures map[string][]json.RawMessage
+	errors   map[string][]*injected
+	calls    map[string]int
+}
+
+// Option allows to customize Server
+type Option func(*Server)
+
+// WithSeriesLength sets number of synthetic records of every entity of paginated endpoints
+func WithSeriesLength(n int) Option {
+	return func(s *Server) {
+		s.seriesLength = n
+	}
+}
+
+// WithAPIKey makes server respond 401 to requests without api key
+func WithAPIKey(key string) Option {
+	return func(s *Server) {
+		s.apiKey = key
+	}
+}
+
+// WithSpec serves spec other than one the client is generated from
+func WithSpec(spec *openapi.Spec) Option {
+	return func(s *Server) {
+		s.spec = spec
+	}
+}
+
+// NewServer creates server of api spec
+func NewServer(opts ...Option) (*Server, error) {
+	s := Server{
+		seriesLength: DefaultSeriesLength,
+		fixtures:     map[string][]json.RawMessage{},
+		errors:       map[string][]*injected{},
+		calls:        map[string]int{},
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	if s.spec == nil {
+		spec, err := openapi.V4()
+		if err != nil {
+			return nil, err
+		}
+		s.spec = spec
+	}
+	s.routes = s.spec.Routes()
+	return &s, nil
+}
+
+// Seed serves records as `data` of path instead of synthetic ones, path is request path like `/timeseries/asset-metrics`
+// or path template of spec like `/blockchain-v2/{asset}/blocks`. Records are paginated like synthetic ones.
+func (s *Server) Seed(path string, records ...interface{}) error {
+	var raw []json.RawMessage
+	for _, record := range records {
+		b, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		raw = append(raw, b)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fixtures[path] = raw
+	return nil
+}
+
+// InjectError makes next times requests of path fail with status, times below 1 fail until ClearErrors.
+// Errors injected for the same path are returned in order.
+func (s *Server) InjectError(path string, status int, times int) {
+	if times < 1 {
+		times = -1
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors[path] = append(s.errors[path], &injected{status: status, remaining: times})
+}
+
+// ClearErrors removes all injected errors
+func (s *Server) ClearErrors() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors = map[string][]*injected{}
+}
+
+// Calls returns number of requests served for path, which is request path or path template of spec
+func (s *Server) Calls(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[path]
+}
+
+// ServeHTTP serves requests to paths of spec with or without api version prefix
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	path := strings.TrimPrefix(req.URL.Path, `/`+constants.ApiVersion)
+	route, params, ok := openapi.Match(s.routes, path)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf(`Path %s is not found.`, path))
+		return
+	}
+	if req.Method != route.Method {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf(`Method %s is not allowed.`, req.Method))
+		return
+	}
+
+	s.mu.Lock()
+	s.calls[path]++
+	if route.Path != path {
+		s.calls[route.Path]++
+	}
+	status := s.injectedStatus(path, route.Path)
+	fixtures, seeded := s.fixtures[path]
+	if !seeded {
+		fixtures, seeded = s.fixtures[route.Path]
+	}
+	s.mu.Unlock()
+
+	query := req.URL.Query()
+	switch {
+	case status != 0:
+		s.writeSpecError(w, route, status)
+		return
+	case s.apiKey != `` && query.Get(constants.ParamsApiKey) != s.apiKey:
+		s.writeSpecError(w, route, http.StatusUnauthorized)
+		return
+	}
+	for _, p := range route.Parameters {
+		if p.In == `query` && p.Required && query.Get(p.Name) == `` {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf(`Missing required parameter '%s'.`, p.Name))
+			return
+		}
+	}
+	schema, _, ok := route.JSONResponse(s.spec, http.StatusOK)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, fmt.Sprintf(`Path %s does not respond with json.`, route.Path))
+		return
+	}
+
+	g := newGenerator(s.spec, query, params)
+	data, hasData := schema.Properties[`data`]
+	if !hasData {
+		writeJSON(w, http.StatusOK, g.value(schema, ``, row{}, 0))
+		return
+	}
+	_, paginated := schema.Properties[`next_page_token`]
+	var records []interface{}
+	if seeded {
+		for _, fixture := range fixtures {
+			records = append(records, fixture)
+		}
+	} else {
+		length := 1
+		if paginated {
+			length = s.seriesLength
+		}
+		items, _ := s.spec.Schema(data)
+		for _, r := range g.rows(primaryKind(route), length) {
+			records = append(records, g.value(items.Items, ``, r, 1))
+		}
+	}
+
+	body := map[string]interface{}{}
+	if paginated {
+		offset, pageSize, err := page(query.Get(`next_page_token`), query.Get(`page_size`))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if offset > len(records) {
+			offset = len(records)
+		}
+		end := offset + pageSize
+		if end < len(records) {
+			token := base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(end)))
+			next := *req.URL
+			q := next.Query()
+			q.Set(`next_page_token`, token)
+			next.RawQuery = q.Encode()
+			next.Scheme, next.Host = `http`, req.Host
+			body[`next_page_token`] = token
+			body[`next_page_url`] = next.String()
+		} else {
+			end = len(records)
+		}
+		records = records[offset:end]
+	}
+	if records == nil {
+		records = []interface{}{}
+	}
+	body[`data`] = records
+	writeJSON(w, http.StatusOK, body)
+}
+
+// injectedStatus returns status of injected error of path and consumes it, s.mu must be held
+func (s *Server) injectedStatus(paths ...string) int {
+	for _, path := range paths {
+		for i, e := range s.errors[path] {
+			if e.remaining > 0 {
+				e.remaining--
+				if e.remaining == 0 {
+					s.errors[path] = append(s.errors[path][:i:i], s.errors[path][i+1:]...)
+				}
+			}
+			return e.status
+		}
+	}
+	return 0
+}
+
+// writeSpecError writes example of error response from spec when there is one
+func (s *Server) writeSpecError(w http.ResponseWriter, route openapi.Route, status int) {
+	if status == http.StatusTooManyRequests {
+		w.Header().Set(`Retry-After`, `1`)
+	}
+	if _, response, ok := route.JSONResponse(s.spec, status); ok {
+		content := response.Content[`application/json`]
+		if content.Example != nil {
+			writeJSON(w, status, content.Example)
+			return
+		}
+		names := make([]string, 0, len(content.Examples))
+		for name := range content.Examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) > 0 {
+			writeJSON(w, status, content.Examples[names[0]].Value)
+			return
+		}
+	}
+	writeError(w, status, http.StatusText(status))
+}
+
+// primaryKind returns first query param of route listing entities, records are generated for each of them
+func primaryKind(route openapi.Route) string {
+	for _, p := range route.Parameters {
+		if _, ok := defaultEntities[p.Name]; ok && p.In == `query` && p.Name != `metrics` {
+			return p.Name
+		}
+	}
+	return ``
+}
+
+// page decodes offset of next page token and page size
+func page(token, size string) (int, int, error) {
+	offset, pageSize := 0, DefaultPageSize
+	if token != `` {
+		raw, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil {
+			return 0, 0, fmt.Errorf(`Invalid next_page_token.`)
+		}
+		if offset, err = strconv.Atoi(string(raw)); err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf(`Invalid next_page_token.`)
+		}
+	}
+	if size != `` {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 1 {
+			return 0, 0, fmt.Errorf(`Invalid page_size.`)
+		}
+		pageSize = n
+	}
+	return offset, pageSize, nil
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	errorType, ok := errorTypes[status]
+	if !ok {
+		errorType = strings.ReplaceAll(strings.ToLower(http.StatusText(status)), ` `, `_`)
+	}
+	writeJSON(w, status, map[string]interface{}{`error`: map[string]string{`type`: errorType, `message`: message}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set(`Content-Type`, `application/json`)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
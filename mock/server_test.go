@@ -0,0 +1,171 @@
+package mock_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/rulesng/coinmetrics-go-sdk/mock"
+	"github.com/rulesng/coinmetrics-go-sdk/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func newClient(t *testing.T, opts ...mock.Option) (*mock.Server, coinmetrics.CoinMetrics) {
+	server, err := mock.NewServer(opts...)
+	assert.Nil(t, err)
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	client, err := coinmetrics.InitClient(ts.URL+`/`, constants.TestKey)
+	assert.Nil(t, err)
+	return server, client
+}
+
+func TestAssetMetricsPagination(t *testing.T) {
+	_, client := newClient(t)
+	pageSize := api.PageSize(3)
+	startTime := api.StartTime(`2022-05-01`)
+	params := api.GetTimeseriesAssetMetricsParams{Assets: `btc,eth`, Metrics: api.AssetMetrics{`PriceUSD`, `AdrActCnt`}, StartTime: &startTime, PageSize: &pageSize}
+	var rows []map[string]interface{}
+	pages := 0
+	for {
+		res, err := client.GetTimeseriesAssetMetricsWithResponse(context.Background(), &params)
+		assert.Nil(t, err)
+		if !assert.NotNil(t, res.JSON200) {
+			return
+		}
+		pages++
+		for _, row := range res.JSON200.Data.([]interface{}) {
+			rows = append(rows, row.(map[string]interface{}))
+		}
+		if res.JSON200.NextPageToken == nil {
+			break
+		}
+		params.NextPageToken = res.JSON200.NextPageToken
+	}
+	assert.Equal(t, 4, pages)
+	assert.Len(t, rows, 2*mock.DefaultSeriesLength)
+	assert.Equal(t, `btc`, rows[0][`asset`])
+	assert.Equal(t, `2022-05-01T00:00:00.000000000Z`, rows[0][`time`])
+	assert.Equal(t, `2022-05-02T00:00:00.000000000Z`, rows[1][`time`])
+	assert.Equal(t, `eth`, rows[mock.DefaultSeriesLength][`asset`])
+	assert.Contains(t, rows[0], `PriceUSD`)
+	assert.Contains(t, rows[0], `AdrActCnt`)
+
+	// responses are deterministic
+	params.NextPageToken = nil
+	first, _ := client.GetTimeseriesAssetMetricsWithResponse(context.Background(), &params)
+	second, _ := client.GetTimeseriesAssetMetricsWithResponse(context.Background(), &params)
+	assert.Equal(t, string(first.Body), string(second.Body))
+}
+
+func TestEveryRoute(t *testing.T) {
+	server, err := mock.NewServer()
+	assert.Nil(t, err)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	spec, err := openapi.V4()
+	assert.Nil(t, err)
+	for _, route := range spec.Routes() {
+		if _, _, ok := route.JSONResponse(spec, http.StatusOK); !ok {
+			continue
+		}
+		path := strings.NewReplacer(`{asset}`, `btc`, `{block_hash}`, `abc`, `{transaction_hash}`, `def`, `{txid}`, `def`).Replace(route.Path)
+		var query []string
+		for _, p := range route.Parameters {
+			if p.In == `query` && p.Required {
+				query = append(query, p.Name+`=btc`)
+			}
+		}
+		res, err := http.Get(ts.URL + `/v4` + path + `?` + strings.Join(query, `&`))
+		if !assert.Nil(t, err) {
+			continue
+		}
+		var body map[string]interface{}
+		assert.Equal(t, http.StatusOK, res.StatusCode, route.Path)
+		assert.Nil(t, json.NewDecoder(res.Body).Decode(&body), route.Path)
+		res.Body.Close()
+	}
+}
+
+func TestTypedResponses(t *testing.T) {
+	_, client := newClient(t)
+	ctx := context.Background()
+
+	assets, err := client.GetCatalogAssetsWithResponse(ctx, &api.GetCatalogAssetsParams{Assets: &api.CatalogAssetId{`btc`, `eth`}})
+	assert.Nil(t, err)
+	if assert.NotNil(t, assets.JSON200) && assert.Len(t, assets.JSON200.Data, 2) {
+		assert.Equal(t, api.AssetId(`eth`), assets.JSON200.Data[1].Asset)
+	}
+
+	block, err := client.GetBlockchainV2FullBlockWithResponse(ctx, `btc`, `abc`, &api.GetBlockchainV2FullBlockParams{})
+	assert.Nil(t, err)
+	if assert.NotNil(t, block.JSON200) {
+		assert.NotEmpty(t, block.JSON200.BlockHash)
+		assert.NotNil(t, block.JSON200.Transactions)
+	}
+
+	feerates, err := client.GetMempoolFeeratesWithResponse(ctx, &api.GetMempoolFeeratesParams{Assets: `btc`})
+	assert.Nil(t, err)
+	if assert.NotNil(t, feerates.JSON200) {
+		assert.Len(t, feerates.JSON200.Data, mock.DefaultSeriesLength)
+	}
+}
+
+func TestSeedAndErrors(t *testing.T) {
+	server, client := newClient(t, mock.WithAPIKey(constants.TestKey))
+	ctx := context.Background()
+	assert.Nil(t, server.Seed(`/timeseries/index-levels`,
+		map[string]string{`index`: `CMBI10`, `time`: `2022-05-01T00:00:00.000000000Z`, `level`: `1000`},
+		map[string]string{`index`: `CMBI10`, `time`: `2022-05-02T00:00:00.000000000Z`, `level`: `1100`},
+	))
+	pageSize := api.PageSize(1)
+	params := api.GetTimeseriesIndexLevelsParams{Indexes: `CMBI10`, PageSize: &pageSize}
+	res, err := client.GetTimeseriesIndexLevelsWithResponse(ctx, &params)
+	assert.Nil(t, err)
+	if assert.NotNil(t, res.JSON200) {
+		assert.Equal(t, api.IndexLevelValue(`1000`), res.JSON200.Data[0].Level)
+		params.NextPageToken = res.JSON200.NextPageToken
+	}
+	res, err = client.GetTimeseriesIndexLevelsWithResponse(ctx, &params)
+	assert.Nil(t, err)
+	if assert.NotNil(t, res.JSON200) {
+		assert.Equal(t, api.IndexLevelValue(`1100`), res.JSON200.Data[0].Level)
+		assert.Nil(t, res.JSON200.NextPageToken)
+	}
+
+	server.InjectError(`/timeseries/index-levels`, http.StatusTooManyRequests, 1)
+	server.InjectError(`/timeseries/index-levels`, http.StatusBadRequest, 1)
+	params.NextPageToken = nil
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusBadRequest, http.StatusOK} {
+		res, err = client.GetTimeseriesIndexLevelsWithResponse(ctx, &params)
+		assert.Nil(t, err)
+		assert.Equal(t, status, res.StatusCode())
+	}
+	server.InjectError(`/blockchain-v2/{asset}/blocks`, http.StatusInternalServerError, 0)
+	for i := 0; i < 2; i++ {
+		blocks, err := client.GetBlockchainV2ListOfBlocksWithResponse(ctx, `btc`, &api.GetBlockchainV2ListOfBlocksParams{})
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusInternalServerError, blocks.StatusCode())
+	}
+	server.ClearErrors()
+	blocks, err := client.GetBlockchainV2ListOfBlocksWithResponse(ctx, `btc`, &api.GetBlockchainV2ListOfBlocksParams{})
+	assert.Nil(t, err)
+	assert.NotNil(t, blocks.JSON200)
+	assert.Equal(t, 3, server.Calls(`/blockchain-v2/{asset}/blocks`))
+	assert.Equal(t, 3, server.Calls(`/blockchain-v2/btc/blocks`))
+
+	res, err = client.GetTimeseriesIndexLevelsWithResponse(ctx, &params, func(ctx context.Context, req *http.Request) error {
+		req.URL.RawQuery = strings.Replace(req.URL.RawQuery, constants.ParamsApiKey, `other`, 1)
+		return nil
+	})
+	assert.Nil(t, err)
+	if assert.NotNil(t, res.JSON401) {
+		assert.Equal(t, `unauthorized`, res.JSON401.Error.Type)
+	}
+}
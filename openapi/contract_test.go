@@ -0,0 +1,159 @@
+package openapi_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/rulesng/coinmetrics-go-sdk/mock"
+	"github.com/rulesng/coinmetrics-go-sdk/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+// knownDrift lists differences of types of the client from spec, the list shrinks as types are fixed
+var knownDrift = map[string][]string{
+	`/blockchain-v2/{asset}/balance-updates`: {
+		`data[].sub_account.account: missing required field`,
+		`data[].sub_account.account_creation_height: missing required field`,
+		`data[].sub_account.chain_sequence_number: missing required field`,
+		`data[].sub_account.change: missing required field`,
+		`data[].sub_account.previous_n_credits: missing required field`,
+		`data[].sub_account.previous_n_debits: missing required field`,
+		`data[].sub_account.transaction_sequence_number: missing required field`,
+	},
+	`/blockchain-v2/{asset}/blocks/{block_hash}`: {
+		`balance_updates[].sub_account.account: missing required field`,
+		`balance_updates[].sub_account.account_creation_height: missing required field`,
+		`balance_updates[].sub_account.chain_sequence_number: missing required field`,
+		`balance_updates[].sub_account.change: missing required field`,
+		`balance_updates[].sub_account.previous_n_credits: missing required field`,
+		`balance_updates[].sub_account.previous_n_debits: missing required field`,
+		`balance_updates[].sub_account.transaction_sequence_number: missing required field`,
+		`export_time: missing required field`,
+		`transactions[].balance_updates[].sub_account.account: missing required field`,
+		`transactions[].balance_updates[].sub_account.account_creation_height: missing required field`,
+		`transactions[].balance_updates[].sub_account.chain_sequence_number: missing required field`,
+		`transactions[].balance_updates[].sub_account.change: missing required field`,
+		`transactions[].balance_updates[].sub_account.previous_n_credits: missing required field`,
+		`transactions[].balance_updates[].sub_account.previous_n_debits: missing required field`,
+		`transactions[].balance_updates[].sub_account.transaction_sequence_number: missing required field`,
+	},
+	`/blockchain-v2/{asset}/blocks/{block_hash}/transactions/{txid}`: {
+		`balance_updates[].sub_account.account: missing required field`,
+		`balance_updates[].sub_account.account_creation_height: missing required field`,
+		`balance_updates[].sub_account.chain_sequence_number: missing required field`,
+		`balance_updates[].sub_account.change: missing required field`,
+		`balance_updates[].sub_account.previous_n_credits: missing required field`,
+		`balance_updates[].sub_account.previous_n_debits: missing required field`,
+		`balance_updates[].sub_account.transaction_sequence_number: missing required field`,
+	},
+	`/blockchain-v2/{asset}/transactions/{txid}`: {
+		`balance_updates[].sub_account.account: missing required field`,
+		`balance_updates[].sub_account.account_creation_height: missing required field`,
+		`balance_updates[].sub_account.chain_sequence_number: missing required field`,
+		`balance_updates[].sub_account.change: missing required field`,
+		`balance_updates[].sub_account.previous_n_credits: missing required field`,
+		`balance_updates[].sub_account.previous_n_debits: missing required field`,
+		`balance_updates[].sub_account.transaction_sequence_number: missing required field`,
+	},
+	`/catalog-all/exchange-assets`: {
+		`data[].pair: missing required field`,
+	},
+	`/catalog-all/market-candles`: {
+		`data[].candles: missing required field`,
+	},
+	`/catalog/exchange-assets`: {
+		`data[].pair: missing required field`,
+	},
+	`/catalog/market-candles`: {
+		`data[].candles: missing required field`,
+	},
+}
+
+var index = regexp.MustCompile(`\[\d+\]`)
+
+// contract calls operation of route with the client and validates decoded response against spec
+func contract(t *testing.T, spec *openapi.Spec, client coinmetrics.CoinMetrics, route openapi.Route) []string {
+	id := route.Operation.OperationID
+	method := reflect.ValueOf(client).MethodByName(strings.ToUpper(id[:1]) + id[1:] + `WithResponse`)
+	if !method.IsValid() {
+		return []string{`no client method`}
+	}
+	// required query params are set by editor, so params of every operation can be left empty
+	required := func(ctx context.Context, req *http.Request) error {
+		q := req.URL.Query()
+		for _, p := range route.Parameters {
+			if p.In == `query` && p.Required && q.Get(p.Name) == `` {
+				q.Set(p.Name, `btc`)
+			}
+		}
+		req.URL.RawQuery = q.Encode()
+		return nil
+	}
+	methodType := method.Type()
+	args := []reflect.Value{reflect.ValueOf(context.Background())}
+	for i := 1; i < methodType.NumIn()-1; i++ {
+		in := methodType.In(i)
+		switch {
+		case in.Kind() == reflect.Ptr:
+			args = append(args, reflect.New(in.Elem()))
+		case in.Kind() == reflect.String:
+			args = append(args, reflect.ValueOf(`btc`).Convert(in))
+		default:
+			args = append(args, reflect.Zero(in))
+		}
+	}
+	args = append(args, reflect.ValueOf(api.RequestEditorFn(required)))
+	out := method.Call(args)
+	if err, _ := out[1].Interface().(error); err != nil {
+		return []string{err.Error()}
+	}
+	json200 := out[0].Elem().FieldByName(`JSON200`)
+	if !json200.IsValid() || json200.IsNil() {
+		return []string{`response is not decoded`}
+	}
+	schema, _, _ := route.JSONResponse(spec, http.StatusOK)
+	violations, err := spec.ValidateValue(schema, json200.Interface())
+	if err != nil {
+		return []string{err.Error()}
+	}
+	// the same field of synthetic rows is reported once
+	unique := map[string]bool{}
+	var found []string
+	for _, violation := range violations {
+		violation.Path = index.ReplaceAllString(violation.Path, `[]`)
+		if !unique[violation.String()] {
+			unique[violation.String()] = true
+			found = append(found, violation.String())
+		}
+	}
+	sort.Strings(found)
+	return found
+}
+
+func TestContract(t *testing.T) {
+	spec, err := openapi.V4()
+	assert.Nil(t, err)
+	server, err := mock.NewServer(mock.WithSeriesLength(1))
+	assert.Nil(t, err)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	client, err := coinmetrics.InitClient(ts.URL+`/`, constants.TestKey)
+	assert.Nil(t, err)
+
+	for _, route := range spec.Routes() {
+		if _, _, ok := route.JSONResponse(spec, http.StatusOK); !ok {
+			continue
+		}
+		found := contract(t, spec, client, route)
+		assert.Equal(t, knownDrift[route.Path], found, route.Path)
+	}
+}
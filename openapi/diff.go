@@ -0,0 +1,119 @@
+package openapi
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Kinds of Change
+const (
+	OperationAdded   = `operation added`
+	OperationRemoved = `operation removed`
+	OperationRenamed = `operation renamed`
+	ParamAdded       = `param added`
+	ParamRemoved     = `param removed`
+	ParamRequired    = `param required`
+	ParamOptional    = `param optional`
+	SchemaAdded      = `schema added`
+	SchemaRemoved    = `schema removed`
+)
+
+// Change is difference between spec versions
+type Change struct {
+	Kind string
+	// Path of operation, empty for schemas
+	Path string
+	// Name of operation id, param or schema
+	Name string
+	// Detail like previous operation id
+	Detail string
+}
+
+func (c Change) String() string {
+	s := c.Kind
+	if c.Path != `` {
+		s += ` ` + c.Path
+	}
+	if c.Name != `` {
+		s += ` ` + c.Name
+	}
+	if c.Detail != `` {
+		s += fmt.Sprintf(` (%s)`, c.Detail)
+	}
+	return s
+}
+
+// Diff lists operations, params and component schemas added or removed between old and new spec, ordered by path
+func Diff(old, new *Spec) []Change {
+	var changes []Change
+	oldRoutes, newRoutes := routesByPath(old), routesByPath(new)
+	for path, route := range oldRoutes {
+		if _, ok := newRoutes[path]; !ok {
+			changes = append(changes, Change{Kind: OperationRemoved, Path: path, Name: route.Operation.OperationID})
+		}
+	}
+	for path, route := range newRoutes {
+		previous, ok := oldRoutes[path]
+		if !ok {
+			changes = append(changes, Change{Kind: OperationAdded, Path: path, Name: route.Operation.OperationID})
+			continue
+		}
+		if previous.Operation.OperationID != route.Operation.OperationID {
+			changes = append(changes, Change{Kind: OperationRenamed, Path: path, Name: route.Operation.OperationID, Detail: previous.Operation.OperationID})
+		}
+		changes = append(changes, diffParams(path, previous, route)...)
+	}
+	for name := range old.Components.Schemas {
+		if _, ok := new.Components.Schemas[name]; !ok {
+			changes = append(changes, Change{Kind: SchemaRemoved, Name: name})
+		}
+	}
+	for name := range new.Components.Schemas {
+		if _, ok := old.Components.Schemas[name]; !ok {
+			changes = append(changes, Change{Kind: SchemaAdded, Name: name})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Path != changes[j].Path {
+			return changes[i].Path < changes[j].Path
+		}
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind < changes[j].Kind
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+func diffParams(path string, old, new Route) []Change {
+	var changes []Change
+	for _, p := range old.Parameters {
+		if _, ok := new.Param(p.Name); !ok {
+			changes = append(changes, Change{Kind: ParamRemoved, Path: path, Name: p.Name})
+		}
+	}
+	for _, p := range new.Parameters {
+		previous, ok := old.Param(p.Name)
+		switch {
+		case !ok:
+			detail := ``
+			if p.Required {
+				detail = `required`
+			}
+			changes = append(changes, Change{Kind: ParamAdded, Path: path, Name: p.Name, Detail: detail})
+		case p.Required && !previous.Required:
+			changes = append(changes, Change{Kind: ParamRequired, Path: path, Name: p.Name})
+		case !p.Required && previous.Required:
+			changes = append(changes, Change{Kind: ParamOptional, Path: path, Name: p.Name})
+		}
+	}
+	return changes
+}
+
+func routesByPath(spec *Spec) map[string]Route {
+	routes := map[string]Route{}
+	for _, route := range spec.Routes() {
+		routes[route.Path] = route
+	}
+	return routes
+}
@@ -0,0 +1,95 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+)
+
+// HttpRequestDoer performs requests, it is the same as api.HttpRequestDoer of the client which is not imported to keep
+// the package usable by generator of the client
+type HttpRequestDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// ValidatingDoer is HttpRequestDoer validating json responses against spec, it is meant for debugging drift of the
+// client from api and costs decoding every response twice
+type ValidatingDoer struct {
+	client HttpRequestDoer
+	spec   *Spec
+	routes []Route
+	strict bool
+	report func(req *http.Request, violations []Violation)
+}
+
+// ValidatingOption allows to customize ValidatingDoer
+type ValidatingOption func(*ValidatingDoer)
+
+// WithStrict makes responses with violations fail with error
+func WithStrict() ValidatingOption {
+	return func(d *ValidatingDoer) {
+		d.strict = true
+	}
+}
+
+// WithReport sets function receiving violations of responses, they are logged by default
+func WithReport(report func(req *http.Request, violations []Violation)) ValidatingOption {
+	return func(d *ValidatingDoer) {
+		d.report = report
+	}
+}
+
+// NewValidatingDoer wraps client, http.DefaultClient is used when client is nil
+func NewValidatingDoer(client HttpRequestDoer, spec *Spec, opts ...ValidatingOption) *ValidatingDoer {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	d := ValidatingDoer{client: client, spec: spec, routes: spec.Routes(), report: logViolations}
+	for _, opt := range opts {
+		opt(&d)
+	}
+	return &d
+}
+
+// Do sends request and validates json response, response which can not be decoded is reported as violation
+func (d *ValidatingDoer) Do(req *http.Request) (*http.Response, error) {
+	res, err := d.client.Do(req)
+	if err != nil || !strings.HasPrefix(res.Header.Get(`Content-Type`), `application/json`) {
+		return res, err
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	path := req.URL.Path
+	if i := strings.Index(path, `/`+constants.ApiVersion+`/`); i >= 0 {
+		path = path[i+len(constants.ApiVersion)+1:]
+	}
+	violations, err := d.spec.ValidateResponse(d.routes, path, res.StatusCode, body)
+	if err != nil {
+		// body which is not json is violation of root value
+		violations = []Violation{{Message: err.Error()}}
+	}
+	if len(violations) == 0 {
+		return res, nil
+	}
+	d.report(req, violations)
+	if d.strict {
+		return nil, fmt.Errorf(`%s: %s %s`, constants.SchemaViolation, path, violations[0])
+	}
+	return res, nil
+}
+
+func logViolations(req *http.Request, violations []Violation) {
+	for _, violation := range violations {
+		log.Printf(`%s: %s %s`, constants.SchemaViolation, req.URL.Path, violation)
+	}
+}
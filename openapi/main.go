@@ -0,0 +1,250 @@
+// Package openapi reads OpenAPI spec of Coin Metrics api, it covers the parts of OpenAPI 3 used by `api/v4/spec/v4.yml`
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	apispec "github.com/rulesng/coinmetrics-go-sdk/api/v4/spec"
+	"gopkg.in/yaml.v3"
+)
+
+const refPrefix = `#/components/`
+
+// Spec is OpenAPI document
+type Spec struct {
+	OpenAPI    string               `yaml:"openapi"`
+	Paths      map[string]*PathItem `yaml:"paths"`
+	Components Components           `yaml:"components"`
+}
+
+// Components are reusable parts of spec referenced by `$ref`
+type Components struct {
+	Parameters map[string]*Parameter `yaml:"parameters"`
+	Responses  map[string]*Response  `yaml:"responses"`
+	Schemas    map[string]*Schema    `yaml:"schemas"`
+}
+
+// PathItem describes operations of path
+type PathItem struct {
+	Get        *Operation   `yaml:"get"`
+	Parameters []*Parameter `yaml:"parameters"`
+}
+
+// Operation describes single api operation
+type Operation struct {
+	OperationID string               `yaml:"operationId"`
+	Summary     string               `yaml:"summary"`
+	Parameters  []*Parameter         `yaml:"parameters"`
+	Responses   map[string]*Response `yaml:"responses"`
+}
+
+// Parameter describes query or path param
+type Parameter struct {
+	Ref         string  `yaml:"$ref"`
+	Name        string  `yaml:"name"`
+	In          string  `yaml:"in"`
+	Description string  `yaml:"description"`
+	Required    bool    `yaml:"required"`
+	Schema      *Schema `yaml:"schema"`
+}
+
+// Response describes response of status
+type Response struct {
+	Ref         string               `yaml:"$ref"`
+	Description string               `yaml:"description"`
+	Content     map[string]MediaType `yaml:"content"`
+}
+
+// MediaType describes response body of content type
+type MediaType struct {
+	Schema   *Schema            `yaml:"schema"`
+	Example  interface{}        `yaml:"example"`
+	Examples map[string]Example `yaml:"examples"`
+}
+
+// Example is named example of response body
+type Example struct {
+	Summary string      `yaml:"summary"`
+	Value   interface{} `yaml:"value"`
+}
+
+// Schema describes json value
+type Schema struct {
+	Ref                  string             `yaml:"$ref"`
+	Type                 string             `yaml:"type"`
+	Format               string             `yaml:"format"`
+	Description          string             `yaml:"description"`
+	Enum                 []interface{}      `yaml:"enum"`
+	Nullable             bool               `yaml:"nullable"`
+	Properties           map[string]*Schema `yaml:"properties"`
+	Required             []string           `yaml:"required"`
+	Items                *Schema            `yaml:"items"`
+	AdditionalProperties *Schema            `yaml:"additionalProperties"`
+	AllOf                []*Schema          `yaml:"allOf"`
+	OneOf                []*Schema          `yaml:"oneOf"`
+	AnyOf                []*Schema          `yaml:"anyOf"`
+
+	// forbidden is set by `additionalProperties: false`
+	forbidden bool
+}
+
+// UnmarshalYAML accepts boolean schemas of additionalProperties
+func (s *Schema) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var allowed bool
+		if err := node.Decode(&allowed); err != nil {
+			return err
+		}
+		*s = Schema{forbidden: !allowed}
+		return nil
+	}
+	type plain Schema
+	return node.Decode((*plain)(s))
+}
+
+// Forbidden tells whether schema does not allow any value, like `additionalProperties: false`
+func (s *Schema) Forbidden() bool {
+	return s.forbidden
+}
+
+// Load parses OpenAPI document
+func Load(data []byte) (*Spec, error) {
+	var spec Spec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+var (
+	v4Once sync.Once
+	v4     *Spec
+	v4Err  error
+)
+
+// V4 returns spec the client is generated from, it is parsed once and must not be modified
+func V4() (*Spec, error) {
+	v4Once.Do(func() {
+		v4, v4Err = Load(apispec.YAML)
+	})
+	return v4, v4Err
+}
+
+// Schema resolves `$ref` of schema, name is name of last referenced component or empty for inline schema
+func (s *Spec) Schema(schema *Schema) (resolved *Schema, name string) {
+	for schema != nil && schema.Ref != `` {
+		name = strings.TrimPrefix(schema.Ref, refPrefix+`schemas/`)
+		schema = s.Components.Schemas[name]
+	}
+	return schema, name
+}
+
+// Parameter resolves `$ref` of parameter
+func (s *Spec) Parameter(parameter *Parameter) *Parameter {
+	for parameter != nil && parameter.Ref != `` {
+		parameter = s.Components.Parameters[strings.TrimPrefix(parameter.Ref, refPrefix+`parameters/`)]
+	}
+	return parameter
+}
+
+// Response resolves `$ref` of response
+func (s *Spec) Response(response *Response) *Response {
+	for response != nil && response.Ref != `` {
+		response = s.Components.Responses[strings.TrimPrefix(response.Ref, refPrefix+`responses/`)]
+	}
+	return response
+}
+
+// Route is operation of path with resolved parameters
+type Route struct {
+	Method     string
+	Path       string
+	Operation  *Operation
+	Parameters []*Parameter
+	pattern    *regexp.Regexp
+	names      []string
+}
+
+// JSONResponse returns json schema and response of status, ok is false when route does not respond with json
+func (r Route) JSONResponse(spec *Spec, status int) (*Schema, *Response, bool) {
+	response := spec.Response(r.Operation.Responses[fmt.Sprint(status)])
+	if response == nil {
+		return nil, nil, false
+	}
+	content, ok := response.Content[`application/json`]
+	if !ok {
+		return nil, response, false
+	}
+	schema, _ := spec.Schema(content.Schema)
+	return schema, response, true
+}
+
+// Param returns parameter of route by name
+func (r Route) Param(name string) (*Parameter, bool) {
+	for _, p := range r.Parameters {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// PathParams returns names of path params in order of path template
+func (r Route) PathParams() []string {
+	return append([]string(nil), r.names...)
+}
+
+var templateParam = regexp.MustCompile(`\{([^}/]+)\}`)
+
+// Routes returns GET operations of spec ordered by path
+func (s *Spec) Routes() []Route {
+	var routes []Route
+	for path, item := range s.Paths {
+		if item == nil || item.Get == nil {
+			continue
+		}
+		route := Route{Method: http.MethodGet, Path: path, Operation: item.Get}
+		for _, p := range append(append([]*Parameter(nil), item.Parameters...), item.Get.Parameters...) {
+			if p = s.Parameter(p); p != nil {
+				route.Parameters = append(route.Parameters, p)
+			}
+		}
+		pattern := regexp.QuoteMeta(path)
+		for _, match := range templateParam.FindAllStringSubmatch(path, -1) {
+			route.names = append(route.names, match[1])
+			pattern = strings.Replace(pattern, regexp.QuoteMeta(match[0]), `([^/]+)`, 1)
+		}
+		route.pattern = regexp.MustCompile(`^` + pattern + `$`)
+		routes = append(routes, route)
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Path < routes[j].Path })
+	return routes
+}
+
+// Match returns route of request path and values of its path params, templates match after literal paths
+func Match(routes []Route, path string) (Route, map[string]string, bool) {
+	var found *Route
+	var values []string
+	for i := range routes {
+		match := routes[i].pattern.FindStringSubmatch(path)
+		if match == nil {
+			continue
+		}
+		if found == nil || len(routes[i].names) < len(found.names) {
+			found, values = &routes[i], match[1:]
+		}
+	}
+	if found == nil {
+		return Route{}, nil, false
+	}
+	params := map[string]string{}
+	for i, name := range found.names {
+		params[name] = values[i]
+	}
+	return *found, params, true
+}
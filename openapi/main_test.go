@@ -0,0 +1,109 @@
+package openapi_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/rulesng/coinmetrics-go-sdk/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutes(t *testing.T) {
+	spec, err := openapi.V4()
+	assert.Nil(t, err)
+	routes := spec.Routes()
+	assert.NotEmpty(t, routes)
+
+	route, params, ok := openapi.Match(routes, `/blockchain-v2/btc/blocks/abc`)
+	assert.True(t, ok)
+	assert.Equal(t, `/blockchain-v2/{asset}/blocks/{block_hash}`, route.Path)
+	assert.Equal(t, map[string]string{`asset`: `btc`, `block_hash`: `abc`}, params)
+
+	route, _, ok = openapi.Match(routes, `/timeseries/asset-metrics`)
+	assert.True(t, ok)
+	p, ok := route.Param(`assets`)
+	assert.True(t, ok)
+	assert.True(t, p.Required)
+	schema, _, ok := route.JSONResponse(spec, http.StatusOK)
+	assert.True(t, ok)
+	assert.Contains(t, schema.Properties, `next_page_token`)
+
+	_, _, ok = openapi.Match(routes, `/unknown`)
+	assert.False(t, ok)
+}
+
+func TestSchema(t *testing.T) {
+	spec, err := openapi.Load([]byte(`
+openapi: 3.0.0
+components:
+  schemas:
+    Id:
+      $ref: '#/components/schemas/Name'
+    Name:
+      type: string
+    Row:
+      type: object
+      additionalProperties: false
+`))
+	assert.Nil(t, err)
+	schema, name := spec.Schema(&openapi.Schema{Ref: `#/components/schemas/Id`})
+	assert.Equal(t, `Name`, name)
+	assert.Equal(t, `string`, schema.Type)
+	row, _ := spec.Schema(&openapi.Schema{Ref: `#/components/schemas/Row`})
+	assert.True(t, row.AdditionalProperties.Forbidden())
+}
+
+func TestDiff(t *testing.T) {
+	old, err := openapi.Load([]byte(`
+paths:
+  /timeseries/asset-metrics:
+    get:
+      operationId: getTimeseriesAssetMetrics
+      parameters:
+        - {name: assets, in: query, required: true}
+        - {name: frequency, in: query}
+        - {name: limit, in: query}
+  /timeseries/market-open-interest:
+    get:
+      operationId: getTimeseriesMarketOpenIntereset
+  /catalog/assets:
+    get:
+      operationId: getCatalogAssets
+components:
+  schemas:
+    Asset: {type: string}
+`))
+	assert.Nil(t, err)
+	new, err := openapi.Load([]byte(`
+paths:
+  /timeseries/asset-metrics:
+    get:
+      operationId: getTimeseriesAssetMetrics
+      parameters:
+        - {name: assets, in: query}
+        - {name: frequency, in: query, required: true}
+        - {name: page_size, in: query, required: true}
+  /timeseries/market-open-interest:
+    get:
+      operationId: getTimeseriesMarketOpenInterest
+components:
+  schemas:
+    AssetId: {type: string}
+`))
+	assert.Nil(t, err)
+	var changes []string
+	for _, change := range openapi.Diff(old, new) {
+		changes = append(changes, change.String())
+	}
+	assert.Equal(t, []string{
+		`schema added AssetId`,
+		`schema removed Asset`,
+		`operation removed /catalog/assets getCatalogAssets`,
+		`param added /timeseries/asset-metrics page_size (required)`,
+		`param optional /timeseries/asset-metrics assets`,
+		`param removed /timeseries/asset-metrics limit`,
+		`param required /timeseries/asset-metrics frequency`,
+		`operation renamed /timeseries/market-open-interest getTimeseriesMarketOpenInterest (getTimeseriesMarketOpenIntereset)`,
+	}, changes)
+	assert.Empty(t, openapi.Diff(new, new))
+}
@@ -0,0 +1,239 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Violation is difference of json value from schema
+type Violation struct {
+	// Path of value like `data[0].asset`, empty for root value
+	Path    string
+	Message string
+}
+
+func (v Violation) String() string {
+	if v.Path == `` {
+		return v.Message
+	}
+	return fmt.Sprintf(`%s: %s`, v.Path, v.Message)
+}
+
+// Messages of violations
+const (
+	UnknownField  = `unknown field`
+	MissingField  = `missing required field`
+	UnexpectedNil = `unexpected null`
+	InvalidEnum   = `value is not in enum`
+)
+
+// Validate checks json value decoded with json.Decoder.UseNumber against schema, reporting unknown and missing fields,
+// wrong types and enum values
+func (s *Spec) Validate(schema *Schema, value interface{}) []Violation {
+	var violations []Violation
+	s.validate(schema, value, ``, &violations)
+	return violations
+}
+
+// ValidateJSON decodes body and validates it against schema
+func (s *Spec) ValidateJSON(schema *Schema, body []byte) ([]Violation, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return s.Validate(schema, value), nil
+}
+
+// ValidateValue validates json encoding of value, like decoded response of the client. Fields of Go types missing in
+// schema are reported as unknown and fields of schema missing in Go types as missing.
+func (s *Spec) ValidateValue(schema *Schema, value interface{}) ([]Violation, error) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return s.ValidateJSON(schema, body)
+}
+
+// ValidateResponse validates json body of response to GET request path of api, like `/timeseries/asset-metrics`.
+// Responses of unknown paths or statuses, or without json schema, have no violations.
+func (s *Spec) ValidateResponse(routes []Route, path string, status int, body []byte) ([]Violation, error) {
+	route, _, ok := Match(routes, path)
+	if !ok {
+		return nil, nil
+	}
+	schema, _, ok := route.JSONResponse(s, status)
+	if !ok || schema == nil {
+		return nil, nil
+	}
+	return s.ValidateJSON(schema, body)
+}
+
+func (s *Spec) validate(schema *Schema, value interface{}, path string, violations *[]Violation) {
+	schema, _ = s.Schema(schema)
+	if schema == nil {
+		return
+	}
+	report := func(format string, args ...interface{}) {
+		*violations = append(*violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if value == nil {
+		if !schema.Nullable {
+			report(UnexpectedNil)
+		}
+		return
+	}
+	if alternatives := append(append([]*Schema(nil), schema.OneOf...), schema.AnyOf...); len(alternatives) > 0 {
+		var closest []Violation
+		for i, alternative := range alternatives {
+			var found []Violation
+			s.validate(alternative, value, path, &found)
+			if len(found) == 0 {
+				return
+			}
+			if i == 0 || len(found) < len(closest) {
+				closest = found
+			}
+		}
+		*violations = append(*violations, closest...)
+		return
+	}
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		report(`%s %v`, InvalidEnum, value)
+		return
+	}
+
+	switch schema.Type {
+	case `object`:
+		if _, ok := value.(map[string]interface{}); !ok {
+			report(`expected object, got %s`, jsonType(value))
+			return
+		}
+	case `array`:
+		items, ok := value.([]interface{})
+		if !ok {
+			report(`expected array, got %s`, jsonType(value))
+			return
+		}
+		for i, item := range items {
+			s.validate(schema.Items, item, fmt.Sprintf(`%s[%d]`, path, i), violations)
+		}
+		return
+	case `string`:
+		if _, ok := value.(string); !ok {
+			report(`expected string, got %s`, jsonType(value))
+		}
+		return
+	case `boolean`:
+		if _, ok := value.(bool); !ok {
+			report(`expected boolean, got %s`, jsonType(value))
+		}
+		return
+	case `integer`:
+		if n, ok := value.(json.Number); !ok || strings.ContainsAny(n.String(), `.eE`) {
+			report(`expected integer, got %s`, jsonType(value))
+		}
+		return
+	case `number`:
+		if _, ok := value.(json.Number); !ok {
+			report(`expected number, got %s`, jsonType(value))
+		}
+		return
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	properties, required, additional := s.fields(schema)
+	// objects without declared properties are free-form
+	if len(properties) == 0 && additional == nil {
+		return
+	}
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field := name
+		if path != `` {
+			field = path + `.` + name
+		}
+		if property, ok := properties[name]; ok {
+			s.validate(property, object[name], field, violations)
+		} else if additional != nil {
+			s.validate(additional, object[name], field, violations)
+		} else {
+			*violations = append(*violations, Violation{Path: field, Message: UnknownField})
+		}
+	}
+	for _, name := range required {
+		if _, ok := object[name]; !ok {
+			field := name
+			if path != `` {
+				field = path + `.` + name
+			}
+			*violations = append(*violations, Violation{Path: field, Message: MissingField})
+		}
+	}
+}
+
+// fields returns properties, required properties and schema of additional properties of object schema merged with
+// its allOf parts. Additional properties are nil when they are not allowed.
+func (s *Spec) fields(schema *Schema) (map[string]*Schema, []string, *Schema) {
+	properties := map[string]*Schema{}
+	var required []string
+	var additional *Schema
+	for _, part := range schema.AllOf {
+		resolved, _ := s.Schema(part)
+		if resolved == nil {
+			continue
+		}
+		p, r, a := s.fields(resolved)
+		for name, property := range p {
+			properties[name] = property
+		}
+		required = append(required, r...)
+		if a != nil {
+			additional = a
+		}
+	}
+	for name, property := range schema.Properties {
+		properties[name] = property
+	}
+	required = append(required, schema.Required...)
+	if schema.AdditionalProperties != nil && !schema.AdditionalProperties.Forbidden() {
+		additional = schema.AdditionalProperties
+	}
+	return properties, required, additional
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return `object`
+	case []interface{}:
+		return `array`
+	case string:
+		return `string`
+	case bool:
+		return `boolean`
+	case json.Number, float64:
+		return `number`
+	}
+	return fmt.Sprintf(`%T`, value)
+}
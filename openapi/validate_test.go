@@ -0,0 +1,134 @@
+package openapi_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	api "github.com/rulesng/coinmetrics-go-sdk/api/v4"
+	"github.com/rulesng/coinmetrics-go-sdk/coinmetrics"
+	"github.com/rulesng/coinmetrics-go-sdk/constants"
+	"github.com/rulesng/coinmetrics-go-sdk/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	spec, err := openapi.Load([]byte(`
+openapi: 3.0.0
+components:
+  schemas:
+    Row:
+      type: object
+      required: [asset, time]
+      properties:
+        asset:
+          type: string
+        time:
+          type: string
+        height:
+          type: integer
+        status:
+          type: string
+          enum: [online, offline]
+        note:
+          type: string
+          nullable: true
+        value:
+          oneOf:
+            - type: number
+            - type: string
+    Response:
+      type: object
+      properties:
+        data:
+          type: array
+          items:
+            $ref: '#/components/schemas/Row'
+`))
+	assert.Nil(t, err)
+	schema := &openapi.Schema{Ref: `#/components/schemas/Response`}
+	violations, err := spec.ValidateJSON(schema, []byte(`{"data": [
+		{"asset": "btc", "time": "2022-01-01", "height": 1, "status": "online", "note": null, "value": "1.5"},
+		{"asset": "eth", "height": 1.5, "status": "lost", "value": true, "extra": 1}
+	]}`))
+	assert.Nil(t, err)
+	var found []string
+	for _, v := range violations {
+		found = append(found, v.String())
+	}
+	assert.Equal(t, []string{
+		`data[1].extra: ` + openapi.UnknownField,
+		`data[1].height: expected integer, got number`,
+		`data[1].status: ` + openapi.InvalidEnum + ` lost`,
+		`data[1].value: expected number, got boolean`,
+		`data[1].time: ` + openapi.MissingField,
+	}, found)
+
+	violations, err = spec.ValidateValue(schema, map[string]interface{}{`data`: nil})
+	assert.Nil(t, err)
+	assert.Equal(t, []openapi.Violation{{Path: `data`, Message: openapi.UnexpectedNil}}, violations)
+}
+
+func TestValidatingDoer(t *testing.T) {
+	spec, err := openapi.V4()
+	assert.Nil(t, err)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set(`Content-Type`, `application/json`)
+		_, _ = w.Write([]byte(`{"data": [{"asset": "btc", "full_name": "Bitcoin", "unknown": 1}]}`))
+	}))
+	defer ts.Close()
+
+	var reported []openapi.Violation
+	doer := openapi.NewValidatingDoer(nil, spec, openapi.WithReport(func(req *http.Request, violations []openapi.Violation) {
+		reported = append(reported, violations...)
+	}))
+	client, err := coinmetrics.InitClient(ts.URL+`/`, constants.TestKey, api.WithHTTPClient(doer))
+	assert.Nil(t, err)
+	res, err := client.GetCatalogAssetsWithResponse(context.Background(), &api.GetCatalogAssetsParams{})
+	assert.Nil(t, err)
+	if assert.NotNil(t, res.JSON200) {
+		assert.Equal(t, api.FullName(`Bitcoin`), res.JSON200.Data[0].FullName)
+	}
+	assert.Equal(t, []openapi.Violation{{Path: `data[0].unknown`, Message: openapi.UnknownField}}, reported)
+
+	doer = openapi.NewValidatingDoer(nil, spec, openapi.WithStrict(), openapi.WithReport(func(*http.Request, []openapi.Violation) {}))
+	client, err = coinmetrics.InitClient(ts.URL+`/`, constants.TestKey, api.WithHTTPClient(doer))
+	assert.Nil(t, err)
+	_, err = client.GetCatalogAssetsWithResponse(context.Background(), &api.GetCatalogAssetsParams{})
+	if assert.NotNil(t, err) {
+		assert.True(t, strings.Contains(err.Error(), constants.SchemaViolation))
+	}
+}
+
+func TestValidatingDoerUndecodableBody(t *testing.T) {
+	spec, err := openapi.V4()
+	assert.Nil(t, err)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set(`Content-Type`, `application/json`)
+		_, _ = w.Write([]byte(`{"data": [`))
+	}))
+	defer ts.Close()
+
+	var reported []openapi.Violation
+	doer := openapi.NewValidatingDoer(nil, spec, openapi.WithReport(func(req *http.Request, violations []openapi.Violation) {
+		reported = append(reported, violations...)
+	}))
+	req, err := http.NewRequest(http.MethodGet, ts.URL+`/`+constants.ApiVersion+`/catalog/assets`, nil)
+	assert.Nil(t, err)
+	res, err := doer.Do(req)
+	assert.Nil(t, err)
+	if assert.NotNil(t, res) {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+	if assert.Len(t, reported, 1) {
+		assert.Empty(t, reported[0].Path)
+	}
+
+	doer = openapi.NewValidatingDoer(nil, spec, openapi.WithStrict(), openapi.WithReport(func(*http.Request, []openapi.Violation) {}))
+	_, err = doer.Do(req)
+	if assert.NotNil(t, err) {
+		assert.True(t, strings.Contains(err.Error(), constants.SchemaViolation))
+	}
+}